@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var addFile = flag.String("file", "", "with add-field, target Go file relative to -path")
+var addStruct = flag.String("struct", "", "with add-field, struct that receives the field")
+var addName = flag.String("name", "", "with add-field, name of the field to add")
+var addType = flag.String("type", "", "with add-field, Go type of the field to add")
+var addTags = flag.String("tags", "", "with add-field, struct tags of the field, without backquotes")
+
+// runAddField 执行 add-field 子命令：按命令行参数在内存中构造一条规则并执行，
+// 用于不值得写进配置文件的一次性修改，返回进程退出码
+func runAddField() int {
+	if *addFile == "" || *addStruct == "" || *addName == "" || *addType == "" {
+		log.Printf("用法: astauto add-field -path directory -file model.go -struct User -name Email -type string [-tags 'json:\"email\"']")
+		return 1
+	}
+	field := logic.Field{Name: *addName, Type: *addType, Tags: logic.FieldTags(*addTags)}
+	if err := logic.ValidateField(field); err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+	config, err := logic.NewConfig(&logic.Config{
+		Rules: []*logic.Rule{{
+			File:    *addFile,
+			Structs: []logic.Struct{{Name: *addStruct, Fields: []logic.Field{field}}},
+		}},
+	})
+	if err != nil {
+		log.Printf("生成规则失败: %v", err)
+		return 1
+	}
+	return run(config)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var archivePath = flag.String("archive", "", "apply the rules to the Go sources inside a .zip, .tar or .tar.gz archive (such as a module zip) instead of a checkout; -path is then relative to the archive root")
+var archiveOut = flag.String("archive-out", "", "with -archive, write the modified archive here instead of replacing the input archive")
+
+// runArchive 把归档解压到临时目录，在其中执行规则，成功后按原格式重新打包，返回进程退出码。
+// 只输出编辑或评审建议时不写归档
+func runArchive(config *logic.Config) int {
+	if *hermetic {
+		log.Printf("-hermetic 模式不支持 -archive")
+		return 1
+	}
+	dir, err := os.MkdirTemp("", "astauto-archive-")
+	if err != nil {
+		log.Printf("创建临时目录失败: %v", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+	archive, err := logic.ExtractArchive(*archivePath, dir)
+	if err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+
+	// 临时目录随运行结束删除，不在其中保留运行锁等状态，也不把它打包进归档
+	*rootPath = filepath.Join(dir, *rootPath)
+	*noState = true
+	if code := run(config); code != 0 || *printEdits || *printSuggestions {
+		return code
+	}
+
+	out := *archivePath
+	if *archiveOut != "" {
+		out = *archiveOut
+	}
+	if err := archive.Write(out, dir); err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+	log.Printf("已写入归档 %s", out)
+	return 0
+}
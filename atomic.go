@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var atomicRun = flag.Bool("atomic-run", false, "verify every modified file (formatting and type-check of its package) before writing, and write all files or none; files already written are restored when a later write fails")
+
+// writeFilesAtomic 校验全部修改后再写回：先把新内容写到同目录的临时文件，全部成功后逐个改名替换，
+// 改名中途失败时恢复已替换文件的原始内容，删除已创建的文件
+func writeFilesAtomic(config *logic.Config, originals, contents map[string][]byte) error {
+	changed := changedFiles(originals, contents)
+	pending := make(map[string][]byte, len(changed))
+	for _, filename := range changed {
+		pending[filename] = contents[filename]
+	}
+	if err := logic.VerifyContents(pending, originals, config.GoVersion); err != nil {
+		return logic.WithCode(logic.CodeFormat, fmt.Errorf("校验失败，没有写入任何文件: %v", err))
+	}
+
+	temps := make(map[string]string, len(changed))
+	removeTemps := func() {
+		for _, temp := range temps {
+			os.Remove(temp)
+		}
+	}
+	for _, filename := range changed {
+		f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".astauto-*")
+		if err == nil {
+			temps[filename] = f.Name()
+			_, err = f.Write(contents[filename])
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err == nil {
+				err = os.Chmod(f.Name(), fileMode(filename))
+			}
+		}
+		if err != nil {
+			removeTemps()
+			return fmt.Errorf("写入文件失败，没有写入任何文件: %w", err)
+		}
+	}
+
+	existed := make(map[string]bool, len(changed))
+	for _, filename := range changed {
+		_, err := os.Stat(filename)
+		existed[filename] = err == nil
+	}
+	var done []string
+	for _, filename := range changed {
+		if err := os.Rename(temps[filename], filename); err != nil {
+			removeTemps()
+			for _, written := range done {
+				if existed[written] {
+					os.WriteFile(written, originals[written], fileMode(written))
+				} else {
+					os.Remove(written)
+				}
+			}
+			return fmt.Errorf("写入文件失败，已恢复写入过的文件: %w", err)
+		}
+		delete(temps, filename)
+		done = append(done, filename)
+		log.Printf("文件 %s 已成功修改并保存\n", filename)
+	}
+
+	if *stagedOnly {
+		for _, filename := range changed {
+			if err := stageFile(filename); err != nil {
+				return fmt.Errorf("重新暂存文件失败: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// fileMode 返回已有文件的权限，文件不存在时与 writeFiles 一样使用 0644
+func fileMode(filename string) os.FileMode {
+	if info, err := os.Stat(filename); err == nil {
+		return info.Mode().Perm()
+	}
+	return 0644
+}
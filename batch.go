@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var (
+	batchRepos   = flag.String("repos", "", "with batch, file listing one repository per line: a local path (relative to the file) or a git URL to clone; blank lines and # comments are ignored")
+	batchDir     = flag.String("batch-dir", "", "with batch, directory that receives the repositories cloned from git URLs; an existing clone is reused")
+	batchPatches = flag.String("patches", "", "with batch, directory that receives <repo>.patch (git diff) for every repository the run modified")
+)
+
+// runBatch 执行 batch 子命令：对仓库列表中的每个仓库（需要时先克隆）执行同一份配置，
+// -path 相对于各仓库的根目录。逐个输出执行汇总，可选地把每个仓库的改动写成补丁，
+// 最后列出各仓库的结果，返回进程退出码：任一仓库失败时为 1
+func runBatch() int {
+	if *batchRepos == "" {
+		log.Printf("batch 需要 -repos")
+		return 1
+	}
+	if *hermetic || *archivePath != "" {
+		log.Printf("batch 不支持 -hermetic 和 -archive")
+		return 1
+	}
+	config, err := logic.ParseConfig(*configPath)
+	if err != nil {
+		log.Printf("解析配置失败: %v", err)
+		return 1
+	}
+	repos, err := logic.ParseRepoList(*batchRepos, *batchDir)
+	if err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+	if *batchPatches != "" {
+		if err := os.MkdirAll(*batchPatches, 0755); err != nil {
+			log.Printf("创建补丁目录失败: %v", err)
+			return 1
+		}
+	}
+
+	root := *rootPath
+	statuses := make([]string, len(repos))
+	code := 0
+	for i, repo := range repos {
+		fmt.Printf("== %s (%s)\n", repo.Name, repo.Path)
+		status, err := batchRepo(config, repo, root)
+		if err != nil {
+			log.Printf("仓库 %s: %v", repo.Name, err)
+			status = "失败"
+			code = 1
+		}
+		statuses[i] = status
+	}
+	*rootPath = root
+
+	fmt.Println("批量执行结果:")
+	for i, repo := range repos {
+		fmt.Printf("  %-24s %s\n", repo.Name, statuses[i])
+	}
+	return code
+}
+
+// batchRepo 在一个仓库中执行配置，返回结果说明
+func batchRepo(config *logic.Config, repo logic.BatchRepo, root string) (string, error) {
+	if repo.URL != "" {
+		if _, err := os.Stat(repo.Path); os.IsNotExist(err) {
+			log.Printf("克隆 %s 到 %s", repo.URL, repo.Path)
+			cmd := exec.Command("git", "clone", "--quiet", repo.URL, repo.Path)
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				return "", fmt.Errorf("克隆失败: %v", err)
+			}
+		}
+	}
+	if info, err := os.Stat(repo.Path); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s 不是目录", repo.Path)
+	}
+	// 运行前已有未提交的改动时补丁中也会包含它们
+	dirty, _ := gitOutput(repo.Path, "status", "--porcelain")
+	if dirty != "" && *batchPatches != "" {
+		log.Printf("仓库 %s 有未提交的改动，补丁中会包含这些改动", repo.Name)
+	}
+
+	*rootPath = filepath.Join(repo.Path, root)
+	if code := run(config); code != 0 {
+		return "", fmt.Errorf("执行规则失败，退出码 %d", code)
+	}
+	if *batchPatches == "" {
+		return "成功", nil
+	}
+	diff, err := gitOutput(repo.Path, "diff")
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "成功，没有改动", nil
+	}
+	patch := filepath.Join(*batchPatches, repo.Name+".patch")
+	if err := os.WriteFile(patch, []byte(diff+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("写入补丁失败: %v", err)
+	}
+	return "成功，补丁 " + patch, nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// runCheck 执行 check 子命令：在内存中执行所有规则而不写回文件，
+// 报告文件与配置的偏差、无效配置和被跳过的规则，返回进程退出码
+func runCheck() int {
+	return writeFindings(checkFindings())
+}
+
+// writeFindings 按 -output 输出问题列表，有错误级别的问题时返回 1
+func writeFindings(findings []logic.Finding) int {
+	var err error
+	switch *outputFormat {
+	case "sarif":
+		err = logic.WriteSARIF(os.Stdout, findings)
+	case "github":
+		err = logic.WriteGitHub(os.Stdout, findings)
+	case "text":
+		err = logic.WriteText(os.Stdout, findings)
+	default:
+		log.Printf("不支持的输出格式: %s", *outputFormat)
+		return 1
+	}
+	if err != nil {
+		log.Printf("输出检查结果失败: %v", err)
+		return 1
+	}
+
+	if logic.Failed(findings) {
+		return 1
+	}
+	return 0
+}
+
+// checkFindings 解析配置并在内存中执行所有规则，收集发现的问题
+func checkFindings() []logic.Finding {
+	config, err := loadSyncedConfig()
+	if err != nil {
+		return []logic.Finding{{
+			Kind:    logic.FindingInvalid,
+			Level:   logic.LevelError,
+			Code:    logic.CodeConfig,
+			File:    *configPath,
+			Message: fmt.Sprintf("解析配置失败: %v", err),
+		}}
+	}
+
+	selected, err := ruleFilter()
+	if err != nil {
+		return []logic.Finding{{
+			Kind:    logic.FindingInvalid,
+			Level:   logic.LevelError,
+			Message: fmt.Sprintf("获取暂存文件失败: %v", err),
+		}}
+	}
+
+	var findings []logic.Finding
+	contents := make(map[string][]byte)
+	processed := make(map[string]bool)
+	defer quietLogs()()
+	for _, rule := range config.Rules {
+		if !selected(rule) {
+			continue
+		}
+		file := filepath.Join(*rootPath, rule.File)
+		start := time.Now()
+		result, err := applyRule(config, rule, contents)
+		if err != nil {
+			metrics.ObserveError(errorType(err))
+			finding := logic.Finding{
+				Kind:    logic.FindingInvalid,
+				Level:   logic.LevelError,
+				Code:    errorType(err),
+				Rule:    rule.Name(),
+				File:    file,
+				Message: fmt.Sprintf("规则 %s 执行失败: %v", rule.Name(), err),
+			}
+			if syntaxErr := syntaxError(err); syntaxErr != nil {
+				finding.Line = syntaxErr.Pos().Line
+			}
+			findings = append(findings, finding)
+			continue
+		}
+		metrics.ObserveRule(time.Since(start), result.Skipped)
+		if result.Skipped {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingSkipped,
+				Level:   logic.LevelNote,
+				Rule:    rule.Name(),
+				File:    file,
+				Message: fmt.Sprintf("规则 %s 的条件不满足，已跳过", rule.Name()),
+			})
+			continue
+		}
+		processed[result.Filename] = true
+		findings = append(findings, resultFindings(result, file)...)
+		if result.Modified() {
+			contents[result.Filename] = result.After
+			for _, edit := range result.Others {
+				contents[edit.Filename] = edit.After
+			}
+		}
+	}
+	metrics.ObserveProcessed(len(processed))
+	return findings
+}
+
+// resultFindings 将规则执行结果转换为问题列表
+func resultFindings(result *ruleResult, file string) []logic.Finding {
+	var findings []logic.Finding
+	name := result.Rule.Name()
+	if result.Partial != nil {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingInvalid,
+			Level:   logic.LevelWarning,
+			Rule:    name,
+			File:    file,
+			Line:    result.Partial.Pos().Line,
+			Message: fmt.Sprintf("目标文件有语法错误，只检查了导入: %v", result.Partial),
+		})
+	}
+	for _, st := range result.Missing {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingSkipped,
+			Level:   logic.LevelWarning,
+			Rule:    name,
+			File:    file,
+			Message: fmt.Sprintf("文件中没有找到结构体 %s", st),
+		})
+	}
+	for _, change := range result.Changes {
+		for _, field := range change.Removed {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingDrift,
+				Level:   logic.LevelError,
+				Rule:    name,
+				File:    file,
+				Line:    change.Line,
+				Message: fmt.Sprintf("结构体 %s 的字段 %s 应当删除", change.Struct, field),
+			})
+		}
+		for _, method := range change.RemovedMethods {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingDrift,
+				Level:   logic.LevelError,
+				Rule:    name,
+				File:    file,
+				Line:    change.Line,
+				Message: fmt.Sprintf("结构体 %s 的方法 %s 应当删除", change.Struct, method),
+			})
+		}
+		for _, rn := range change.Receivers {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingDrift,
+				Level:   logic.LevelError,
+				Rule:    name,
+				File:    file,
+				Message: fmt.Sprintf("结构体 %s 的方法接收者应当改名: %s", change.Struct, rn),
+			})
+		}
+		for _, rn := range change.Renamed {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingDrift,
+				Level:   logic.LevelError,
+				Rule:    name,
+				File:    file,
+				Line:    change.Line,
+				Message: fmt.Sprintf("结构体 %s 的字段标签应当修改: %s", change.Struct, rn),
+			})
+		}
+		for _, field := range change.Deprecated {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingDrift,
+				Level:   logic.LevelError,
+				Rule:    name,
+				File:    file,
+				Line:    change.Line,
+				Message: fmt.Sprintf("结构体 %s 的字段 %s 应当标记为废弃", change.Struct, field),
+			})
+		}
+		for _, w := range change.Widened {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingDrift,
+				Level:   logic.LevelError,
+				Rule:    name,
+				File:    file,
+				Line:    change.Line,
+				Message: fmt.Sprintf("结构体 %s 的字段类型应当放宽: %s", change.Struct, w),
+			})
+		}
+		if change.RenamedTo != "" {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingDrift,
+				Level:   logic.LevelError,
+				Rule:    name,
+				File:    file,
+				Line:    change.Line,
+				Message: fmt.Sprintf("结构体 %s 应当改名为 %s", change.Struct, change.RenamedTo),
+			})
+		}
+		for _, field := range change.Added {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingDrift,
+				Level:   logic.LevelError,
+				Rule:    name,
+				File:    file,
+				Line:    change.Line,
+				Message: fmt.Sprintf("结构体 %s 缺少字段 %s", change.Struct, field),
+			})
+		}
+	}
+	// 字段已存在但类型与配置不同，plan 中显示为 conflict
+	for _, existing := range result.Existing {
+		if existing.Action != logic.PlanConflict {
+			continue
+		}
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingDrift,
+			Level:   logic.LevelError,
+			Rule:    name,
+			File:    file,
+			Message: fmt.Sprintf("字段 %s 的类型与配置不一致: %s", existing.Target, existing.Detail),
+		})
+	}
+	for _, clash := range result.Clashes {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingDuplicateName,
+			Level:   logic.LevelError,
+			Rule:    name,
+			File:    file,
+			Line:    clash.Line,
+			Message: clash.String(),
+		})
+	}
+	for _, path := range result.Imports {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingDrift,
+			Level:   logic.LevelError,
+			Rule:    name,
+			File:    file,
+			Message: fmt.Sprintf("缺少导入 %s", path),
+		})
+	}
+	for _, edit := range result.Others {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingDrift,
+			Level:   logic.LevelError,
+			Rule:    name,
+			File:    edit.Filename,
+			Message: "文件需要" + edit.Reason,
+		})
+	}
+	if len(result.Regenerated) > 0 {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingDrift,
+			Level:   logic.LevelError,
+			Rule:    name,
+			File:    file,
+			Message: fmt.Sprintf("声明 %s 与字段不一致，需要重新生成", strings.Join(result.Regenerated, ", ")),
+		})
+	}
+	if result.Header {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingDrift,
+			Level:   logic.LevelError,
+			Rule:    name,
+			File:    file,
+			Line:    1,
+			Message: "文件缺少文件头",
+		})
+	}
+	if result.Footer {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingDrift,
+			Level:   logic.LevelError,
+			Rule:    name,
+			File:    file,
+			Message: "文件末尾的来源注释需要更新",
+		})
+	}
+	if len(result.Decls) > 0 {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingDrift,
+			Level:   logic.LevelError,
+			Rule:    name,
+			File:    file,
+			Message: fmt.Sprintf("缺少声明 %s", strings.Join(result.Decls, ", ")),
+		})
+	}
+
+	// 其余差异（例如格式）统一报告为文件不同步
+	if len(findings) == 0 && result.Modified() {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingDrift,
+			Level:   logic.LevelError,
+			Rule:    name,
+			File:    file,
+			Message: "文件与配置不同步",
+		})
+	}
+	return findings
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var cleanManaged = flag.Bool("clean", false, "before applying, remove every field and declaration marked astauto:managed from the target files so managed rules regenerate them")
+
+// cleanFiles 删除各规则目标文件中带标记的字段和声明，结果记入内存中的文件内容，
+// 与规则的修改一起写回；每个文件只处理一次，不存在的文件跳过
+func cleanFiles(config *logic.Config, selected func(*logic.Rule) bool, contents, originals map[string][]byte) error {
+	cleaned := make(map[string]bool)
+	for _, rule := range config.Rules {
+		filename := filepath.Join(*rootPath, rule.File)
+		if !selected(rule) || cleaned[filename] {
+			continue
+		}
+		cleaned[filename] = true
+
+		src, err := os.ReadFile(filename)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("读取文件失败: %w", err)
+		}
+		bom, body, err := logic.SplitBOM(src)
+		if err != nil {
+			return fmt.Errorf("文件 %s 编码不受支持: %v", rule.File, err)
+		}
+		out, n, err := logic.StripManaged(body)
+		if err != nil {
+			return fmt.Errorf("清理文件 %s 失败: %v", rule.File, err)
+		}
+		if n == 0 {
+			continue
+		}
+		originals[filename] = src
+		contents[filename] = append(bom, out...)
+		log.Printf("已从文件 %s 删除 %d 处带标记的内容", rule.File, n)
+	}
+	return nil
+}
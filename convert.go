@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var (
+	convertFrom      = flag.String("from", "", "with convert, config file to read (TOML, JSON, YAML, CUE, HCL or Starlark by extension)")
+	convertTo        = flag.String("to", "", "with convert, config file to write; the format (TOML, JSON or YAML) follows the extension")
+	convertOverwrite = flag.Bool("overwrite", false, "with convert, overwrite an existing -to file")
+)
+
+// runConvert 执行 convert 子命令：把 -from 的配置按原样（不展开 extends、include 和预设）转换为 -to 的格式，
+// 返回进程退出码
+func runConvert() int {
+	if *convertFrom == "" || *convertTo == "" {
+		log.Printf("convert 需要 -from 和 -to")
+		return 1
+	}
+	// 不覆盖已有的配置
+	if _, err := os.Stat(*convertTo); err == nil && !*convertOverwrite {
+		log.Printf("配置 %s 已存在，使用 -overwrite 覆盖", *convertTo)
+		return 1
+	}
+	data, lost, err := logic.ConvertConfig(*convertFrom, *convertTo)
+	if err != nil {
+		log.Printf("转换配置失败: %v", err)
+		return 1
+	}
+	if err := os.WriteFile(*convertTo, data, 0644); err != nil {
+		log.Printf("写入配置失败: %v", err)
+		return 1
+	}
+	if lost > 0 {
+		log.Printf("有 %d 段注释无法保留", lost)
+	}
+	log.Printf("已把配置 %s 转换为 %s", *convertFrom, *convertTo)
+	return 0
+}
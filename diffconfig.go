@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// runDiffConfig 执行 diff-config 子命令：按规则、结构体和字段对比两份配置文件，
+// 列出新增、删除和修改的内容，便于评审配置变更，返回进程退出码
+func runDiffConfig(args []string) int {
+	if len(args) != 2 {
+		log.Printf("用法: astauto diff-config [-output text|json] old.toml new.toml")
+		return 1
+	}
+	var configs [2]*logic.Config
+	for i, filename := range args {
+		config, err := logic.ParseConfig(filename)
+		if err != nil {
+			log.Printf("解析配置 %s 失败: %v", filename, err)
+			return 1
+		}
+		configs[i] = config
+	}
+
+	changes := logic.DiffConfig(configs[0], configs[1])
+	var err error
+	switch *outputFormat {
+	case "json":
+		if changes == nil {
+			changes = []logic.ConfigChange{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(changes)
+	case "text":
+		err = logic.WriteConfigDiff(os.Stdout, changes)
+	default:
+		log.Printf("不支持的输出格式: %s", *outputFormat)
+		return 1
+	}
+	if err != nil {
+		log.Printf("输出配置差异失败: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var printEdits = flag.Bool("print-edits", false, "print the changes as JSON text edits against the files on disk instead of writing them")
+var printSuggestions = flag.Bool("suggestions", false, "print the changes as JSON review suggestions (path, line range, replacement) for code review bots instead of writing them")
+
+// fileEdits 是一个文件上的全部文本编辑
+type fileEdits struct {
+	File  string           `json:"file"`
+	Edits []logic.TextEdit `json:"edits"`
+}
+
+// writeEdits 以 JSON 输出各个修改过的文件相对于磁盘内容的文本编辑，不写回文件，
+// 供编辑器等工具按位置应用修改
+func writeEdits(originals, contents map[string][]byte) error {
+	files := []fileEdits{}
+	for _, filename := range changedFiles(originals, contents) {
+		files = append(files, fileEdits{
+			File:  relPaths([]string{filename})[0],
+			Edits: logic.ComputeEdits(originals[filename], contents[filename]),
+		})
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(files)
+}
+
+// writeSuggestions 以 JSON 输出各个修改过的文件按整行替换的评审建议，不写回文件，
+// 供代码评审机器人逐条创建修改建议
+func writeSuggestions(originals, contents map[string][]byte) error {
+	suggestions := []logic.Suggestion{}
+	for _, filename := range changedFiles(originals, contents) {
+		path := relPaths([]string{filename})[0]
+		suggestions = append(suggestions, logic.Suggestions(path, originals[filename], contents[filename])...)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(suggestions)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var exportFormat = flag.String("format", "json", "output format of the export command: json")
+
+// runExport 执行 export 子命令：只读地导出配置中所有结构体的当前状态（字段、类型、标签、位置），
+// 供其他生成器和文档工具使用，返回进程退出码
+func runExport() int {
+	if *exportFormat != "json" {
+		log.Printf("不支持的导出格式: %s", *exportFormat)
+		return 1
+	}
+	config, err := loadConfig()
+	if err != nil {
+		log.Printf("解析配置失败: %v", err)
+		return 1
+	}
+
+	structs, err := exportStructs(config)
+	if err != nil {
+		log.Printf("导出失败: %v", err)
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(structs); err != nil {
+		log.Printf("输出导出结果失败: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// exportStructs 读取各规则目标文件中配置的结构体，多条规则引用同一结构体时合并为一项
+func exportStructs(config *logic.Config) ([]*logic.StructInfo, error) {
+	structs := []*logic.StructInfo{}
+	seen := make(map[string]*logic.StructInfo)
+	for _, rule := range config.Rules {
+		filename := filepath.Join(*rootPath, rule.File)
+		src, err := os.ReadFile(filename)
+		if os.IsNotExist(err) {
+			log.Printf("文件 %s 不存在，跳过规则 %s", rule.File, rule.Name())
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %v", err)
+		}
+		_, src, err = logic.SplitBOM(src)
+		if err != nil {
+			return nil, fmt.Errorf("文件 %s 编码不受支持: %v", rule.File, err)
+		}
+		for _, st := range rule.Structs {
+			key := rule.File + "\x00" + st.Name
+			if info, ok := seen[key]; ok {
+				info.Rules = append(info.Rules, rule.Name())
+				continue
+			}
+			info, err := logic.ExportStruct(rule.File, src, st.Name)
+			if err != nil {
+				return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+			}
+			if info == nil {
+				log.Printf("文件 %s 中没有找到结构体 %s", rule.File, st.Name)
+				continue
+			}
+			info.Rules = []string{rule.Name()}
+			seen[key] = info
+			structs = append(structs, info)
+		}
+	}
+	return structs, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+
+	"github.com/afantree/astauto/logic"
+	"golang.org/x/tools/imports"
+)
+
+var formatPackage = flag.Bool("format-package", false, "after edits, run goimports over every file of the touched packages")
+
+// formatPackages 对修改过的文件所在包的全部 Go 文件执行 goimports（格式化并整理导入），返回被重写的文件。
+// 格式化在内存中进行：结果写入 contents，原内容记入 originals，与规则的修改一起检查修改量、写回和重新暂存。
+// 带有 Code generated 头的文件除非指定 -include-generated，否则不格式化
+func formatPackages(results []*ruleResult, originals, contents map[string][]byte) ([]string, error) {
+	dirs := make(map[string]bool)
+	for _, result := range results {
+		if result.Modified() {
+			dirs[filepath.Dir(result.Filename)] = true
+		}
+	}
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted)
+
+	var formatted []string
+	for _, dir := range sorted {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			return formatted, err
+		}
+		for _, filename := range files {
+			src, err := readSource(filename, contents)
+			if err != nil {
+				return formatted, err
+			}
+			if !*includeGenerated && logic.IsGeneratedSource(src) {
+				continue
+			}
+			out, err := imports.Process(filename, src, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+			if err != nil {
+				return formatted, fmt.Errorf("格式化文件 %s 失败: %v", filename, err)
+			}
+			if bytes.Equal(src, out) {
+				continue
+			}
+			if _, ok := originals[filename]; !ok {
+				originals[filename] = src
+			}
+			contents[filename] = out
+			log.Printf("已格式化文件 %s", filename)
+			formatted = append(formatted, filename)
+		}
+	}
+	return formatted, nil
+}
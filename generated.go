@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// syncGeneratedDecls 按结构体当前的字段生成 ToMap、FromMap、getter、setter、Redacted 和列名声明，追加缺少的声明并替换过时的声明，
+// FromMap 需要的 fmt 导入一起补上
+func syncGeneratedDecls(rule *logic.Rule, result *ruleResult, src *[]byte) error {
+	methods, err := logic.MapMethods(*src, rule.MapSpecs, rule.GoVersion)
+	if err != nil {
+		return err
+	}
+	columns, err := logic.Columns(*src, rule.ColumnSpecs)
+	if err != nil {
+		return err
+	}
+	accessors, err := logic.Accessors(*src, rule.AccessorSpecs, rule.Codegen)
+	if err != nil {
+		return err
+	}
+	redacted, err := logic.Redact(*src, rule.RedactSpecs)
+	if err != nil {
+		return err
+	}
+	code := methods + accessors + redacted + columns
+	if code == "" {
+		return nil
+	}
+	if rule.Managed {
+		if code, err = logic.MarkDecls(code, rule.Name()); err != nil {
+			return err
+		}
+	}
+	out, added, replaced, err := logic.ReplaceDecls(*src, code)
+	if err != nil {
+		return fmt.Errorf("生成声明失败: %v", err)
+	}
+	if len(added) == 0 && len(replaced) == 0 {
+		return nil
+	}
+	if strings.Contains(code, "fmt.Errorf") {
+		var imports []string
+		out, imports, _, err = logic.AddImports(out, []logic.Import{{Path: "fmt"}}, rule.ImportConflict)
+		if err != nil {
+			return fmt.Errorf("添加导入失败: %v", err)
+		}
+		result.Imports = append(result.Imports, imports...)
+	}
+	for _, name := range added {
+		log.Printf("成功插入声明 %s", name)
+	}
+	for _, name := range replaced {
+		log.Printf("已按字段重新生成 %s", name)
+	}
+	result.Decls = append(result.Decls, added...)
+	result.Regenerated = append(result.Regenerated, replaced...)
+	*src = out
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var hermetic = flag.Bool("hermetic", false, "run as a build-system generator: -path, -conf and -out must be given explicitly, results are written under -out instead of in place, and no state, changelog, git or network access is used")
+var outDir = flag.String("out", "", "with -hermetic, directory that receives every target file of the run, at the same path relative to -path")
+
+// checkHermetic 检查 -hermetic 模式的参数：输入和输出路径必须显式给出，
+// 会写入源码树、调用 git 或访问网络的选项不能同时使用。通过后禁止 go 命令下载模块
+func checkHermetic() error {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range []string{"path", "conf", "out"} {
+		if !set[name] {
+			return fmt.Errorf("-hermetic 模式必须显式指定 -%s", name)
+		}
+	}
+	for _, name := range []string{"format-package", "staged-only"} {
+		if set[name] {
+			return fmt.Errorf("-hermetic 模式不支持 -%s", name)
+		}
+	}
+	if logic.IsRemoteConfig(*configPath) {
+		return fmt.Errorf("-hermetic 模式不能访问网络，-conf 必须是本地文件")
+	}
+	logic.Offline = true
+	// 类型检查可能经 go 命令解析依赖，禁止它访问模块代理
+	os.Setenv("GOPROXY", "off")
+	os.Setenv("GOFLAGS", "-mod=readonly")
+	return nil
+}
+
+// writeOutputs 把本次运行涉及的全部文件写到 -out 目录下，未修改的文件也原样写出，
+// 使构建系统声明的输出总是存在
+func writeOutputs(results []*ruleResult, contents map[string][]byte) error {
+	files := make(map[string]bool)
+	for _, result := range results {
+		files[result.Filename] = true
+		for _, edit := range result.Others {
+			files[edit.Filename] = true
+		}
+	}
+	for filename := range files {
+		rel, err := filepath.Rel(*rootPath, filename)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("文件 %s 不在处理目录 %s 中，无法写入输出目录", filename, *rootPath)
+		}
+		src, err := readSource(filename, contents)
+		if err != nil {
+			return err
+		}
+		out := filepath.Join(*outDir, rel)
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			return fmt.Errorf("创建输出目录失败: %v", err)
+		}
+		if err := os.WriteFile(out, src, 0644); err != nil {
+			return fmt.Errorf("写入输出文件失败: %w", err)
+		}
+	}
+	return nil
+}
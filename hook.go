@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var stagedOnly = flag.Bool("staged-only", false, "only process rules whose target file is staged in git; modified files are staged again")
+var forceHook = flag.Bool("force", false, "overwrite an existing pre-commit hook not written by astauto, or with init an existing config file")
+
+// hookMarker 标记由 astauto 生成的钩子脚本，用于判断能否覆盖
+const hookMarker = "# astauto pre-commit hook"
+
+// runHook 执行 hook 子命令，目前只支持 hook install
+func runHook(args []string) int {
+	if len(args) == 0 || args[0] != "install" {
+		log.Printf("用法: astauto hook install [-path directory] [-conf config.toml] [-force]")
+		return 1
+	}
+	flag.CommandLine.Parse(args[1:])
+
+	config, err := logic.ParseConfig(*configPath)
+	if err != nil {
+		log.Printf("解析配置失败，检查根目录下面的配置: %v", err)
+		return 1
+	}
+
+	top, err := gitOutput(*rootPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		log.Printf("查找 git 仓库失败: %v", err)
+		return 1
+	}
+	hooksDir, err := gitOutput(*rootPath, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		log.Printf("查找 git 钩子目录失败: %v", err)
+		return 1
+	}
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(*rootPath, hooksDir)
+	}
+	hookFile := filepath.Join(hooksDir, "pre-commit")
+
+	// 不覆盖用户手写的钩子
+	if data, err := os.ReadFile(hookFile); err == nil && !bytes.Contains(data, []byte(hookMarker)) && !*forceHook {
+		log.Printf("钩子 %s 已存在且不是由 astauto 生成，使用 -force 覆盖", hookFile)
+		return 1
+	}
+
+	script, err := hookScript(config.Hook, top)
+	if err != nil {
+		log.Printf("生成钩子脚本失败: %v", err)
+		return 1
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		log.Printf("创建钩子目录失败: %v", err)
+		return 1
+	}
+	if err := os.WriteFile(hookFile, []byte(script), 0755); err != nil {
+		log.Printf("写入钩子失败: %v", err)
+		return 1
+	}
+	log.Printf("已安装 pre-commit 钩子: %s", hookFile)
+	return 0
+}
+
+// hookScript 生成 pre-commit 钩子脚本，钩子在仓库根目录执行，路径均转换为相对根目录
+func hookScript(hook logic.Hook, top string) (string, error) {
+	path, err := relToTop(top, *rootPath)
+	if err != nil {
+		return "", err
+	}
+	conf, err := relToTop(top, *configPath)
+	if err != nil {
+		return "", err
+	}
+	command := hook.Command
+	if command == "" {
+		command = "astauto"
+	}
+	common := fmt.Sprintf("-path %s -conf %s -staged-only", shellQuote(path), shellQuote(conf))
+
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString(hookMarker + "，由 astauto hook install 生成，重新安装会覆盖本文件\n")
+	sb.WriteString("set -e\n")
+	if hook.Fix {
+		sb.WriteString(fmt.Sprintf("%s %s\n", command, common))
+	}
+	check := fmt.Sprintf("%s check %s", command, common)
+	for _, arg := range hook.Args {
+		check += " " + shellQuote(arg)
+	}
+	sb.WriteString(check + "\n")
+	return sb.String(), nil
+}
+
+// relToTop 将路径转换为相对仓库根目录的路径
+func relToTop(top, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// shellQuote 为 shell 脚本中的参数加上单引号
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// gitOutput 在指定目录执行 git 命令并返回去掉首尾空白的输出
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// stagedFiles 返回 git 暂存区中新增或修改的文件（绝对路径）
+func stagedFiles() (map[string]bool, error) {
+	top, err := gitOutput(*rootPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	out, err := gitOutput(*rootPath, "diff", "--cached", "--name-only", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		if line != "" {
+			files[filepath.Join(top, line)] = true
+		}
+	}
+	return files, nil
+}
+
+// ruleFilter 返回判断规则是否需要处理的函数，未指定 -staged-only 时处理所有规则
+func ruleFilter() (func(rule *logic.Rule) bool, error) {
+	if !*stagedOnly {
+		return tagSelected, nil
+	}
+	staged, err := stagedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return func(rule *logic.Rule) bool {
+		abs, err := filepath.Abs(filepath.Join(*rootPath, rule.File))
+		return err == nil && staged[abs] && tagSelected(rule)
+	}, nil
+}
+
+// stageFile 将修改后的文件重新加入暂存区
+func stageFile(filename string) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	_, err = gitOutput(*rootPath, "add", "--", abs)
+	return err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// runInit 执行 init 子命令：在 -conf 指定的位置写入带注释的初始配置，
+// 给出 Go 文件（相对 -path）时扫描其中的结构体生成对应的规则，返回进程退出码
+func runInit(files []string) int {
+	var targets []logic.ScaffoldTarget
+	for _, file := range files {
+		src, err := os.ReadFile(filepath.Join(*rootPath, file))
+		if err != nil {
+			log.Printf("读取文件失败: %v", err)
+			return 1
+		}
+		_, src, err = logic.SplitBOM(src)
+		if err != nil {
+			log.Printf("文件 %s 编码不受支持: %v", file, err)
+			return 1
+		}
+		names, err := logic.StructNames(src)
+		if err != nil {
+			log.Printf("文件 %s: %v", file, err)
+			return 1
+		}
+		if len(names) == 0 {
+			log.Printf("文件 %s 中没有结构体，跳过", file)
+			continue
+		}
+		targets = append(targets, logic.ScaffoldTarget{File: filepath.ToSlash(file), Structs: names})
+	}
+	if len(files) > 0 && len(targets) == 0 {
+		log.Printf("给出的文件中没有结构体")
+		return 1
+	}
+
+	// 不覆盖已有的配置
+	if _, err := os.Stat(*configPath); err == nil && !*forceHook {
+		log.Printf("配置 %s 已存在，使用 -force 覆盖", *configPath)
+		return 1
+	}
+	if err := os.WriteFile(*configPath, []byte(logic.ScaffoldConfig(targets)), 0644); err != nil {
+		log.Printf("写入配置失败: %v", err)
+		return 1
+	}
+	log.Printf("已生成配置 %s", *configPath)
+	return 0
+}
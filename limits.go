@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var maxChangedFiles = flag.Int("max-changed-files", 0, "abort before writing if the run would modify more than this many files (0 means no limit)")
+var maxChangedLines = flag.Int("max-changed-lines", 0, "abort before writing if the run would add or remove more than this many lines in total (0 means no limit)")
+
+// changedFiles 返回内容与磁盘上原始内容不同的文件，按文件名排序
+func changedFiles(originals, contents map[string][]byte) []string {
+	var files []string
+	for filename, src := range contents {
+		if string(src) != string(originals[filename]) {
+			files = append(files, filename)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// checkLimits 检查本次运行的修改量是否超过安全阈值，超过时返回错误，调用方不应写回任何文件
+func checkLimits(originals, contents map[string][]byte) error {
+	files := changedFiles(originals, contents)
+	if *maxChangedFiles > 0 && len(files) > *maxChangedFiles {
+		return fmt.Errorf("本次运行将修改 %d 个文件，超过 -max-changed-files=%d，没有写入任何文件: %s",
+			len(files), *maxChangedFiles, strings.Join(relPaths(files), ", "))
+	}
+	if *maxChangedLines > 0 {
+		lines := 0
+		for _, filename := range files {
+			lines += logic.ChangedLines(originals[filename], contents[filename])
+		}
+		if lines > *maxChangedLines {
+			return fmt.Errorf("本次运行将增删 %d 行，超过 -max-changed-lines=%d，没有写入任何文件", lines, *maxChangedLines)
+		}
+	}
+	return nil
+}
+
+// relPaths 将文件路径转换为相对于处理目录的路径
+func relPaths(files []string) []string {
+	rel := make([]string, 0, len(files))
+	for _, filename := range files {
+		if r, err := filepath.Rel(*rootPath, filename); err == nil {
+			filename = r
+		}
+		rel = append(rel, filename)
+	}
+	return rel
+}
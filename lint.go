@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// runLint 执行 lint 子命令：对规则选中的结构体执行内置的标签检查
+// （json 命名风格、gorm 列名、缺少 db 标签），输出格式与 check 相同，返回进程退出码
+func runLint() int {
+	return writeFindings(lintFindings())
+}
+
+// lintFindings 解析配置，按规则的文件、结构体和执行条件选出结构体并检查它们的标签
+func lintFindings() []logic.Finding {
+	config, err := loadConfig()
+	if err != nil {
+		return []logic.Finding{{
+			Kind:    logic.FindingInvalid,
+			Level:   logic.LevelError,
+			File:    *configPath,
+			Message: fmt.Sprintf("解析配置失败: %v", err),
+		}}
+	}
+	selected, err := ruleFilter()
+	if err != nil {
+		return []logic.Finding{{
+			Kind:    logic.FindingInvalid,
+			Level:   logic.LevelError,
+			Message: fmt.Sprintf("获取暂存文件失败: %v", err),
+		}}
+	}
+
+	var findings []logic.Finding
+	invalid := func(rule *logic.Rule, file string, err error) {
+		findings = append(findings, logic.Finding{
+			Kind:    logic.FindingInvalid,
+			Level:   logic.LevelError,
+			Rule:    rule.Name(),
+			File:    file,
+			Message: fmt.Sprintf("规则 %s 无法检查: %v", rule.Name(), err),
+		})
+	}
+	// 同一结构体可能被多条规则选中，只检查一次
+	linted := make(map[string]bool)
+	for _, rule := range config.Rules {
+		if !selected(rule) {
+			continue
+		}
+		filename := filepath.Join(*rootPath, rule.File)
+		src, err := readSource(filename, nil)
+		if err != nil {
+			invalid(rule, filename, err)
+			continue
+		}
+		_, src, err = logic.SplitBOM(src)
+		if err != nil {
+			invalid(rule, filename, err)
+			continue
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			invalid(rule, filename, logic.NewSyntaxError(err))
+			continue
+		}
+		fileEnv := logic.FileEnv(rule.File, file)
+		if rule.When != "" {
+			if ok, err := logic.EvalBool(rule.When, fileEnv); err != nil || !ok {
+				continue
+			}
+		}
+
+		wanted := make(map[string]logic.Struct)
+		for _, st := range rule.Structs {
+			name, err := logic.ResolveStruct(fset, filename, file, st.Name, rule.GoVersion)
+			if err != nil {
+				invalid(rule, filename, err)
+				continue
+			}
+			wanted[name] = st
+		}
+		logic.WalkStructs(file, func(path string, structType *ast.StructType) {
+			st, ok := wanted[path]
+			if !ok || linted[filename+":"+path] {
+				return
+			}
+			if st.When != "" {
+				if ok, err := logic.EvalBool(st.When, logic.StructEnv(fileEnv, path, structType)); err != nil || !ok {
+					return
+				}
+			}
+			linted[filename+":"+path] = true
+			for _, finding := range logic.LintStruct(fset, path, structType, config.Lint) {
+				finding.Rule = rule.Name()
+				finding.File = filename
+				findings = append(findings, finding)
+			}
+		})
+	}
+	return findings
+}
@@ -0,0 +1,153 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 生成方法的接收者类型
+const (
+	ReceiverPointer = "pointer"
+	ReceiverValue   = "value"
+)
+
+// setter 的风格
+const (
+	// SetterSet 生成 SetName(v)，修改接收者
+	SetterSet = "set"
+	// SetterWith 生成 WithName(v)，返回修改后的结构体，便于链式调用
+	SetterWith = "with"
+	// SetterNone 不生成 setter
+	SetterNone = "none"
+)
+
+// Codegen 结构体表示生成方法的命名约定，不同代码库的习惯不同，由配置中的 [codegen] 决定
+type Codegen struct {
+	// GetterPrefix getter 名称的前缀，默认为 Get；设为空字符串时 getter 与字段同名，只能用于未导出字段
+	GetterPrefix *string `json:"getter_prefix" toml:"getter_prefix"`
+	// Setter setter 的风格：set（默认）、with 或 none
+	Setter string `json:"setter" toml:"setter"`
+	// Receiver 不修改结构体的方法（getter、ToMap 和 with 风格的 setter）的接收者：pointer（默认）或 value。
+	// set 风格的 setter 和 FromMap 总是使用指针接收者
+	Receiver string `json:"receiver" toml:"receiver"`
+}
+
+// Validate 检查命名约定的配置
+func (c Codegen) Validate() error {
+	switch c.Setter {
+	case "", SetterSet, SetterWith, SetterNone:
+	default:
+		return fmt.Errorf("codegen.setter %q 无效，只支持 set、with 和 none", c.Setter)
+	}
+	switch c.Receiver {
+	case "", ReceiverPointer, ReceiverValue:
+	default:
+		return fmt.Errorf("codegen.receiver %q 无效，只支持 pointer 和 value", c.Receiver)
+	}
+	if p := c.getterPrefix(); p != "" && !token.IsIdentifier(p) {
+		return fmt.Errorf("codegen.getter_prefix %q 不是有效的标识符", p)
+	}
+	return nil
+}
+
+// getterPrefix 返回 getter 的前缀
+func (c Codegen) getterPrefix() string {
+	if c.GetterPrefix == nil {
+		return "Get"
+	}
+	return *c.GetterPrefix
+}
+
+// ValueReceiver 返回不修改结构体的方法是否使用值接收者
+func (c Codegen) ValueReceiver() bool {
+	return c.Receiver == ReceiverValue
+}
+
+// Accessors 按源码中结构体当前的具名字段生成 getter 和 setter 的源码：结构体名 -> 接收者名称（为空时使用类型名的首字母小写）。
+// 方法名按 codegen 的约定生成，与结构体的字段同名时返回错误
+func Accessors(src []byte, specs map[string]string, codegen Codegen) (string, error) {
+	if len(specs) == 0 {
+		return "", nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("解析源码失败: %v", err)
+	}
+
+	var names []string
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		structType := topLevelStruct(file, name)
+		if structType == nil {
+			continue
+		}
+		code, err := accessorsSource(name, structType, specs[name], codegen)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(code)
+	}
+	return sb.String(), nil
+}
+
+// accessorsSource 生成单个结构体的 getter 和 setter
+func accessorsSource(name string, structType *ast.StructType, recv string, codegen Codegen) (string, error) {
+	if recv == "" {
+		recv = string(unicode.ToLower([]rune(name)[0]))
+	}
+	if recv == "v" {
+		recv = "s"
+	}
+	fields := make(map[string]bool)
+	for _, field := range structType.Fields.List {
+		for _, ident := range field.Names {
+			fields[ident.Name] = true
+		}
+	}
+	getRecv, getType := recv+" *"+name, "*"+name
+	if codegen.ValueReceiver() {
+		getRecv, getType = recv+" "+name, name
+	}
+
+	var sb strings.Builder
+	for _, field := range structType.Fields.List {
+		typ := types.ExprString(field.Type)
+		for _, ident := range field.Names {
+			if ident.Name == "_" {
+				continue
+			}
+			title := strings.ToUpper(ident.Name[:1]) + ident.Name[1:]
+			getter := codegen.getterPrefix() + title
+			if fields[getter] {
+				return "", fmt.Errorf("结构体 %s 的 getter %s 与字段同名，请设置 codegen.getter_prefix", name, getter)
+			}
+			fmt.Fprintf(&sb, "\n// %s 返回 %s，由 astauto 按字段生成\n", getter, ident.Name)
+			fmt.Fprintf(&sb, "func (%s) %s() %s {\n\treturn %s.%s\n}\n", getRecv, getter, typ, recv, ident.Name)
+
+			switch codegen.Setter {
+			case "", SetterSet:
+				fmt.Fprintf(&sb, "\n// Set%s 设置 %s，由 astauto 按字段生成\n", title, ident.Name)
+				fmt.Fprintf(&sb, "func (%s *%s) Set%s(v %s) {\n\t%s.%s = v\n}\n", recv, name, title, typ, recv, ident.Name)
+			case SetterWith:
+				if codegen.ValueReceiver() {
+					fmt.Fprintf(&sb, "\n// With%s 返回 %s 设置为 v 的副本，由 astauto 按字段生成\n", title, ident.Name)
+				} else {
+					fmt.Fprintf(&sb, "\n// With%s 设置 %s 并返回接收者，便于链式调用，由 astauto 按字段生成\n", title, ident.Name)
+				}
+				fmt.Fprintf(&sb, "func (%s) With%s(v %s) %s {\n\t%s.%s = v\n\treturn %s\n}\n", getRecv, title, typ, getType, recv, ident.Name, recv)
+			}
+		}
+	}
+	return sb.String(), nil
+}
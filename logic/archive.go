@@ -0,0 +1,288 @@
+package logic
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Archive 记录解压出的源码归档（zip、tar 或 tar.gz），重新打包时保持原有条目的顺序和元数据，
+// 只替换文件内容，并追加处理过程中新建的文件
+type Archive struct {
+	format  string
+	zips    []zip.FileHeader
+	tars    []tar.Header
+	entries map[string]bool
+}
+
+// archiveFormat 按扩展名识别归档格式
+func archiveFormat(filename string) (string, error) {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip", nil
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar", nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tgz", nil
+	}
+	return "", fmt.Errorf("不支持的归档格式 %s，只支持 .zip、.tar、.tar.gz 和 .tgz", filename)
+}
+
+// entryPath 检查条目名称，拒绝绝对路径和指向归档之外的路径
+func entryPath(dir, name string) (string, error) {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("归档条目 %s 指向归档之外", name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(clean)), nil
+}
+
+// ExtractArchive 把归档中的目录和普通文件解压到 dir，符号链接等其他条目只在重新打包时原样写回
+func ExtractArchive(filename, dir string) (*Archive, error) {
+	format, err := archiveFormat(filename)
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{format: format, entries: make(map[string]bool)}
+	if format == "zip" {
+		err = a.extractZip(filename, dir)
+	} else {
+		err = a.extractTar(filename, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解压 %s 失败: %v", filename, err)
+	}
+	return a, nil
+}
+
+func (a *Archive) extractZip(filename, dir string) error {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		target, err := entryPath(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		a.zips = append(a.zips, f.FileHeader)
+		a.entries[target] = true
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeEntry(target, rc, f.Mode().Perm())
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Archive) extractTar(filename, dir string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if a.format == "tgz" {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := entryPath(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		a.tars = append(a.tars, *hdr)
+		a.entries[target] = true
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeEntry(target, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeEntry 把条目内容写到 target，必要时创建上级目录
+func writeEntry(target string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm|0200)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write 按原归档的条目顺序把 dir 中的内容重新打包到 filename，之后追加 dir 中新建的文件。
+// 先写临时文件再改名，filename 可以就是原归档
+func (a *Archive) Write(filename, dir string) error {
+	added, err := a.addedFiles(dir)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".astauto-*")
+	if err != nil {
+		return fmt.Errorf("创建归档失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if a.format == "zip" {
+		err = a.writeZip(tmp, dir, added)
+	} else {
+		err = a.writeTar(tmp, dir, added)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("写入归档 %s 失败: %v", filename, err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("写入归档 %s 失败: %v", filename, err)
+	}
+	return nil
+}
+
+// addedFiles 返回 dir 中不在原归档里的普通文件相对 dir 的路径
+func (a *Archive) addedFiles(dir string) ([]string, error) {
+	var added []string
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || a.entries[p] {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		added = append(added, filepath.ToSlash(rel))
+		return nil
+	})
+	return added, err
+}
+
+func (a *Archive) writeZip(w io.Writer, dir string, added []string) error {
+	zw := zip.NewWriter(w)
+	copyFile := func(hdr *zip.FileHeader, src string) error {
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(data)
+		return err
+	}
+	for _, h := range a.zips {
+		// 大小和校验和由 zip.Writer 按新内容重新计算
+		hdr := h
+		hdr.CRC32, hdr.CompressedSize, hdr.UncompressedSize, hdr.CompressedSize64, hdr.UncompressedSize64 = 0, 0, 0, 0, 0
+		hdr.Extra = nil
+		target, _ := entryPath(dir, h.Name)
+		if !h.Mode().IsRegular() {
+			if _, err := zw.CreateHeader(&hdr); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := copyFile(&hdr, target); err != nil {
+			return err
+		}
+	}
+	for _, name := range added {
+		if err := copyFile(&zip.FileHeader{Name: name, Method: zip.Deflate}, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (a *Archive) writeTar(w io.Writer, dir string, added []string) error {
+	var gz *gzip.Writer
+	if a.format == "tgz" {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	copyFile := func(hdr *tar.Header, src string) error {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		hdr.Size = int64(len(data))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	}
+	for _, h := range a.tars {
+		hdr := h
+		if hdr.Typeflag != tar.TypeReg {
+			// 非普通文件没有内容，原样写回
+			if err := tw.WriteHeader(&hdr); err != nil {
+				return err
+			}
+			continue
+		}
+		target, _ := entryPath(dir, h.Name)
+		if err := copyFile(&hdr, target); err != nil {
+			return err
+		}
+	}
+	for _, name := range added {
+		if err := copyFile(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
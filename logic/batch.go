@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BatchRepo 批量执行中的一个仓库
+type BatchRepo struct {
+	// Name 仓库的名称，用于报告和补丁文件名，同名时追加序号
+	Name string
+	// Path 仓库的本地路径，需要克隆的仓库为克隆的目标目录
+	Path string
+	// URL 需要克隆的 git 地址，本地仓库为空
+	URL string
+}
+
+// IsGitURL 判断仓库列表中的一项是否为需要克隆的 git 地址（如 https://、ssh:// 或 git@host:path）
+func IsGitURL(s string) bool {
+	return strings.Contains(s, "://") || strings.HasPrefix(s, "git@")
+}
+
+// ParseRepoList 读取仓库列表：每行一个本地路径（相对于列表文件所在目录）或 git 地址，
+// 空行和 # 开头的注释行忽略。git 地址克隆到 cloneDir 下以仓库名命名的目录
+func ParseRepoList(filename, cloneDir string) ([]BatchRepo, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取仓库列表失败: %v", err)
+	}
+	var repos []BatchRepo
+	names := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var repo BatchRepo
+		if IsGitURL(line) {
+			if cloneDir == "" {
+				return nil, fmt.Errorf("仓库列表第 %d 行是 git 地址，需要用 -batch-dir 指定克隆的目录", n)
+			}
+			repo.URL = line
+			repo.Name = strings.TrimSuffix(path.Base(strings.ReplaceAll(strings.TrimRight(line, "/"), ":", "/")), ".git")
+		} else {
+			repo.Path = line
+			if !filepath.IsAbs(line) {
+				repo.Path = filepath.Join(filepath.Dir(filename), line)
+			}
+			repo.Name = filepath.Base(filepath.Clean(repo.Path))
+		}
+		names[repo.Name]++
+		if names[repo.Name] > 1 {
+			repo.Name = fmt.Sprintf("%s-%d", repo.Name, names[repo.Name])
+		}
+		if repo.URL != "" {
+			repo.Path = filepath.Join(cloneDir, repo.Name)
+		}
+		repos = append(repos, repo)
+	}
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("仓库列表 %s 中没有仓库", filename)
+	}
+	return repos, nil
+}
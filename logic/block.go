@@ -0,0 +1,158 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// 托管块起止注释的前缀，完整的注释形如 // astauto:begin fields
+const (
+	BlockBegin = "astauto:begin"
+	BlockEnd   = "astauto:end"
+)
+
+// BlockComments 返回名为 name 的托管块的起止注释
+func BlockComments(name string) (begin, end string) {
+	return "// " + BlockBegin + " " + name, "// " + BlockEnd + " " + name
+}
+
+// StructBlock 结构体中一个托管块的全部内容
+type StructBlock struct {
+	// Name 块的名称，与起止注释中的名称对应
+	Name string
+	// Fields 块中的字段名，按声明顺序
+	Fields []string
+	// Lines 块内的源码行：字段的文档注释和字段声明
+	Lines []string
+}
+
+// StructBlocks 记录打印后需要同步的托管块：结构体名 -> 块
+type StructBlocks map[string][]StructBlock
+
+// Add 登记结构体的一个托管块
+func (b StructBlocks) Add(structName string, block StructBlock) {
+	b[structName] = append(b[structName], block)
+}
+
+// SyncBlocks 用登记的内容替换结构体中起止注释之间的全部内容，块外的字段不做任何改动；
+// 结构体中还没有这对注释时连同内容一起追加到结构体末尾。块外已声明同名字段时返回错误。
+// 返回新源码和各结构体块内新增、删除的字段
+func SyncBlocks(src []byte, blocks StructBlocks) ([]byte, []StructChange, error) {
+	if len(blocks) == 0 {
+		return src, nil, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	var replacements []replacement
+	var changes []StructChange
+	var syncErr error
+	WalkStructs(file, func(name string, structType *ast.StructType) {
+		for _, block := range blocks[name] {
+			if syncErr != nil {
+				return
+			}
+			begin, end := findAnchor(file, structType, BlockBegin+" "+block.Name), findAnchor(file, structType, BlockEnd+" "+block.Name)
+			if (begin == nil) != (end == nil) || begin != nil && end.Pos() < begin.Pos() {
+				syncErr = WithCode(CodeMatch, fmt.Errorf("结构体 %s 中托管块 %s 的起止注释不完整", name, block.Name))
+				return
+			}
+
+			// 块内和块外已有的字段
+			inside := make(map[string]bool)
+			var existing []string
+			for _, field := range structType.Fields.List {
+				for _, ident := range field.Names {
+					if begin != nil && field.Pos() > begin.End() && field.End() < end.Pos() {
+						inside[ident.Name] = true
+						existing = append(existing, ident.Name)
+					} else if containsName(block.Fields, ident.Name) {
+						syncErr = WithCode(CodeConflict, fmt.Errorf("字段 %s.%s 已在托管块 %s 之外声明", name, ident.Name, block.Name))
+						return
+					}
+				}
+			}
+
+			text := ""
+			for _, line := range block.Lines {
+				text += line + "\n"
+			}
+			var r replacement
+			if begin != nil {
+				r.start = lineEnd(src, fset.Position(begin.End()).Offset) + 1
+				r.end = lineStart(string(src), fset.Position(end.Pos()).Offset)
+				r.text = text
+			} else {
+				// 右花括号所在行之前；字段列表写在一行时直接插在右花括号前
+				beginComment, endComment := BlockComments(block.Name)
+				closing := fset.Position(structType.Fields.Closing).Offset
+				r.start = lineStart(string(src), closing)
+				r.text = beginComment + "\n" + text + endComment + "\n"
+				if fset.Position(structType.Fields.Opening).Line == fset.Position(structType.Fields.Closing).Line {
+					r.start = closing
+					r.text = "\n" + r.text
+				}
+				r.end = r.start
+			}
+			replacements = append(replacements, r)
+
+			change := StructChange{Struct: name, Line: fset.Position(structType.Pos()).Line}
+			for _, field := range block.Fields {
+				if !inside[field] {
+					change.Added = append(change.Added, field)
+				}
+			}
+			for _, field := range existing {
+				if !containsName(block.Fields, field) {
+					change.Removed = append(change.Removed, field)
+				}
+			}
+			if len(change.Added) > 0 || len(change.Removed) > 0 {
+				changes = append(changes, change)
+			}
+		}
+	})
+	if syncErr != nil {
+		return nil, nil, syncErr
+	}
+
+	// 从后往前替换，避免偏移量失效
+	sort.SliceStable(replacements, func(i, j int) bool { return replacements[i].start > replacements[j].start })
+	out := string(src)
+	for _, r := range replacements {
+		out = out[:r.start] + r.text + out[r.end:]
+	}
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		return nil, nil, WithCode(CodeFormat, fmt.Errorf("格式化托管块失败: %v", err))
+	}
+	return formatted, changes, nil
+}
+
+// containsName 判断名称列表中是否包含 name
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validBlockName 判断托管块的名称能否写在起止注释中
+func validBlockName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t\r\n")
+}
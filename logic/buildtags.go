@@ -0,0 +1,38 @@
+package logic
+
+import (
+	"fmt"
+	"go/build"
+	"go/build/constraint"
+	"path/filepath"
+)
+
+// validateBuildTag 检查构建标签的语法，标签必须能单独作为 //go:build 约束
+func validateBuildTag(tag string) error {
+	expr, err := constraint.Parse("//go:build " + tag)
+	if err != nil {
+		return fmt.Errorf("构建标签 %q 无效: %v", tag, err)
+	}
+	if _, ok := expr.(*constraint.TagExpr); !ok {
+		return fmt.Errorf("构建标签 %q 无效: 只能是单个标签，如 linux、integration", tag)
+	}
+	return nil
+}
+
+// matchBuildTags 判断文件在只满足 tags 中的构建标签时是否参与构建：文件名的 _GOOS、_GOARCH 后缀
+// 和 //go:build（以及旧的 // +build）约束都按 tags 求值，go1.N 版本标签按当前工具链求值。
+// tags 为空时不做筛选
+func matchBuildTags(filename string, tags []string) (bool, error) {
+	if len(tags) == 0 {
+		return true, nil
+	}
+	ctxt := build.Context{
+		BuildTags:   tags,
+		ReleaseTags: build.Default.ReleaseTags,
+	}
+	ok, err := ctxt.MatchFile(filepath.Dir(filename), filepath.Base(filename))
+	if err != nil {
+		return false, fmt.Errorf("读取 %s 的构建约束失败: %v", filename, err)
+	}
+	return ok, nil
+}
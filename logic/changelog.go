@@ -0,0 +1,128 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StructChange 记录单个结构体在一次执行中的字段变更
+type StructChange struct {
+	Struct  string   `json:"struct"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	// RemovedMethods 删除的方法名
+	RemovedMethods []string `json:"removed_methods,omitempty"`
+	// Receivers 接收者改名的方法，形如 Save: usr -> u
+	Receivers []string `json:"receivers,omitempty"`
+	// Renamed 修改的标签名称，形如 Name json: userName -> user_name
+	Renamed []string `json:"renamed,omitempty"`
+	// Deprecated 标记为废弃的字段，之后的删除记在 Removed 中
+	Deprecated []string `json:"deprecated,omitempty"`
+	// Widened 放宽了数值类型的字段，形如 ID: int32 -> int64
+	Widened []string `json:"widened,omitempty"`
+	// RenamedTo 结构体改名后的名称
+	RenamedTo string `json:"renamed_to,omitempty"`
+	// Line 结构体在修改前文件中的行号
+	Line int `json:"-"`
+}
+
+// ChangelogEntry 表示变更历史中的一条记录
+type ChangelogEntry struct {
+	Date string `json:"date"`
+	Rule string `json:"rule"`
+	StructChange
+}
+
+// changelogHeader 新建 Markdown 变更日志时写入的标题
+const changelogHeader = "# 模型变更日志\n\n本文件由 astauto 自动追加，记录结构体字段的演进。\n"
+
+// AppendChangelog 将变更记录追加到变更日志文件，
+// 扩展名为 .json 时写入 JSON 历史数组，否则写入 Markdown
+func AppendChangelog(filename string, entries []ChangelogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return appendJSONChangelog(filename, entries)
+	}
+	return appendMarkdownChangelog(filename, entries)
+}
+
+// appendJSONChangelog 读取已有的 JSON 历史并追加新记录
+func appendJSONChangelog(filename string, entries []ChangelogEntry) error {
+	var history []ChangelogEntry
+	data, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取变更历史失败: %v", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &history); err != nil {
+			return fmt.Errorf("解析变更历史失败: %v", err)
+		}
+	}
+	history = append(history, entries...)
+
+	out, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化变更历史失败: %v", err)
+	}
+	if err := os.WriteFile(filename, append(out, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入变更历史失败: %v", err)
+	}
+	return nil
+}
+
+// appendMarkdownChangelog 以 Markdown 列表形式追加新记录
+func appendMarkdownChangelog(filename string, entries []ChangelogEntry) error {
+	var sb strings.Builder
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		sb.WriteString(changelogHeader)
+	}
+	sb.WriteString(fmt.Sprintf("\n## %s\n\n", entries[0].Date))
+	for _, entry := range entries {
+		sb.WriteString(fmt.Sprintf("- 规则 `%s`，结构体 `%s`", entry.Rule, entry.Struct))
+		var parts []string
+		if len(entry.Added) > 0 {
+			parts = append(parts, fmt.Sprintf("新增字段 %s", strings.Join(entry.Added, ", ")))
+		}
+		if len(entry.Removed) > 0 {
+			parts = append(parts, fmt.Sprintf("删除字段 %s", strings.Join(entry.Removed, ", ")))
+		}
+		if len(entry.RemovedMethods) > 0 {
+			parts = append(parts, fmt.Sprintf("删除方法 %s", strings.Join(entry.RemovedMethods, ", ")))
+		}
+		if len(entry.Receivers) > 0 {
+			parts = append(parts, fmt.Sprintf("接收者改名 %s", strings.Join(entry.Receivers, ", ")))
+		}
+		if len(entry.Renamed) > 0 {
+			parts = append(parts, fmt.Sprintf("修改标签 %s", strings.Join(entry.Renamed, ", ")))
+		}
+		if len(entry.Deprecated) > 0 {
+			parts = append(parts, fmt.Sprintf("废弃字段 %s", strings.Join(entry.Deprecated, ", ")))
+		}
+		if len(entry.Widened) > 0 {
+			parts = append(parts, fmt.Sprintf("放宽类型 %s", strings.Join(entry.Widened, ", ")))
+		}
+		if entry.RenamedTo != "" {
+			parts = append(parts, fmt.Sprintf("改名为 %s", entry.RenamedTo))
+		}
+		if len(parts) > 0 {
+			sb.WriteString("：" + strings.Join(parts, "；"))
+		}
+		sb.WriteString("\n")
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开变更日志失败: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(sb.String()); err != nil {
+		return fmt.Errorf("写入变更日志失败: %v", err)
+	}
+	return nil
+}
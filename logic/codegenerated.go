@@ -0,0 +1,44 @@
+package logic
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// IncludeGenerated 为 true 时按目录查找目标文件（file 通配模式、package 和按结构体名称查找）
+// 也包括带有 Code generated 头的文件，默认跳过它们，避免与生成这些文件的工具互相改写
+var IncludeGenerated = false
+
+// generatedHeader Go 约定的生成文件标记，见 https://go.dev/s/generatedcode
+var generatedHeader = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// IsGeneratedSource 判断源码在 package 子句之前是否有 // Code generated ... DO NOT EDIT. 注释行
+func IsGeneratedSource(src []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(make([]byte, 64*1024), len(src)+1)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if generatedHeader.MatchString(line) {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+	return false
+}
+
+// skipGenerated 判断按目录查找时是否跳过该文件：文件带有 Code generated 头且没有设置 IncludeGenerated
+func skipGenerated(filename string) bool {
+	if IncludeGenerated || !strings.HasSuffix(filename, ".go") {
+		return false
+	}
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return false
+	}
+	return IsGeneratedSource(src)
+}
@@ -0,0 +1,90 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColumnsName 返回结构体列名列表变量的名称，如 UserColumns
+func ColumnsName(structName string) string {
+	return structName + "Columns"
+}
+
+// ColumnName 返回字段列名常量的名称，如 UserColumnID
+func ColumnName(structName, fieldName string) string {
+	return structName + "Column" + fieldName
+}
+
+// Columns 按源码中结构体当前的字段生成列名列表变量和各字段的列名常量：结构体名 -> 列名取自的标签（如 db）。
+// 只包含设置了该标签的具名导出字段，标签为 - 的字段跳过
+func Columns(src []byte, specs map[string]string) (string, error) {
+	if len(specs) == 0 {
+		return "", nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("解析源码失败: %v", err)
+	}
+
+	var names []string
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		if structType := topLevelStruct(file, name); structType != nil {
+			sb.WriteString(columnsSource(name, structType, specs[name]))
+		}
+	}
+	return sb.String(), nil
+}
+
+// columnsSource 生成单个结构体的列名声明
+func columnsSource(name string, structType *ast.StructType, key string) string {
+	type column struct{ field, name string }
+	var columns []column
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		value, ok := reflect.StructTag(tag).Lookup(key)
+		col, _, _ := strings.Cut(value, ",")
+		if !ok || col == "" || col == "-" {
+			continue
+		}
+		for _, ident := range field.Names {
+			if ident.IsExported() {
+				columns = append(columns, column{field: ident.Name, name: col})
+			}
+		}
+	}
+
+	var sb strings.Builder
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = strconv.Quote(c.name)
+	}
+	fmt.Fprintf(&sb, "\n// %s %s 的 %s 列名，按字段顺序排列，由 astauto 按字段生成\n", ColumnsName(name), name, key)
+	fmt.Fprintf(&sb, "var %s = []string{%s}\n", ColumnsName(name), strings.Join(quoted, ", "))
+	if len(columns) == 0 {
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "\n// %s 各字段的 %s 列名，由 astauto 按字段生成\nconst (\n", name, key)
+	for _, c := range columns {
+		fmt.Fprintf(&sb, "\t%s = %s\n", ColumnName(name, c.field), strconv.Quote(c.name))
+	}
+	sb.WriteString(")\n")
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// FieldDocs 记录需要插入的字段文档注释：结构体名 -> 字段名 -> 注释行
+type FieldDocs map[string]map[string][]string
+
+// Add 登记一个字段的文档注释
+func (d FieldDocs) Add(structName, fieldName string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	if d[structName] == nil {
+		d[structName] = make(map[string][]string)
+	}
+	d[structName][fieldName] = lines
+}
+
+// InsertFieldDocs 在格式化后的源码中为指定字段插入文档注释。
+// go/printer 无法输出没有位置信息的注释，因此在打印之后按字段所在行插入，再重新格式化
+func InsertFieldDocs(src []byte, docs FieldDocs) ([]byte, error) {
+	if len(docs) == 0 {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+
+	type insertion struct {
+		offset int
+		text   string
+	}
+	var insertions []insertion
+	WalkStructs(file, func(name string, structType *ast.StructType) {
+		fields, ok := docs[name]
+		if !ok {
+			return
+		}
+		for _, field := range structType.Fields.List {
+			if len(field.Names) == 0 {
+				continue
+			}
+			lines, ok := fields[field.Names[0].Name]
+			if !ok {
+				continue
+			}
+			// 定位字段所在行的行首，沿用原有缩进
+			offset := fset.Position(field.Pos()).Offset
+			lineStart := strings.LastIndexByte(string(src[:offset]), '\n') + 1
+			indent := string(src[lineStart:offset])
+			var sb strings.Builder
+			for _, line := range lines {
+				sb.WriteString(indent + line + "\n")
+			}
+			insertions = append(insertions, insertion{offset: lineStart, text: sb.String()})
+		}
+	})
+	if len(insertions) == 0 {
+		return src, nil
+	}
+
+	// 从后往前插入，避免偏移量失效
+	sort.Slice(insertions, func(i, j int) bool { return insertions[i].offset > insertions[j].offset })
+	out := string(src)
+	for _, ins := range insertions {
+		out = out[:ins.offset] + ins.text + out[ins.offset:]
+	}
+	return format.Source([]byte(out))
+}
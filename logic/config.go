@@ -2,21 +2,197 @@ package logic
 
 import (
 	"fmt"
+	"go/parser"
+	"go/token"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
 
 // Config 结构体用于解析JSON和TOML配置
 type Config struct {
-	Rules []*Rule `json:"rules" toml:"rules"`
+	// Version 配置格式的版本，见 ConfigVersion；没有设置时按版本 1 读取并升级
+	Version int `json:"version" toml:"version"`
+	// Changelog 变更日志路径（相对于处理目录），为空时不记录；扩展名为 .json 时写入 JSON 历史
+	Changelog string `json:"changelog" toml:"changelog"`
+	// Dictionary 数据字典路径（相对于处理目录），为空时不生成；每次执行后按结构体的最新状态重写，
+	// 扩展名为 .json 时写入 JSON，否则写入 Markdown 表格
+	Dictionary string  `json:"dictionary" toml:"dictionary"`
+	Rules      []*Rule `json:"rules" toml:"rules"`
+	// Exclude 通配模式（如 vendor/**、**/*_test.go、gen/**），与之匹配的路径在 file 通配、package、
+	// 按结构体名称查找和模块范围的引用审计中都不会被处理，对每条规则生效
+	Exclude []string `json:"exclude" toml:"exclude"`
+	// Imports 全局导入，添加到每条规则处理的文件中；规则中已有同一路径的导入时以规则为准
+	Imports []Import `json:"imports" toml:"imports"`
+	// Models 模型清单，每个清单生成并持续维护一个模型文件
+	Models []Model `json:"models" toml:"models"`
+	// Registry 从 schema registry 同步的主题，每个主题生成并持续维护目标文件中的结构体
+	Registry []RegistrySchema `json:"registry" toml:"registry"`
+	// Presets 类型预设，字段类型中以 @名称 引用，覆盖同名的内置预设，见 Preset
+	Presets map[string]Preset `json:"presets" toml:"presets"`
+	// Nullable 可空字段（nullable = true）映射为 Go 类型的策略：pointer（默认）、sql 或 option，规则可以单独设置
+	Nullable string `json:"nullable" toml:"nullable"`
+	// OptionType nullable 策略为 option 时使用的泛型类型及其导入，如 type = "opt.Option"
+	OptionType Preset `json:"option_type" toml:"option_type"`
+	// Snippets 具名代码片段，规则通过 apply_snippet 引用
+	Snippets map[string]Snippet `json:"snippets" toml:"snippets"`
+	// Hook astauto hook install 生成的 pre-commit 钩子的配置
+	Hook Hook `json:"hook" toml:"hook"`
+	// GoVersion 目标 Go 版本（如 1.17），规则没有单独设置时使用；决定能否生成泛型、
+	// 是否把 any 写成 interface{}，以及类型检查使用的语言版本
+	GoVersion string `json:"go_version" toml:"go_version"`
+	// StateDir 运行锁等运行状态的存放目录，相对路径相对于模块根目录，默认为 .astauto
+	StateDir string `json:"state_dir" toml:"state_dir"`
+	// DisableState 不在磁盘上保存任何运行状态（也不使用运行锁），用于 Bazel 等密封的构建环境
+	DisableState bool `json:"disable_state" toml:"disable_state"`
+	// Lint astauto lint 的标签检查配置
+	Lint Lint `json:"lint" toml:"lint"`
+	// Header 需要出现在目标文件顶部的许可证或归属声明，见 FileHeader
+	Header FileHeader `json:"header" toml:"header"`
+	// Codegen 生成方法的命名约定（getter 前缀、setter 风格、接收者类型）
+	Codegen Codegen `json:"codegen" toml:"codegen"`
+	// Provenance 在目标文件末尾记录维护它的规则和配置哈希，默认不记录
+	Provenance Provenance `json:"provenance" toml:"provenance"`
+	// Deprecation 删除字段前必须先经过的废弃阶段，默认不检查
+	Deprecation Deprecation `json:"deprecation" toml:"deprecation"`
+	// Vars 配置中以 ${名称} 引用的变量，优先于同名的环境变量
+	Vars map[string]string `json:"vars" toml:"vars"`
+	// Fieldsets 具名的字段组，结构体通过 fieldsets 引用，避免在多个结构体中重复同一组字段
+	Fieldsets map[string][]Field `json:"fieldsets" toml:"fieldsets"`
+	// Disabled 因 enabled = false 或 requires 的规则被禁用而不执行的规则名称
+	Disabled []string `json:"-" toml:"-"`
+	// Extends 父配置文件，相对于本文件所在目录。本文件的设置覆盖父配置：
+	// 规则按 ID 或 (文件, 结构体) 覆盖父配置中的规则，见 extendConfig
+	Extends string `json:"extends" toml:"extends"`
+	// Include 需要合并进来的其他配置文件，支持通配符，相对于本文件所在目录；
+	// 被引用文件的规则排在本文件的规则之后，同名的片段、预设等定义不能冲突
+	Include []string `json:"include" toml:"include"`
+	// Secrets 具名密钥，供需要凭据的集成通过 secret:名称 引用，避免在配置中写明文
+	Secrets map[string]Secret `json:"secrets" toml:"secrets"`
+}
+
+// Hook 结构体表示 pre-commit 钩子配置
+type Hook struct {
+	// Command 钩子中调用的 astauto 命令，默认为 astauto
+	Command string `json:"command" toml:"command"`
+	// Fix 检查前先执行规则修复暂存的文件，并重新加入暂存区
+	Fix bool `json:"fix" toml:"fix"`
+	// Args 追加到 check 命令的额外参数，例如 ["-output", "github"]，每个参数单独加引号
+	Args []string `json:"args" toml:"args"`
 }
 
 // Rule 结构体表示一条规则
 type Rule struct {
-	File    string   `json:"file" toml:"file"`
+	// ID 规则标识，供其他规则在 depends_on 中引用
+	ID string `json:"id" toml:"id"`
+	// DependsOn 需要先于本规则执行的规则 ID 列表
+	DependsOn []string `json:"depends_on" toml:"depends_on"`
+	// Requires 与 depends_on 一样先于本规则执行的规则 ID，区别在于其中任一规则被禁用时本规则也不执行
+	Requires []string `json:"requires" toml:"requires"`
+	// Enabled 为 false 时规则不执行，默认执行
+	Enabled *bool `json:"enabled" toml:"enabled"`
+	// Tags 规则的标签，命令行的 -only 和 -skip 按标签选择要执行的规则
+	Tags []string `json:"tags" toml:"tags"`
+	// When 条件表达式，结果为 false 时跳过整条规则，可用的事实见 FileEnv
+	When string `json:"when" toml:"when"`
+
+	// File 目标文件，相对 -path；可以是通配模式（如 models/*.go、internal/**/dto.go），
+	// 执行时展开为每个匹配文件一条规则，见 ExpandTargets
+	File string `json:"file" toml:"file"`
+	// FileRegex 代替 file 用正则表达式匹配目标文件相对 -path、以 / 分隔的路径，如 ^api/v[0-9]+/models\.go$，
+	// 用于通配模式难以表达的目录结构；执行时与通配模式一样展开为每个匹配文件一条规则
+	FileRegex string `json:"file_regex" toml:"file_regex"`
+	// Files 代替 file 列出多个目标文件（如结构相同的多份 DTO），执行时展开为每个文件一条规则，见 expandFiles
+	Files []string `json:"files" toml:"files"`
+	// Package 代替 file 指定目标包，如 ./internal/models/...（以 /... 结尾时包括子目录），
+	// 执行时在包的所有 Go 文件中查找规则的结构体，展开为每个声明了它们的文件一条规则。
+	// file 和 package 都不设置时在 -path 下的全部 Go 文件中查找，每个结构体只能在一个文件中声明
+	Package string `json:"package" toml:"package"`
+	// IncludeTests 按 package 查找结构体时包括 _test.go 文件
+	IncludeTests bool `json:"include_tests" toml:"include_tests"`
+	// Exclude 只对本规则生效的排除模式，追加在配置的 exclude 之后
+	Exclude []string `json:"exclude" toml:"exclude"`
+	// BuildTags 按 file 通配模式、package 或结构体名称查找文件时只保留在这些构建标签下参与构建的文件，
+	// 文件名的 _GOOS、_GOARCH 后缀和 //go:build 约束都按它们求值，如 ["linux"]
+	BuildTags []string `json:"build_tags" toml:"build_tags"`
+
 	Imports []Import `json:"imports" toml:"imports"`
 	Structs []Struct `json:"structs" toml:"structs"`
+	// TagFormat 标签的排版方式，align 表示将规则中各结构体的多键标签按键分列对齐
+	TagFormat string `json:"tag_format" toml:"tag_format"`
+	// ApplySnippets 需要插入到文件中的代码片段
+	ApplySnippets SnippetRefs `json:"apply_snippet" toml:"apply_snippet"`
+
+	// GoVersion 目标 Go 版本，覆盖配置中的 go_version
+	GoVersion string `json:"go_version" toml:"go_version"`
+	// Nullable 可空字段的映射策略，覆盖配置中的 nullable
+	Nullable string `json:"nullable" toml:"nullable"`
+
+	// NormalizeAny 把空接口统一写成 any 或 interface{}，为空时不处理
+	NormalizeAny string `json:"normalize_any" toml:"normalize_any"`
+	// NormalizeAnyScope 统一空接口的范围：structs（默认）只处理规则中的结构体，file 处理整个文件
+	NormalizeAnyScope string `json:"normalize_any_scope" toml:"normalize_any_scope"`
+
+	// MergeImports 添加导入前把多个导入声明合并为一个导入块，并删除重复的导入
+	MergeImports bool `json:"merge_imports" toml:"merge_imports"`
+
+	// ImportConflict 文件中已有同一路径但名称不同的导入时的处理方式：
+	// error（默认）报错，keep 沿用已有名称，rewrite 把已有导入及其引用改为配置的别名
+	ImportConflict string `json:"import_conflict" toml:"import_conflict"`
+
+	// OnSyntaxError 目标文件有语法错误时的处理方式：fail（默认）报告错误位置并中止规则，
+	// imports 在不完整的语法树上只添加导入，跳过结构体和代码片段
+	OnSyntaxError string `json:"on_syntax_error" toml:"on_syntax_error"`
+
+	// PreserveFormat 不重新格式化未被修改触及的区域，使没有经过 gofmt 的文件也只产生最小的差异
+	PreserveFormat bool `json:"preserve_format" toml:"preserve_format"`
+
+	// Managed 在注入的字段和声明上加标记注释（// astauto:managed rule=xyz），
+	// 配合 -clean 可以删除全部标记内容后重新生成
+	Managed bool `json:"managed" toml:"managed"`
+
+	// Model 由模型清单生成的规则所对应的清单，文件不存在时按清单生成骨架
+	Model *Model `json:"-" toml:"-"`
+	// Entity 由结构体的模式生成的伴随规则所对应的实体，执行时按 ResolveEntity 补全包名和导入
+	Entity *EntityRef `json:"-" toml:"-"`
+	// Registry 由 registry 主题生成的规则对应的主题，执行前按 SyncRegistry 下载 schema 生成结构体
+	Registry *RegistrySchema `json:"-" toml:"-"`
+	// MapSpecs 目标文件中需要生成 ToMap 和 FromMap 的结构体，汇总自作用于同一文件的所有规则，
+	// 使后面的规则新增字段后方法也随之更新
+	MapSpecs map[string]MapSpec `json:"-" toml:"-"`
+	// AccessorSpecs 目标文件中需要生成 getter 和 setter 的结构体及接收者名称，与 MapSpecs 一样汇总自同一文件的所有规则
+	AccessorSpecs map[string]string `json:"-" toml:"-"`
+	// Codegen 配置中生成方法的命名约定
+	Codegen Codegen `json:"-" toml:"-"`
+	// ColumnSpecs 目标文件中需要生成列名声明的结构体及取列名的标签，与 MapSpecs 一样汇总自同一文件的所有规则
+	ColumnSpecs map[string]string `json:"-" toml:"-"`
+	// RedactSpecs 目标文件中需要生成 Redacted 的结构体，与 MapSpecs 一样汇总自同一文件的所有规则
+	RedactSpecs map[string]RedactSpec `json:"-" toml:"-"`
+	// Variant 由字段的 types 展开的规则对应的构建标签，文件不存在时生成带构建约束的骨架
+	Variant string `json:"-" toml:"-"`
+}
+
+// Name 返回规则的显示名称，未设置 ID 时使用文件路径或包路径，都没有时使用结构体名称
+func (r *Rule) Name() string {
+	if r.ID != "" {
+		return r.ID
+	}
+	if r.File == "" && r.FileRegex != "" {
+		return r.FileRegex
+	}
+	if r.File == "" && r.Package == "" {
+		names := make([]string, len(r.Structs))
+		for i, st := range r.Structs {
+			names[i] = st.Name
+		}
+		return strings.Join(names, ",")
+	}
+	if r.File == "" {
+		return r.Package
+	}
+	return r.File
 }
 
 // Import 结构体表示导入信息
@@ -27,29 +203,491 @@ type Import struct {
 
 // Struct 结构体表示结构体信息
 type Struct struct {
-	Name   string  `json:"name" toml:"name"`
+	// Name 结构体的类型名，或指向内联结构体的路径（如 Users[]、Config.Server），见 WalkStructs
+	Name string `json:"name" toml:"name"`
+	// Description 结构体说明，创建结构体时生成为文档注释
+	Description string `json:"description" toml:"description"`
+	// Create 文件中没有该结构体时创建它
+	Create bool `json:"create" toml:"create"`
+	// Constructor 文件中没有 NewX 构造函数时生成它，字段按 Default 初始化
+	Constructor bool `json:"constructor" toml:"constructor"`
+	// Pattern 在结构体上安装的高层模式，由字段、导入和方法组合而成：soft-delete（gorm.DeletedAt
+	// 类型的 DeletedAt 字段和 IsDeleted 方法）、version-lock（Version 字段，并改写已有的构造函数和 Equal 方法）
+	// 或 pagination（在 pattern_file 中维护 ListXRequest 和 ListXResponse）
+	Pattern string `json:"pattern" toml:"pattern"`
+	// PatternScope 同时生成模式的查询作用域函数，soft-delete 为只查询已删除记录的 OnlyDeletedX
+	PatternScope bool `json:"pattern_scope" toml:"pattern_scope"`
+	// PatternFile 模式生成伴随结构体的文件（相对 -path），默认为结构体所在的文件；
+	// pagination 在其中维护 ListXRequest 和 ListXResponse
+	PatternFile string `json:"pattern_file" toml:"pattern_file"`
+	// When 条件表达式，结果为 false 时跳过该结构体，可用的事实见 StructEnv
+	When string `json:"when" toml:"when"`
+	// Anchor 锚点注释（如 astauto:fields），设置后新字段插入到结构体中该注释之后，而不是末尾
+	Anchor string `json:"anchor" toml:"anchor"`
+	// Block 托管块的名称。设置后 fields 是结构体中 // astauto:begin 名称 与 // astauto:end 名称 之间的全部内容，
+	// 每次执行都按配置整体重新生成，块外的字段不做任何改动；结构体中还没有这对注释时追加到结构体末尾
+	Block string `json:"block" toml:"block"`
+	// SortByTag 按该标签键的数值（如 protobuf 字段编号或 order:"3"）对字段排序
+	SortByTag string `json:"sort_by_tag" toml:"sort_by_tag"`
+	// TagDefaults 注入字段的默认标签，值为模板（如 bson = "{{.Snake}}"），字段自己写的键优先，见 ApplyTagDefaults
+	TagDefaults FieldTags `json:"tag_defaults" toml:"tag_defaults"`
+	// Fields 需要插入的字段，除了表也可以写字段简写字符串，见 ParseFieldShorthand
 	Fields []Field `json:"fields" toml:"fields"`
+	// Remove 需要从结构体中删除的字段
+	Remove []RemoveField `json:"remove" toml:"remove"`
+	// RemoveMethods 需要删除的该结构体的方法名（值接收者和指针接收者都匹配）
+	RemoveMethods []string `json:"remove_methods" toml:"remove_methods"`
+	// Fieldsets 引用的字段组名称，组内的字段按顺序追加在 fields 之后，与 fields 同名的字段以 fields 为准
+	Fieldsets []string `json:"fieldsets" toml:"fieldsets"`
+	// MapMethods 生成 ToMap 和 FromMap 方法时作为 map 键的标签（如 json 或 db），为空时不生成；
+	// 每次执行都按结构体当前的字段重新生成
+	MapMethods string `json:"map_methods" toml:"map_methods"`
+	// Accessors 为结构体的每个具名字段生成 getter 和 setter，命名按 [codegen] 的约定，
+	// 每次执行都按结构体当前的字段重新生成
+	Accessors bool `json:"accessors" toml:"accessors"`
+	// Columns 生成列名列表变量（如 UserColumns）和各字段列名常量（如 UserColumnID）时取列名的标签，
+	// 通常为 db；只包含设置了该标签的字段，每次执行都按结构体当前的字段重新生成
+	Columns string `json:"columns" toml:"columns"`
+	// Redacted 生成返回脱敏副本的 Redacted 方法，带有 sensitive:"true" 标签的字段和 sensitive 中列出的字段
+	// 被脱敏；每次执行都按结构体当前的字段重新生成
+	Redacted bool `json:"redacted" toml:"redacted"`
+	// Sensitive 没有 sensitive 标签但也需要脱敏的字段，设置后同样生成 Redacted
+	Sensitive []string `json:"sensitive" toml:"sensitive"`
+	// Receiver 目标文件中该结构体方法接收者的统一名称（如 u），方法体中的引用一起改写
+	Receiver string `json:"receiver" toml:"receiver"`
+	// RenameTags 需要修改标签名称的已有字段
+	RenameTags []RenameTag `json:"rename_tags" toml:"rename_tags"`
+	// Deprecate 标记为废弃的已有字段，下一阶段把它们移到 remove 中删除
+	Deprecate []DeprecateField `json:"deprecate" toml:"deprecate"`
+	// Widen 需要放宽数值类型的已有字段（如 int32 -> int64）
+	Widen []WidenField `json:"widen" toml:"widen"`
+	// RenameTo 结构体的新名称：与构造函数、builder、生成的声明和测试函数一起改名，并改写 -path 下全部包中的引用；
+	// 涉及多个文件，执行时总是按 -atomic-run 校验后一起写回。改名后配置中的 name 应改为新名称
+	RenameTo string `json:"rename_to" toml:"rename_to"`
+}
+
+// RemoveField 结构体表示需要删除的字段，以及删除前如何处理对它的引用
+type RemoveField struct {
+	Name string `json:"name" toml:"name"`
+	// Audit 发现引用时的处理方式：fail（默认，报错并列出引用）、list（只列出引用）、rewrite（按 Replacement 改写）
+	Audit string `json:"audit" toml:"audit"`
+	// Scope 扫描引用的范围：package（默认，目标文件所在的包）或 module（处理目录下的全部文件）
+	Scope string `json:"scope" toml:"scope"`
+	// Replacement 改写引用的表达式模板，{{.X}} 为原选择器的接收表达式，例如 "{{.X}}.FullName()"
+	Replacement string `json:"replacement" toml:"replacement"`
+}
+
+// RenameTag 结构体表示修改已有字段某个标签中的名称，如把 json:"userName" 改为 json:"user_name"，标签选项保持不变
+type RenameTag struct {
+	Field string `json:"field" toml:"field"`
+	Key   string `json:"key" toml:"key"`
+	Name  string `json:"name" toml:"name"`
+	// Literals 允许改写字符串字面量的包目录（相对处理目录），其中值等于旧名称的字符串改为新名称。
+	// 只改写明确列出的包，避免误改同名的无关字符串
+	Literals []string `json:"literals" toml:"literals"`
 }
 
 // Field 结构体表示字段信息
 type Field struct {
 	Name string `json:"name" toml:"name"`
 	Type string `json:"type" toml:"type"`
-	Tags string `json:"tags" toml:"tags"`
+	// Tags 标签，可以写原始字符串或键值表，见 FieldTags
+	Tags FieldTags `json:"tags" toml:"tags"`
+	// Description 字段说明，生成为字段的文档注释
+	Description string `json:"description" toml:"description"`
+	// Example 示例值，生成为 example 标签
+	Example string `json:"example" toml:"example"`
+	// Default 默认值表达式（如 time.Now()），生成的构造函数用它初始化字段
+	Default string `json:"default" toml:"default"`
+	// Types 按构建标签设置的类型，如 { amd64 = "int64", wasm = "int32" }。规则按标签展开到
+	// 同名加 _标签 后缀的变体文件（size.go 对应 size_wasm.go），不存在时生成带 //go:build 约束的文件；
+	// 同时设置了 type 时原文件保留为默认实现
+	Types map[string]string `json:"types" toml:"types"`
+	// Nullable 字段可以为空，类型按 nullable 策略改写，如 string 改写为 *string 或 sql.NullString
+	Nullable bool `json:"nullable" toml:"nullable"`
 }
 
 // ParseTOML 从TOML文件解析配置
 func ParseTOML(filename string) (*Config, error) {
-	file, err := os.Open(filename)
+	return parseConfigFile(filename, decodeTOML)
+}
+
+// decodeTOML 解码TOML配置文件，不展开和检查
+func decodeTOML(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("无法打开TOML文件: %v", err)
 	}
-	defer file.Close()
 
 	var config Config
-	if _, err := toml.DecodeReader(file, &config); err != nil {
+	md, err := toml.Decode(string(data), &config)
+	if err != nil {
 		return nil, fmt.Errorf("解析TOML文件失败: %v", err)
 	}
-
+	if StrictConfig {
+		if keys := unknownKeys(data, md); len(keys) > 0 {
+			lines := make([]string, len(keys))
+			for i, key := range keys {
+				lines[i] = "  " + key.String()
+			}
+			return nil, fmt.Errorf("TOML文件中有 %d 个未知的键:\n%s", len(keys), strings.Join(lines, "\n"))
+		}
+	}
 	return &config, nil
 }
+
+// prepareConfig 展开并检查解码后的配置：模型清单和 registry 主题转换为规则、合并全局导入、规范 Go 版本、
+// 按依赖排序规则并检查各项设置，与配置文件的格式无关
+func prepareConfig(config *Config) (*Config, error) {
+	var err error
+	// 模型清单和 registry 主题转换为规则，排在普通规则之前
+	var modelRules []*Rule
+	for i := range config.Models {
+		modelRules = append(modelRules, config.Models[i].Rule())
+	}
+	registry, err := registryRules(config.Registry)
+	if err != nil {
+		return nil, err
+	}
+	modelRules = append(modelRules, registry...)
+	config.Rules = append(modelRules, config.Rules...)
+
+	// 设置了 files 的规则展开为每个文件一条规则
+	if config.Rules, err = expandFiles(config.Rules); err != nil {
+		return nil, err
+	}
+
+	// 结构体引用的字段组和模式的字段追加到字段列表中，模式的伴随规则排在所有规则之后
+	var companions []*Rule
+	for _, rule := range config.Rules {
+		if err := applyFieldsets(rule, config.Fieldsets); err != nil {
+			return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+		}
+		rules, err := applyPatterns(rule)
+		if err != nil {
+			return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+		}
+		companions = append(companions, rules...)
+	}
+	config.Rules = append(config.Rules, companions...)
+
+	// 展开 file、导入路径、字段类型和标签中的 ${NAME}，先查 [vars] 再查环境变量；vars 的值中可以引用环境变量
+	for name, value := range config.Vars {
+		if config.Vars[name], err = ExpandEnv(value); err != nil {
+			return nil, fmt.Errorf("vars.%s: %v", name, err)
+		}
+	}
+	if err := expandImportsEnv(config.Imports, config.Vars); err != nil {
+		return nil, err
+	}
+	for _, rule := range config.Rules {
+		if err := expandRuleEnv(rule, config.Vars); err != nil {
+			return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+		}
+	}
+
+	// 字段按构建标签设置了不同类型的规则展开到各平台的变体文件
+	if config.Rules, err = expandVariants(config.Rules); err != nil {
+		return nil, err
+	}
+
+	// 全局导入合并到每条规则中，排在规则自己的导入之前
+	for _, rule := range config.Rules {
+		rule.Imports = mergeImports(config.Imports, rule.Imports)
+	}
+
+	if err := config.Lint.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Header.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Provenance.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Deprecation.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Codegen.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 规范目标 Go 版本，规则继承配置中的设置
+	if config.GoVersion, err = NormalizeGoVersion(config.GoVersion); err != nil {
+		return nil, err
+	}
+	for _, rule := range config.Rules {
+		if rule.GoVersion == "" {
+			rule.GoVersion = config.GoVersion
+		}
+		if rule.GoVersion, err = NormalizeGoVersion(rule.GoVersion); err != nil {
+			return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+		}
+	}
+
+	// 按依赖关系排序规则，再去掉禁用的规则
+	rules, err := SortRules(config.Rules)
+	if err != nil {
+		return nil, err
+	}
+	config.Rules, config.Disabled = dropDisabled(rules)
+
+	// 提前检查条件表达式、file 通配模式、file_regex、排除模式、构建标签和 package 的语法
+	if err := checkWhen(config.Rules); err != nil {
+		return nil, err
+	}
+	for _, pattern := range config.Exclude {
+		if err := validateFileGlob(pattern); err != nil {
+			return nil, fmt.Errorf("exclude: %v", err)
+		}
+	}
+	for _, rule := range config.Rules {
+		for _, pattern := range rule.Exclude {
+			if err := validateFileGlob(pattern); err != nil {
+				return nil, fmt.Errorf("规则 %s 的 exclude: %v", rule.Name(), err)
+			}
+		}
+		rule.Exclude = append(append([]string(nil), config.Exclude...), rule.Exclude...)
+		for _, tag := range rule.BuildTags {
+			if err := validateBuildTag(tag); err != nil {
+				return nil, fmt.Errorf("规则 %s 的 build_tags: %v", rule.Name(), err)
+			}
+		}
+		if IsFileGlob(rule.File) {
+			if err := validateFileGlob(rule.File); err != nil {
+				return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+			}
+		}
+		switch {
+		case rule.File != "" && rule.Package != "":
+			return nil, fmt.Errorf("规则 %s 不能同时设置 file 和 package", rule.Name())
+		case rule.FileRegex != "":
+			if rule.File != "" || rule.Package != "" {
+				return nil, fmt.Errorf("规则 %s 设置了 file_regex，不能再设置 file 或 package", rule.Name())
+			}
+			if _, err := regexp.Compile(rule.FileRegex); err != nil {
+				return nil, fmt.Errorf("规则 %s 的 file_regex 无效: %v", rule.Name(), err)
+			}
+			continue
+		case rule.File != "":
+			if len(rule.BuildTags) > 0 && !IsFileGlob(rule.File) {
+				return nil, fmt.Errorf("规则 %s 的 file 是具体路径，不能设置 build_tags", rule.Name())
+			}
+			continue
+		case rule.Package != "":
+			if err := validatePackagePattern(rule.Package); err != nil {
+				return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+			}
+		case len(rule.Structs) == 0:
+			return nil, fmt.Errorf("规则 %s 没有设置 file 和 package 时必须列出要查找的结构体", rule.Name())
+		}
+		for _, st := range rule.Structs {
+			if st.Create {
+				return nil, fmt.Errorf("规则 %s 按结构体名称查找文件，不支持 create", rule.Name())
+			}
+		}
+	}
+
+	// 检查引用的代码片段是否存在
+	for _, rule := range config.Rules {
+		// 展开类型预设，按策略改写可空字段的类型，再合并结构体的默认标签
+		if err := ApplyPresets(rule, config.Presets); err != nil {
+			return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+		}
+		if rule.Nullable == "" {
+			rule.Nullable = config.Nullable
+		}
+		if err := ApplyNullable(rule, rule.Nullable, config.OptionType); err != nil {
+			return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+		}
+		for i := range rule.Structs {
+			if err := ApplyTagDefaults(&rule.Structs[i]); err != nil {
+				return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+			}
+		}
+		for _, st := range rule.Structs {
+			if IsStructPath(st.Name) {
+				if err := ValidateStructPath(st.Name); err != nil {
+					return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+				}
+				if st.Create || st.Constructor || len(st.RemoveMethods) > 0 || st.Receiver != "" || st.MapMethods != "" || st.Columns != "" || st.Accessors || st.Redacted || len(st.Sensitive) > 0 || st.RenameTo != "" {
+					return nil, fmt.Errorf("规则 %s 的结构体 %s 是内联结构体，不支持 create、constructor、remove_methods、receiver、map_methods、columns、accessors、redacted、sensitive 和 rename_to", rule.Name(), st.Name)
+				}
+			}
+			if st.Block != "" {
+				if !validBlockName(st.Block) {
+					return nil, fmt.Errorf("规则 %s 结构体 %s 的 block %q 不能为空白或包含空白", rule.Name(), st.Name, st.Block)
+				}
+				if st.Anchor != "" || st.SortByTag != "" {
+					return nil, fmt.Errorf("规则 %s 结构体 %s 的 block 不能与 anchor 或 sort_by_tag 同时使用", rule.Name(), st.Name)
+				}
+			}
+			if st.Receiver != "" && (!token.IsIdentifier(st.Receiver) || st.Receiver == "_") {
+				return nil, fmt.Errorf("规则 %s 结构体 %s 的 receiver %q 不是有效的标识符", rule.Name(), st.Name, st.Receiver)
+			}
+			for _, field := range st.Fields {
+				if field.Default == "" {
+					continue
+				}
+				if _, err := parser.ParseExpr(field.Default); err != nil {
+					return nil, fmt.Errorf("规则 %s 字段 %s.%s 的默认值 %q 不是有效的表达式: %v", rule.Name(), st.Name, field.Name, field.Default, err)
+				}
+			}
+			for _, rn := range st.RenameTags {
+				if err := rn.validate(); err != nil {
+					return nil, fmt.Errorf("规则 %s 结构体 %s: %v", rule.Name(), st.Name, err)
+				}
+			}
+			for _, d := range st.Deprecate {
+				if err := d.validate(); err != nil {
+					return nil, fmt.Errorf("规则 %s 结构体 %s: %v", rule.Name(), st.Name, err)
+				}
+				for _, rm := range st.Remove {
+					if rm.Name == d.Name {
+						return nil, fmt.Errorf("规则 %s 的字段 %s.%s 同时在 deprecate 和 remove 中，删除时请从 deprecate 中移除", rule.Name(), st.Name, d.Name)
+					}
+				}
+			}
+			for _, w := range st.Widen {
+				if err := w.validate(); err != nil {
+					return nil, fmt.Errorf("规则 %s 结构体 %s: %v", rule.Name(), st.Name, err)
+				}
+			}
+			if st.RenameTo != "" {
+				if !token.IsIdentifier(st.RenameTo) || st.RenameTo == st.Name {
+					return nil, fmt.Errorf("规则 %s 结构体 %s 的 rename_to %q 不是有效的新名称", rule.Name(), st.Name, st.RenameTo)
+				}
+				if st.Create {
+					return nil, fmt.Errorf("规则 %s 结构体 %s 不能同时设置 create 和 rename_to", rule.Name(), st.Name)
+				}
+			}
+			for _, rm := range st.Remove {
+				switch rm.Audit {
+				case "", "fail", "list":
+				case "rewrite":
+					if rm.Replacement == "" {
+						return nil, fmt.Errorf("规则 %s 删除字段 %s.%s 时使用 rewrite 但没有设置 replacement", rule.Name(), st.Name, rm.Name)
+					}
+				default:
+					return nil, fmt.Errorf("规则 %s 删除字段 %s.%s 的 audit %q 无效", rule.Name(), st.Name, rm.Name, rm.Audit)
+				}
+			}
+		}
+		switch rule.NormalizeAny {
+		case "", "interface{}":
+		case "any":
+			if beforeGenerics(rule.GoVersion) {
+				return nil, fmt.Errorf("规则 %s 的 normalize_any = \"any\" 需要 %s，目标版本为 %s", rule.Name(), genericsVersion, rule.GoVersion)
+			}
+		default:
+			return nil, fmt.Errorf("规则 %s 的 normalize_any %q 无效，只支持 any 和 interface{}", rule.Name(), rule.NormalizeAny)
+		}
+		switch rule.NormalizeAnyScope {
+		case "", "structs", "file":
+		default:
+			return nil, fmt.Errorf("规则 %s 的 normalize_any_scope %q 无效，只支持 structs 和 file", rule.Name(), rule.NormalizeAnyScope)
+		}
+		switch rule.ImportConflict {
+		case "", ImportConflictError, ImportConflictKeep, ImportConflictRewrite:
+		default:
+			return nil, fmt.Errorf("规则 %s 的 import_conflict %q 无效，只支持 error、keep 和 rewrite", rule.Name(), rule.ImportConflict)
+		}
+		switch rule.OnSyntaxError {
+		case "", SyntaxErrorFail, SyntaxErrorImports:
+		default:
+			return nil, fmt.Errorf("规则 %s 的 on_syntax_error %q 无效，只支持 fail 和 imports", rule.Name(), rule.OnSyntaxError)
+		}
+		if rule.TagFormat != "" && rule.TagFormat != "align" {
+			return nil, fmt.Errorf("规则 %s 的 tag_format %q 无效，只支持 align", rule.Name(), rule.TagFormat)
+		}
+		for _, ref := range rule.ApplySnippets {
+			if _, ok := config.Snippets[ref.Name]; !ok {
+				return nil, fmt.Errorf("规则 %s 引用的代码片段 %s 不存在", rule.Name(), ref.Name)
+			}
+		}
+	}
+
+	// 同一文件的每条规则都按当时的字段重新生成 ToMap、FromMap、Redacted 和列名声明，最终结果与规则顺序无关
+	mapSpecs := make(map[string]map[string]MapSpec)
+	redactSpecs := make(map[string]map[string]RedactSpec)
+	columnSpecs := make(map[string]map[string]string)
+	accessorSpecs := make(map[string]map[string]string)
+	for _, rule := range config.Rules {
+		for _, st := range rule.Structs {
+			if st.MapMethods != "" {
+				if mapSpecs[rule.File] == nil {
+					mapSpecs[rule.File] = make(map[string]MapSpec)
+				}
+				mapSpecs[rule.File][st.Name] = MapSpec{Key: st.MapMethods, Receiver: st.Receiver, ValueReceiver: config.Codegen.ValueReceiver()}
+			}
+			if st.Accessors {
+				if accessorSpecs[rule.File] == nil {
+					accessorSpecs[rule.File] = make(map[string]string)
+				}
+				accessorSpecs[rule.File][st.Name] = st.Receiver
+			}
+			if st.Columns != "" {
+				if columnSpecs[rule.File] == nil {
+					columnSpecs[rule.File] = make(map[string]string)
+				}
+				columnSpecs[rule.File][st.Name] = st.Columns
+			}
+			if st.Redacted || len(st.Sensitive) > 0 {
+				if redactSpecs[rule.File] == nil {
+					redactSpecs[rule.File] = make(map[string]RedactSpec)
+				}
+				spec := redactSpecs[rule.File][st.Name]
+				spec.Fields = append(spec.Fields, st.Sensitive...)
+				spec.Receiver, spec.ValueReceiver = st.Receiver, config.Codegen.ValueReceiver()
+				redactSpecs[rule.File][st.Name] = spec
+			}
+		}
+	}
+	for _, rule := range config.Rules {
+		rule.MapSpecs = mapSpecs[rule.File]
+		rule.ColumnSpecs = columnSpecs[rule.File]
+		rule.AccessorSpecs = accessorSpecs[rule.File]
+		rule.RedactSpecs = redactSpecs[rule.File]
+		rule.Codegen = config.Codegen
+	}
+
+	return config, nil
+}
+
+// checkWhen 检查所有规则和结构体中 when 表达式的语法
+func checkWhen(rules []*Rule) error {
+	for _, rule := range rules {
+		if rule.When != "" {
+			if _, err := ParseExpr(rule.When); err != nil {
+				return fmt.Errorf("规则 %s 的 when 表达式无效: %v", rule.Name(), err)
+			}
+		}
+		for _, st := range rule.Structs {
+			if st.When != "" {
+				if _, err := ParseExpr(st.When); err != nil {
+					return fmt.Errorf("规则 %s 中结构体 %s 的 when 表达式无效: %v", rule.Name(), st.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mergeImports 合并全局导入与规则的导入，同一路径以规则中的为准
+func mergeImports(global, local []Import) []Import {
+	if len(global) == 0 {
+		return local
+	}
+	paths := make(map[string]bool, len(local))
+	for _, imp := range local {
+		paths[imp.Path] = true
+	}
+	var imports []Import
+	for _, imp := range global {
+		if !paths[imp.Path] {
+			paths[imp.Path] = true
+			imports = append(imports, imp)
+		}
+	}
+	return append(imports, local...)
+}
@@ -0,0 +1,322 @@
+package logic
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 配置差异的类型
+const (
+	ConfigAdded   = "added"
+	ConfigRemoved = "removed"
+	ConfigChanged = "changed"
+)
+
+// ConfigChange 表示两份配置之间的一处语义差异
+type ConfigChange struct {
+	Kind string `json:"kind"`
+	// Rule 规则名称，规则本身增删时为该规则
+	Rule string `json:"rule"`
+	// Target 发生变化的对象，如 结构体 User、字段 User.Name、导入 time
+	Target string `json:"target"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// configMarks 输出时各差异类型的前缀
+var configMarks = map[string]string{ConfigAdded: "+", ConfigRemoved: "-", ConfigChanged: "~"}
+
+// DiffConfig 按规则名称、结构体名和字段名对比两份配置，列出新增、删除和修改的规则、结构体、字段、
+// 字段的类型与标签、导入，以及需要删除的字段和方法。两份配置都应已经过 ParseConfig 的展开
+func DiffConfig(old, new *Config) []ConfigChange {
+	var changes []ConfigChange
+	oldRules, newRules := rulesByName(old), rulesByName(new)
+	for _, name := range unionKeys(oldRules, newRules) {
+		a, b := oldRules[name], newRules[name]
+		switch {
+		case a == nil:
+			changes = append(changes, ConfigChange{Kind: ConfigAdded, Rule: name, Target: "规则", Detail: b.File})
+		case b == nil:
+			changes = append(changes, ConfigChange{Kind: ConfigRemoved, Rule: name, Target: "规则", Detail: a.File})
+		default:
+			changes = append(changes, diffRule(name, a, b)...)
+		}
+	}
+	return changes
+}
+
+// diffRule 对比同名规则的导入和结构体
+func diffRule(name string, a, b *Rule) []ConfigChange {
+	var changes []ConfigChange
+	add := func(kind, target, detail string) {
+		changes = append(changes, ConfigChange{Kind: kind, Rule: name, Target: target, Detail: detail})
+	}
+	if a.File != b.File {
+		add(ConfigChanged, "文件", a.File+" -> "+b.File)
+	}
+	if enabled, newEnabled := a.Enabled == nil || *a.Enabled, b.Enabled == nil || *b.Enabled; enabled != newEnabled {
+		add(ConfigChanged, "启用", fmt.Sprintf("%v -> %v", enabled, newEnabled))
+	}
+	if strings.Join(a.Requires, ",") != strings.Join(b.Requires, ",") {
+		add(ConfigChanged, "requires", fmt.Sprintf("%v -> %v", a.Requires, b.Requires))
+	}
+	if strings.Join(a.Tags, ",") != strings.Join(b.Tags, ",") {
+		add(ConfigChanged, "标签", fmt.Sprintf("%v -> %v", a.Tags, b.Tags))
+	}
+
+	oldImports, newImports := make(map[string]string), make(map[string]string)
+	for _, imp := range a.Imports {
+		oldImports[imp.Path] = imp.Alias
+	}
+	for _, imp := range b.Imports {
+		newImports[imp.Path] = imp.Alias
+	}
+	for _, path := range unionKeys(oldImports, newImports) {
+		alias, inOld := oldImports[path]
+		newAlias, inNew := newImports[path]
+		switch {
+		case !inOld:
+			add(ConfigAdded, "导入 "+path, newAlias)
+		case !inNew:
+			add(ConfigRemoved, "导入 "+path, alias)
+		case alias != newAlias:
+			add(ConfigChanged, "导入 "+path, fmt.Sprintf("别名 %q -> %q", alias, newAlias))
+		}
+	}
+
+	oldStructs, newStructs := make(map[string]*Struct), make(map[string]*Struct)
+	for i := range a.Structs {
+		oldStructs[a.Structs[i].Name] = &a.Structs[i]
+	}
+	for i := range b.Structs {
+		newStructs[b.Structs[i].Name] = &b.Structs[i]
+	}
+	for _, st := range unionKeys(oldStructs, newStructs) {
+		x, y := oldStructs[st], newStructs[st]
+		switch {
+		case x == nil:
+			add(ConfigAdded, "结构体 "+st, fmt.Sprintf("%d 个字段", len(y.Fields)))
+		case y == nil:
+			add(ConfigRemoved, "结构体 "+st, fmt.Sprintf("%d 个字段", len(x.Fields)))
+		default:
+			for _, c := range diffStruct(x, y) {
+				c.Rule = name
+				changes = append(changes, c)
+			}
+		}
+	}
+	return changes
+}
+
+// diffStruct 对比同名结构体的字段、需要删除的字段和方法
+func diffStruct(a, b *Struct) []ConfigChange {
+	var changes []ConfigChange
+	add := func(kind, target, detail string) {
+		changes = append(changes, ConfigChange{Kind: kind, Target: target, Detail: detail})
+	}
+
+	oldFields, newFields := make(map[string]Field), make(map[string]Field)
+	for _, f := range a.Fields {
+		oldFields[f.Name] = f
+	}
+	for _, f := range b.Fields {
+		newFields[f.Name] = f
+	}
+	for _, name := range unionKeys(oldFields, newFields) {
+		target := "字段 " + a.Name + "." + name
+		x, inOld := oldFields[name]
+		y, inNew := newFields[name]
+		switch {
+		case !inOld:
+			add(ConfigAdded, target, fieldSummary(y))
+		case !inNew:
+			add(ConfigRemoved, target, fieldSummary(x))
+		default:
+			if x.Type != y.Type {
+				add(ConfigChanged, target, fmt.Sprintf("类型 %s -> %s", x.Type, y.Type))
+			}
+			for _, detail := range diffTags(x.TagValue(), y.TagValue()) {
+				add(ConfigChanged, target, detail)
+			}
+			if x.Default != y.Default {
+				add(ConfigChanged, target, fmt.Sprintf("默认值 %q -> %q", x.Default, y.Default))
+			}
+		}
+	}
+
+	oldRemoves, newRemoves := make(map[string]bool), make(map[string]bool)
+	for _, rm := range a.Remove {
+		oldRemoves[rm.Name] = true
+	}
+	for _, rm := range b.Remove {
+		newRemoves[rm.Name] = true
+	}
+	for _, name := range unionKeys(oldRemoves, newRemoves) {
+		if oldRemoves[name] != newRemoves[name] {
+			add(setKind(newRemoves[name]), "删除字段 "+a.Name+"."+name, "")
+		}
+	}
+	oldDeprecated, newDeprecated := make(map[string]bool), make(map[string]bool)
+	for _, d := range a.Deprecate {
+		oldDeprecated[d.Name] = true
+	}
+	for _, d := range b.Deprecate {
+		newDeprecated[d.Name] = true
+	}
+	for _, name := range unionKeys(oldDeprecated, newDeprecated) {
+		if oldDeprecated[name] != newDeprecated[name] {
+			add(setKind(newDeprecated[name]), "废弃字段 "+a.Name+"."+name, "")
+		}
+	}
+	oldWiden, newWiden := make(map[string]string), make(map[string]string)
+	for _, w := range a.Widen {
+		oldWiden[w.Name] = w.Type
+	}
+	for _, w := range b.Widen {
+		newWiden[w.Name] = w.Type
+	}
+	for _, name := range unionKeys(oldWiden, newWiden) {
+		switch old, typ := oldWiden[name], newWiden[name]; {
+		case old == "" || typ == "":
+			add(setKind(old == ""), "放宽类型 "+a.Name+"."+name, old+typ)
+		case old != typ:
+			add(ConfigChanged, "放宽类型 "+a.Name+"."+name, old+" -> "+typ)
+		}
+	}
+	oldMethods, newMethods := make(map[string]bool), make(map[string]bool)
+	for _, m := range a.RemoveMethods {
+		oldMethods[m] = true
+	}
+	for _, m := range b.RemoveMethods {
+		newMethods[m] = true
+	}
+	for _, name := range unionKeys(oldMethods, newMethods) {
+		if oldMethods[name] != newMethods[name] {
+			add(setKind(newMethods[name]), "删除方法 "+a.Name+"."+name+"()", "")
+		}
+	}
+	if a.RenameTo != b.RenameTo {
+		add(ConfigChanged, "改名 "+a.Name, fmt.Sprintf("%q -> %q", a.RenameTo, b.RenameTo))
+	}
+	if a.Receiver != b.Receiver {
+		add(ConfigChanged, "接收者 "+a.Name, fmt.Sprintf("%q -> %q", a.Receiver, b.Receiver))
+	}
+	oldRenames, newRenames := make(map[string]string), make(map[string]string)
+	for _, rn := range a.RenameTags {
+		oldRenames[rn.Field+" "+rn.Key] = rn.Name
+	}
+	for _, rn := range b.RenameTags {
+		newRenames[rn.Field+" "+rn.Key] = rn.Name
+	}
+	for _, key := range unionKeys(oldRenames, newRenames) {
+		x, inOld := oldRenames[key]
+		y, inNew := newRenames[key]
+		field, tag, _ := strings.Cut(key, " ")
+		target := "修改标签 " + a.Name + "." + field + " " + tag
+		switch {
+		case !inOld:
+			add(ConfigAdded, target, y)
+		case !inNew:
+			add(ConfigRemoved, target, x)
+		case x != y:
+			add(ConfigChanged, target, x+" -> "+y)
+		}
+	}
+	return changes
+}
+
+// diffTags 按键对比两个标签，返回每个新增、删除或修改的键的说明
+func diffTags(a, b string) []string {
+	oldPairs, err1 := ParseTag(a)
+	newPairs, err2 := ParseTag(b)
+	if err1 != nil || err2 != nil {
+		if a != b {
+			return []string{fmt.Sprintf("标签 %q -> %q", a, b)}
+		}
+		return nil
+	}
+	oldTags, newTags := make(map[string]string), make(map[string]string)
+	for _, p := range oldPairs {
+		oldTags[p.Key] = p.Value
+	}
+	for _, p := range newPairs {
+		newTags[p.Key] = p.Value
+	}
+	var details []string
+	for _, key := range unionKeys(oldTags, newTags) {
+		x, inOld := oldTags[key]
+		y, inNew := newTags[key]
+		switch {
+		case !inOld:
+			details = append(details, fmt.Sprintf("新增标签 %s:%s", key, strconv.Quote(y)))
+		case !inNew:
+			details = append(details, fmt.Sprintf("删除标签 %s:%s", key, strconv.Quote(x)))
+		case x != y:
+			details = append(details, fmt.Sprintf("标签 %s %s -> %s", key, strconv.Quote(x), strconv.Quote(y)))
+		}
+	}
+	return details
+}
+
+// fieldSummary 返回字段的类型和标签，用于新增、删除字段的说明
+func fieldSummary(f Field) string {
+	if tag := f.TagValue(); tag != "" {
+		return f.Type + " `" + tag + "`"
+	}
+	return f.Type
+}
+
+// setKind 返回集合成员变化对应的差异类型
+func setKind(added bool) string {
+	if added {
+		return ConfigAdded
+	}
+	return ConfigRemoved
+}
+
+// rulesByName 按规则名称索引规则
+func rulesByName(config *Config) map[string]*Rule {
+	rules := make(map[string]*Rule, len(config.Rules))
+	for _, rule := range config.Rules {
+		rules[rule.Name()] = rule
+	}
+	return rules
+}
+
+// unionKeys 返回两个 map 的键的并集，按字典序排列
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteConfigDiff 逐行输出配置差异，+ 新增、- 删除、~ 修改，末尾附各类差异的数量。
+// 目标中含有中文，不用 tabwriter 对齐
+func WriteConfigDiff(w io.Writer, changes []ConfigChange) error {
+	counts := make(map[string]int)
+	for _, c := range changes {
+		counts[c.Kind]++
+		line := fmt.Sprintf("%s [%s] %s", configMarks[c.Kind], c.Rule, c.Target)
+		if c.Detail != "" {
+			line += ": " + c.Detail
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	if len(changes) > 0 {
+		fmt.Fprintln(w)
+	}
+	_, err := fmt.Fprintf(w, "配置差异: 新增 %d 删除 %d 修改 %d\n", counts[ConfigAdded], counts[ConfigRemoved], counts[ConfigChanged])
+	return err
+}
@@ -0,0 +1,300 @@
+package logic
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	cueerrors "cuelang.org/go/cue/errors"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configSchema CUE 配置的 schema，见 schema.cue
+//
+//go:embed schema.cue
+var configSchema string
+
+// NewConfig 处理在内存中构造的配置（如由命令行参数生成的规则），
+// 与从文件解析的配置一样展开预设和变量、补齐默认值并校验
+func NewConfig(config *Config) (*Config, error) {
+	return prepareConfig(config)
+}
+
+// ParseConfig 按扩展名解析配置文件：.yaml 和 .yml 为 YAML，.json 为 JSON，.cue 为 CUE，.hcl 为 HCL，
+// .star 为生成配置的 Starlark 脚本，其余按 TOML 解析。
+// 各种格式使用相同的结构，键名与 TOML 中一致。filename 为目录时按文件名顺序合并目录下的所有配置文件，
+// 为 http 或 https 地址时下载后解析，见 ConfigChecksum
+func ParseConfig(filename string) (*Config, error) {
+	if IsRemoteConfig(filename) {
+		return parseRemoteConfig(filename)
+	}
+	if info, err := os.Stat(filename); err == nil && info.IsDir() {
+		return parseConfigDir(filename)
+	}
+	return parseConfigFile(filename, decoderFor(filename))
+}
+
+// decoderFor 按扩展名返回配置文件的解码函数
+func decoderFor(filename string) func(string) (*Config, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return decodeYAML
+	case ".json":
+		return decodeJSON
+	case ".cue":
+		return decodeCUE
+	case ".hcl":
+		return decodeHCL
+	case ".star":
+		return decodeStarlark
+	default:
+		return decodeTOML
+	}
+}
+
+// ParseYAML 从YAML文件解析配置。YAML 先解码为通用的值再转换为 JSON 解码到 Config，
+// 从而复用结构体上的 json 标签（与 toml 标签一致）以及 FieldTags 等类型的多种写法
+func ParseYAML(filename string) (*Config, error) {
+	return parseConfigFile(filename, decodeYAML)
+}
+
+// decodeYAML 解码YAML配置文件，不展开和检查
+func decodeYAML(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开YAML文件: %v", err)
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析YAML文件失败: %v", err)
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("解析YAML文件失败: %v", err)
+	}
+	var config Config
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("解析YAML文件失败: %v", err)
+	}
+	return &config, nil
+}
+
+// ParseJSON 从JSON文件解析配置，键名与 TOML 中一致
+func ParseJSON(filename string) (*Config, error) {
+	return parseConfigFile(filename, decodeJSON)
+}
+
+// decodeJSON 解码JSON配置文件，不展开和检查
+func decodeJSON(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开JSON文件: %v", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析JSON文件失败: %v", err)
+	}
+	return &config, nil
+}
+
+// ParseCUE 从CUE文件解析配置：先与内置 schema 中的 #Config 合一并校验，
+// 未知的键、类型不符或取值不在枚举中时报告所有错误的位置，校验通过后导出为 JSON 解码到 Config
+func ParseCUE(filename string) (*Config, error) {
+	return parseConfigFile(filename, decodeCUE)
+}
+
+// decodeCUE 解码CUE配置文件，不展开和检查
+func decodeCUE(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开CUE文件: %v", err)
+	}
+
+	ctx := cuecontext.New()
+	schema := ctx.CompileString(configSchema, cue.Filename("schema.cue"))
+	if err := schema.Err(); err != nil {
+		return nil, fmt.Errorf("编译内置 schema 失败: %v", err)
+	}
+	value := ctx.CompileBytes(data, cue.Filename(filename))
+	if err := value.Err(); err != nil {
+		return nil, fmt.Errorf("解析CUE文件失败: %s", cueErrorDetails(err))
+	}
+	value = schema.LookupPath(cue.ParsePath("#Config")).Unify(value)
+	if err := value.Validate(cue.Concrete(true)); err != nil {
+		return nil, fmt.Errorf("CUE配置不符合 schema: %s", cueErrorDetails(err))
+	}
+
+	raw, err := value.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("导出CUE配置失败: %s", cueErrorDetails(err))
+	}
+	var config Config
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("解析CUE文件失败: %v", err)
+	}
+	return &config, nil
+}
+
+// cueErrorDetails 把 CUE 错误展开为逐条带位置的说明
+func cueErrorDetails(err error) string {
+	return strings.TrimSpace(cueerrors.Details(err, nil))
+}
+
+// WriteConfig 以 TOML 输出配置，省略空值。用于查看合并 extends、include 并展开之后实际生效的配置，
+// 表中的键按名称排序
+func WriteConfig(w io.Writer, config *Config) error {
+	doc, err := configDoc(config)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		return nil
+	}
+	return toml.NewEncoder(w).Encode(doc)
+}
+
+// configDoc 把配置转换为省略空值的通用值，全部为空时返回 nil
+func configDoc(config *Config) (interface{}, error) {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	// 保留整数，否则 version = 2 会输出为 2.0，再次解码到 int 时失败
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return pruneEmpty(doc, reflect.TypeOf(config).Elem()), nil
+}
+
+// pruneEmpty 按配置的 Go 类型 t 递归去掉通用值中没有设置的项，全部为空时返回 nil：
+// 结构体中为零值的非指针字段、null、空表和空列表。指针字段（如 enabled = false）只要设置了就保留，
+// 映射中的键和列表中的元素也保留，以便输出的配置再次解码后与原配置一致
+func pruneEmpty(v interface{}, t reflect.Type) interface{} {
+	return prune(v, t, false)
+}
+
+// prune 实现 pruneEmpty，keep 表示值是显式设置的，即使为零值也保留
+func prune(v interface{}, t reflect.Type, keep bool) interface{} {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t, keep = t.Elem(), true
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if t != nil && t.Kind() == reflect.Struct {
+			fields := jsonFields(t)
+			for key, value := range v {
+				if pruned := prune(value, fields[key], false); pruned == nil {
+					delete(v, key)
+				} else {
+					v[key] = pruned
+				}
+			}
+		} else {
+			var elem reflect.Type
+			if t != nil && t.Kind() == reflect.Map {
+				elem = t.Elem()
+			}
+			for key, value := range v {
+				if pruned := prune(value, elem, true); pruned == nil {
+					delete(v, key)
+				} else {
+					v[key] = pruned
+				}
+			}
+		}
+		if len(v) == 0 && !keep {
+			return nil
+		}
+		return v
+	case []interface{}:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		// 列表中的元素保留位置，空表保留为空表
+		out := []interface{}{}
+		for _, value := range v {
+			if pruned := prune(value, elem, true); pruned != nil {
+				out = append(out, pruned)
+			}
+		}
+		if len(out) == 0 && !keep {
+			return nil
+		}
+		return out
+	case string:
+		if v == "" && !keep {
+			return nil
+		}
+	case bool:
+		if !v && !keep {
+			return nil
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			if n == 0 && !keep {
+				return nil
+			}
+			return n
+		}
+		f, _ := v.Float64()
+		if f == 0 && !keep {
+			return nil
+		}
+		return f
+	case nil:
+		return nil
+	}
+	return v
+}
+
+// jsonFields 返回结构体按 JSON 名称索引的字段类型，嵌入结构体的字段合并到外层
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for key, typ := range jsonFields(embedded) {
+					if _, ok := fields[key]; !ok {
+						fields[key] = typ
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
@@ -0,0 +1,205 @@
+package logic
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		check func(t *testing.T, config *Config)
+	}{
+		{
+			name: "nested structs and fields",
+			files: map[string]string{"config.json": `{
+	"version": 2,
+	"rules": [{
+		"id": "user",
+		"file": "user.go",
+		"imports": [{"path": "time"}],
+		"structs": [{
+			"name": "User",
+			"fields": [
+				{"name": "CreatedAt", "type": "time.Time", "tags": {"json": "created_at", "db": "created_at"}},
+				{"name": "Name", "type": "string", "tags": "json:\"name\""}
+			]
+		}]
+	}]
+}`},
+			check: func(t *testing.T, config *Config) {
+				if len(config.Rules) != 1 {
+					t.Fatalf("got %d rules, want 1", len(config.Rules))
+				}
+				rule := config.Rules[0]
+				if rule.ID != "user" || rule.File != "user.go" {
+					t.Errorf("rule = %s %s, want user user.go", rule.ID, rule.File)
+				}
+				if want := []Import{{Path: "time"}}; !reflect.DeepEqual(rule.Imports, want) {
+					t.Errorf("imports = %v, want %v", rule.Imports, want)
+				}
+				if len(rule.Structs) != 1 || len(rule.Structs[0].Fields) != 2 {
+					t.Fatalf("structs = %+v, want User with 2 fields", rule.Structs)
+				}
+				fields := rule.Structs[0].Fields
+				if fields[0].Name != "CreatedAt" || fields[0].Type != "time.Time" {
+					t.Errorf("fields[0] = %s %s, want CreatedAt time.Time", fields[0].Name, fields[0].Type)
+				}
+				if got, want := string(fields[0].Tags), `db:"created_at" json:"created_at"`; got != want {
+					t.Errorf("fields[0].tags = %s, want %s", got, want)
+				}
+				if got, want := string(fields[1].Tags), `json:"name"`; got != want {
+					t.Errorf("fields[1].tags = %s, want %s", got, want)
+				}
+			},
+		},
+		{
+			name: "rules inherit global settings",
+			files: map[string]string{"config.json": `{
+	"version": 2,
+	"go_version": "1.17",
+	"imports": [{"path": "time"}, {"path": "github.com/google/uuid"}],
+	"rules": [{"file": "a.go", "imports": [{"path": "fmt"}], "structs": [{"name": "A"}]}]
+}`},
+			check: func(t *testing.T, config *Config) {
+				rule := config.Rules[0]
+				if rule.GoVersion != "go1.17" {
+					t.Errorf("go_version = %q, want go1.17", rule.GoVersion)
+				}
+				want := []Import{{Path: "time"}, {Path: "github.com/google/uuid"}, {Path: "fmt"}}
+				if !reflect.DeepEqual(rule.Imports, want) {
+					t.Errorf("imports = %v, want %v", rule.Imports, want)
+				}
+			},
+		},
+		{
+			name: "rule settings override global settings",
+			files: map[string]string{"config.json": `{
+	"version": 2,
+	"go_version": "1.17",
+	"nullable": "sql",
+	"imports": [{"path": "github.com/google/uuid"}],
+	"rules": [{
+		"file": "a.go",
+		"go_version": "1.21",
+		"nullable": "pointer",
+		"imports": [{"path": "github.com/google/uuid", "alias": "guid"}],
+		"structs": [{"name": "A"}]
+	}]
+}`},
+			check: func(t *testing.T, config *Config) {
+				rule := config.Rules[0]
+				if rule.GoVersion != "go1.21" {
+					t.Errorf("go_version = %q, want go1.21", rule.GoVersion)
+				}
+				if rule.Nullable != "pointer" {
+					t.Errorf("nullable = %q, want pointer", rule.Nullable)
+				}
+				want := []Import{{Path: "github.com/google/uuid", Alias: "guid"}}
+				if !reflect.DeepEqual(rule.Imports, want) {
+					t.Errorf("imports = %v, want %v", rule.Imports, want)
+				}
+			},
+		},
+		{
+			name: "extends inherits parent rules",
+			files: map[string]string{
+				"base.json": `{
+	"version": 2,
+	"go_version": "1.17",
+	"rules": [
+		{"id": "a", "file": "a.go", "structs": [{"name": "A", "fields": [{"name": "X", "type": "int"}]}]},
+		{"id": "b", "file": "b.go", "structs": [{"name": "B", "fields": [{"name": "Y", "type": "int"}]}]}
+	]
+}`,
+				"config.json": `{
+	"version": 2,
+	"extends": "base.json",
+	"rules": [{"id": "c", "file": "c.go", "structs": [{"name": "C"}]}]
+}`,
+			},
+			check: func(t *testing.T, config *Config) {
+				if got, want := ruleIDs(config), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+					t.Errorf("rules = %v, want %v", got, want)
+				}
+				for _, rule := range config.Rules {
+					if rule.GoVersion != "go1.17" {
+						t.Errorf("rule %s go_version = %q, want go1.17 from the parent", rule.ID, rule.GoVersion)
+					}
+				}
+			},
+		},
+		{
+			name: "extends child overrides parent",
+			files: map[string]string{
+				"base.json": `{
+	"version": 2,
+	"go_version": "1.17",
+	"rules": [
+		{"id": "a", "file": "a.go", "structs": [{"name": "A", "fields": [{"name": "X", "type": "int"}]}]},
+		{"file": "b.go", "structs": [
+			{"name": "B", "fields": [{"name": "Y", "type": "int"}]},
+			{"name": "D", "fields": [{"name": "Z", "type": "int"}]}
+		]}
+	]
+}`,
+				"config.json": `{
+	"version": 2,
+	"extends": "base.json",
+	"go_version": "1.21",
+	"rules": [
+		{"id": "a", "file": "a.go", "structs": [{"name": "A", "fields": [{"name": "X", "type": "int64"}]}]},
+		{"file": "b.go", "structs": [{"name": "B", "fields": [{"name": "Y", "type": "string"}]}]}
+	]
+}`,
+			},
+			check: func(t *testing.T, config *Config) {
+				if config.GoVersion != "go1.21" {
+					t.Errorf("go_version = %q, want go1.21 from the child", config.GoVersion)
+				}
+				types := make(map[string]string)
+				for _, rule := range config.Rules {
+					for _, st := range rule.Structs {
+						for _, field := range st.Fields {
+							if _, ok := types[st.Name+"."+field.Name]; ok {
+								t.Errorf("field %s.%s configured twice", st.Name, field.Name)
+							}
+							types[st.Name+"."+field.Name] = field.Type
+						}
+					}
+				}
+				want := map[string]string{"A.X": "int64", "B.Y": "string", "D.Z": "int"}
+				if !reflect.DeepEqual(types, want) {
+					t.Errorf("fields = %v, want %v", types, want)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			config, err := ParseJSON(filepath.Join(dir, "config.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, config)
+		})
+	}
+}
+
+// ruleIDs 返回配置中规则的 ID
+func ruleIDs(config *Config) []string {
+	var ids []string
+	for _, rule := range config.Rules {
+		ids = append(ids, rule.ID)
+	}
+	return ids
+}
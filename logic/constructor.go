@@ -0,0 +1,31 @@
+package logic
+
+import (
+	"strings"
+)
+
+// ConstructorName 返回结构体构造函数的名称
+func ConstructorName(structName string) string {
+	return "New" + structName
+}
+
+// ConstructorSource 生成结构体的构造函数源码，设置了 Default 的字段按默认值初始化，
+// 其余字段保持零值
+func ConstructorSource(st Struct) string {
+	var sb strings.Builder
+	name := ConstructorName(st.Name)
+	sb.WriteString("// " + name + " 创建 " + st.Name + "\n")
+	sb.WriteString("func " + name + "() *" + st.Name + " {\n")
+	sb.WriteString("\treturn &" + st.Name + "{")
+	var inits []string
+	for _, field := range st.Fields {
+		if field.Default != "" {
+			inits = append(inits, "\t\t"+field.Name+": "+field.Default+",\n")
+		}
+	}
+	if len(inits) > 0 {
+		sb.WriteString("\n" + strings.Join(inits, "") + "\t")
+	}
+	sb.WriteString("}\n}\n")
+	return sb.String()
+}
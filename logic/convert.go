@@ -0,0 +1,293 @@
+package logic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConvertConfig 把配置文件转换为 to 的扩展名对应的格式（TOML、JSON 或 YAML），不展开 extends、include、
+// 变量和预设。源文件是 TOML 或 YAML、目标是 TOML 或 YAML 时保留写在键和表前面的注释，
+// 同时返回无法保留的注释段数（目标为 JSON 时为全部注释）
+func ConvertConfig(from, to string) ([]byte, int, error) {
+	format := configFormat(to)
+	switch format {
+	case "toml", "json", "yaml":
+	default:
+		return nil, 0, fmt.Errorf("不支持转换为 %s，目标只能是 .toml、.json、.yaml 或 .yml", filepath.Ext(to))
+	}
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return nil, 0, fmt.Errorf("读取配置失败: %v", err)
+	}
+	config, err := decoderFor(from)(from)
+	if err != nil {
+		return nil, 0, err
+	}
+	doc, err := configDoc(config)
+	if err != nil {
+		return nil, 0, err
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+
+	var comments map[string]string
+	switch configFormat(from) {
+	case "toml":
+		comments = tomlComments(data)
+	case "yaml":
+		comments, err = yamlComments(data)
+		if err != nil {
+			return nil, 0, fmt.Errorf("解析YAML文件失败: %v", err)
+		}
+	}
+
+	var out []byte
+	used := 0
+	switch format {
+	case "toml":
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+			return nil, 0, err
+		}
+		out, used = insertTOMLComments(buf.Bytes(), comments)
+	case "json":
+		out, err = json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, '\n')
+	case "yaml":
+		var node yaml.Node
+		if err := node.Encode(doc); err != nil {
+			return nil, 0, err
+		}
+		used = attachYAMLComments(&node, "", comments)
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return nil, 0, err
+		}
+		enc.Close()
+		out = buf.Bytes()
+	}
+	return out, len(comments) - used, nil
+}
+
+// configFormat 按扩展名返回配置文件的格式，与 decoderFor 的规则一致
+func configFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	case ".cue":
+		return "cue"
+	case ".hcl":
+		return "hcl"
+	case ".star":
+		return "starlark"
+	default:
+		return "toml"
+	}
+}
+
+// keyPath 拼接注释所在的键路径，如 rules[0].structs[1].name
+func keyPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// tomlKeyLine 匹配 TOML 的赋值行，键可以是带点的键
+var tomlKeyLine = regexp.MustCompile(`^\s*((?:"[^"]*"|'[^']*'|[A-Za-z0-9_-]+)(?:\s*\.\s*(?:"[^"]*"|'[^']*'|[A-Za-z0-9_-]+))*)\s*=`)
+
+// scanTOMLKeys 逐行扫描 TOML 文本，对每个表头和赋值行按行号调用 fn，传入它的键路径（表数组带下标）。
+// 多行字符串中的内容不作为键
+func scanTOMLKeys(data []byte, fn func(line int, path string)) {
+	// arrays 记录表数组当前的下标，键为不带下标的路径
+	arrays := make(map[string]int)
+	table := ""
+	multiline := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		if multiline != "" {
+			if strings.Count(text, multiline)%2 == 1 {
+				multiline = ""
+			}
+			continue
+		}
+		if m := tomlHeader.FindStringSubmatch(text); m != nil {
+			parts := strings.Split(normalizeTableName(m[1]), ".")
+			path := ""
+			for i, part := range parts {
+				path = keyPath(path, part)
+				if i == len(parts)-1 && strings.HasPrefix(strings.TrimSpace(text), "[[") {
+					if _, ok := arrays[path]; !ok {
+						arrays[path] = -1
+					}
+					arrays[path]++
+				}
+				if index, ok := arrays[path]; ok {
+					path += "[" + strconv.Itoa(index) + "]"
+				}
+			}
+			table = path
+			fn(n, table)
+			continue
+		}
+		if m := tomlKeyLine.FindStringSubmatch(text); m != nil {
+			fn(n, keyPath(table, normalizeTableName(m[1])))
+			for _, quote := range []string{`"""`, `'''`} {
+				if strings.Count(text, quote)%2 == 1 {
+					multiline = quote
+				}
+			}
+		}
+	}
+}
+
+// tomlComments 收集 TOML 中写在键或表前面的连续注释行，键为键路径，值为去掉 # 的注释文本
+func tomlComments(data []byte) map[string]string {
+	lines := strings.Split(string(data), "\n")
+	comments := make(map[string]string)
+	scanTOMLKeys(data, func(line int, path string) {
+		var block []string
+		for i := line - 2; i >= 0; i-- {
+			text := strings.TrimSpace(lines[i])
+			if !strings.HasPrefix(text, "#") {
+				break
+			}
+			block = append([]string{strings.TrimSpace(strings.TrimPrefix(text, "#"))}, block...)
+		}
+		if len(block) > 0 {
+			comments[path] = strings.Join(block, "\n")
+		}
+	})
+	return comments
+}
+
+// insertTOMLComments 在 TOML 文本中对应的键和表前面插入注释，返回插入的注释段数
+func insertTOMLComments(data []byte, comments map[string]string) ([]byte, int) {
+	if len(comments) == 0 {
+		return data, 0
+	}
+	insert := make(map[int]string)
+	scanTOMLKeys(data, func(line int, path string) {
+		if comment, ok := comments[path]; ok {
+			insert[line] = comment
+		}
+	})
+	var buf bytes.Buffer
+	for i, text := range strings.SplitAfter(string(data), "\n") {
+		if comment, ok := insert[i+1]; ok {
+			indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+			for _, c := range strings.Split(comment, "\n") {
+				buf.WriteString(strings.TrimRight(indent+"# "+c, " ") + "\n")
+			}
+		}
+		buf.WriteString(text)
+	}
+	return buf.Bytes(), len(insert)
+}
+
+// yamlComments 收集 YAML 中键前面的注释，键路径的写法与 TOML 一致
+func yamlComments(data []byte) (map[string]string, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	comments := make(map[string]string)
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key := keyPath(path, n.Content[i].Value)
+				if c := yamlCommentText(n.Content[i].HeadComment); c != "" {
+					comments[key] = c
+				}
+				walk(n.Content[i+1], key)
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				item := path + "[" + strconv.Itoa(i) + "]"
+				if text := yamlCommentText(c.HeadComment); text != "" {
+					comments[item] = text
+				}
+				walk(c, item)
+			}
+		}
+	}
+	walk(&node, "")
+	return comments, nil
+}
+
+// yamlCommentText 去掉 YAML 注释每行的 # 前缀
+func yamlCommentText(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// attachYAMLComments 把注释设置到 YAML 节点中对应的键上，返回设置的注释段数
+func attachYAMLComments(n *yaml.Node, path string, comments map[string]string) int {
+	if len(comments) == 0 {
+		return 0
+	}
+	head := func(comment string) string {
+		lines := strings.Split(comment, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("# "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	}
+	used := 0
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			used += attachYAMLComments(c, path, comments)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := keyPath(path, n.Content[i].Value)
+			if comment, ok := comments[key]; ok {
+				n.Content[i].HeadComment = head(comment)
+				used++
+			}
+			used += attachYAMLComments(n.Content[i+1], key, comments)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			item := path + "[" + strconv.Itoa(i) + "]"
+			if comment, ok := comments[item]; ok {
+				c.HeadComment = head(comment)
+				used++
+			}
+			used += attachYAMLComments(c, item, comments)
+		}
+	}
+	return used
+}
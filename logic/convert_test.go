@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertConfigKeepsExplicitValues(t *testing.T) {
+	src := `version = 2
+
+[codegen]
+getter_prefix = ""
+
+[[rules]]
+file = "a.go"
+enabled = false
+
+[[rules.structs]]
+name = "User"
+
+[[rules.structs.fields]]
+name = "ID"
+type = "int64"
+
+[[rules]]
+file = "b.go"
+enabled = true
+`
+	dir := t.TempDir()
+	from := filepath.Join(dir, "from.toml")
+	if err := os.WriteFile(from, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ext := range []string{".toml", ".json", ".yaml"} {
+		t.Run(ext, func(t *testing.T) {
+			to := filepath.Join(dir, "to"+ext)
+			data, _, err := ConvertConfig(from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(to, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			config, err := decoderFor(to)(to)
+			if err != nil {
+				t.Fatalf("decode converted config: %v\n%s", err, data)
+			}
+			if len(config.Rules) != 2 {
+				t.Fatalf("got %d rules, want 2\n%s", len(config.Rules), data)
+			}
+			if enabled := config.Rules[0].Enabled; enabled == nil || *enabled {
+				t.Errorf("rules[0].enabled = %v, want false\n%s", enabled, data)
+			}
+			if enabled := config.Rules[1].Enabled; enabled == nil || !*enabled {
+				t.Errorf("rules[1].enabled = %v, want true\n%s", enabled, data)
+			}
+			if prefix := config.Codegen.GetterPrefix; prefix == nil || *prefix != "" {
+				t.Errorf("codegen.getter_prefix = %v, want empty string\n%s", prefix, data)
+			}
+			if config.Version != 2 {
+				t.Errorf("version = %d, want 2", config.Version)
+			}
+		})
+	}
+}
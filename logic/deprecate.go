@@ -0,0 +1,185 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// deprecatedPrefix 废弃说明段落的开头，与 Go 的约定一致，gopls 和 staticcheck 据此提示
+const deprecatedPrefix = "// Deprecated:"
+
+// DeprecateField 结构体表示废弃一个已有字段的第一阶段：在字段的文档注释中加上 Deprecated 段落，
+// 并把指定标签改为 "-"，使编码时不再输出该字段。之后把它从 deprecate 移到 remove 即删除字段；
+// 配置了 [deprecation] 时删除前检查运行状态目录中记录的废弃时间，见 Lifecycle
+type DeprecateField struct {
+	Name string `json:"name" toml:"name"`
+	// Message 废弃说明，如 "改用 Email"
+	Message string `json:"message" toml:"message"`
+	// Tags 改为 "-" 的标签键，默认为 json
+	Tags []string `json:"tags" toml:"tags"`
+}
+
+// tagKeys 返回需要改为 "-" 的标签键
+func (d DeprecateField) tagKeys() []string {
+	if d.Tags == nil {
+		return []string{"json"}
+	}
+	return d.Tags
+}
+
+// validate 检查废弃字段的配置
+func (d DeprecateField) validate() error {
+	if d.Name == "" || d.Message == "" {
+		return fmt.Errorf("deprecate 必须设置 name 和 message")
+	}
+	if strings.Contains(d.Message, "\n") {
+		return fmt.Errorf("字段 %s 的废弃说明不能包含换行", d.Name)
+	}
+	for _, key := range d.Tags {
+		if key == "" || strings.ContainsAny(key, " :\"`") {
+			return fmt.Errorf("字段 %s 的标签键 %q 无效", d.Name, key)
+		}
+	}
+	return nil
+}
+
+// FieldDeprecation 记录一个被标记为废弃的字段
+type FieldDeprecation struct {
+	Struct string
+	Field  string
+}
+
+// DeprecateFields 在格式化后的源码中标记废弃字段：结构体名 -> 废弃列表。
+// 文档注释已有 Deprecated 段落、标签已经是 "-" 时不重复修改，只有实际修改过的字段才返回
+func DeprecateFields(src []byte, deprecations map[string][]DeprecateField) ([]byte, []FieldDeprecation, error) {
+	if len(deprecations) == 0 {
+		return src, nil, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	var done []FieldDeprecation
+	var walkErr error
+	WalkStructs(file, func(name string, structType *ast.StructType) {
+		for _, d := range deprecations[name] {
+			if walkErr != nil {
+				return
+			}
+			field := findField(structType, d.Name)
+			if field == nil {
+				walkErr = WithCode(CodeMatch, fmt.Errorf("结构体 %s 中没有字段 %s", name, d.Name))
+				return
+			}
+			if len(field.Names) > 1 {
+				walkErr = fmt.Errorf("字段 %s.%s 与其他字段声明在同一行，无法单独废弃", name, d.Name)
+				return
+			}
+			changed := false
+
+			// 文档注释中加上 Deprecated 段落
+			if !hasDeprecated(field.Doc) {
+				start := fset.Position(field.Pos()).Offset
+				lineStart := strings.LastIndexByte(string(src[:start]), '\n') + 1
+				indent := string(src[lineStart:start])
+				line := deprecatedPrefix + " " + d.Message
+				if field.Doc == nil {
+					edits = append(edits, edit{start, start, line + "\n" + indent})
+				} else {
+					end := fset.Position(field.Doc.End()).Offset
+					edits = append(edits, edit{end, end, "\n" + indent + "//\n" + indent + line})
+				}
+				changed = true
+			}
+
+			// 标签改为 "-"
+			var pairs []TagPair
+			if field.Tag != nil {
+				tag, err := strconv.Unquote(field.Tag.Value)
+				if err != nil {
+					walkErr = fmt.Errorf("字段 %s.%s 的标签无效: %v", name, d.Name, err)
+					return
+				}
+				if pairs, err = ParseTag(tag); err != nil {
+					walkErr = fmt.Errorf("字段 %s.%s: %v", name, d.Name, err)
+					return
+				}
+			}
+			tagChanged := false
+			for _, key := range d.tagKeys() {
+				found := false
+				for i := range pairs {
+					if pairs[i].Key == key {
+						found = true
+						if pairs[i].Value != "-" {
+							pairs[i].Value = "-"
+							tagChanged = true
+						}
+					}
+				}
+				if !found {
+					pairs = append(pairs, TagPair{Key: key, Value: "-"})
+					tagChanged = true
+				}
+			}
+			if tagChanged {
+				text := "`" + FormatTag(pairs) + "`"
+				if field.Tag != nil {
+					edits = append(edits, edit{fset.Position(field.Tag.Pos()).Offset, fset.Position(field.Tag.End()).Offset, text})
+				} else {
+					end := fset.Position(field.Type.End()).Offset
+					edits = append(edits, edit{end, end, " " + text})
+				}
+				changed = true
+			}
+			if changed {
+				done = append(done, FieldDeprecation{Struct: name, Field: d.Name})
+			}
+		}
+	})
+	if walkErr != nil {
+		return nil, nil, walkErr
+	}
+	if len(edits) == 0 {
+		return src, nil, nil
+	}
+
+	// 从后往前修改，避免偏移量失效
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := string(src)
+	for _, e := range edits {
+		out = out[:e.start] + e.text + out[e.end:]
+	}
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		return nil, nil, err
+	}
+	return formatted, done, nil
+}
+
+// hasDeprecated 返回文档注释中是否已有 Deprecated 段落
+func hasDeprecated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, deprecatedPrefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dictionaryHeader Markdown 数据字典的文件头
+const dictionaryHeader = "# 数据字典\n\n本文件由 astauto 根据配置中的结构体生成，每次执行后重写，请勿手动修改。\n"
+
+// DictStruct 数据字典中的一个结构体
+type DictStruct struct {
+	File   string      `json:"file"`
+	Name   string      `json:"name"`
+	Doc    string      `json:"doc,omitempty"`
+	Fields []DictField `json:"fields"`
+}
+
+// DictField 数据字典中的一个字段
+type DictField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// JSON 字段的 json 名称，不参与编码时为空
+	JSON string `json:"json,omitempty"`
+	// Column db 标签或 gorm 标签中 column 指定的列名
+	Column      string `json:"column,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// BuildDictionary 由导出的结构体生成数据字典，字段说明优先取配置中的 description，
+// 其次取源码中的文档注释和行尾注释
+func BuildDictionary(structs []*StructInfo, config *Config) []DictStruct {
+	descriptions := make(map[string]string)
+	for _, rule := range config.Rules {
+		for _, st := range rule.Structs {
+			for _, field := range st.Fields {
+				if field.Description != "" {
+					descriptions[rule.File+"\x00"+st.Name+"."+field.Name] = field.Description
+				}
+			}
+		}
+	}
+
+	dict := []DictStruct{}
+	for _, info := range structs {
+		ds := DictStruct{File: info.File, Name: info.Name, Doc: info.Doc, Fields: []DictField{}}
+		for _, fi := range info.Fields {
+			df := DictField{Name: fi.Name, Type: fi.Type, Description: descriptions[info.File+"\x00"+info.Name+"."+fi.Name]}
+			if df.Description == "" {
+				df.Description = fi.Doc
+			}
+			if df.Description == "" {
+				df.Description = fi.Comment
+			}
+			name, _, _ := strings.Cut(tagLookup(fi.Tags, "json"), ",")
+			switch {
+			case name == "-":
+			case name != "":
+				df.JSON = name
+			case !fi.Embedded && token.IsExported(fi.Name):
+				df.JSON = fi.Name
+			}
+			df.Column, _, _ = strings.Cut(tagLookup(fi.Tags, "db"), ",")
+			if df.Column == "" || df.Column == "-" {
+				df.Column = gormSetting(tagLookup(fi.Tags, "gorm"), "column")
+			}
+			ds.Fields = append(ds.Fields, df)
+		}
+		dict = append(dict, ds)
+	}
+	return dict
+}
+
+// tagLookup 返回键值对中指定键的值
+func tagLookup(pairs []TagPair, key string) string {
+	for _, p := range pairs {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// WriteDictionary 重写数据字典文件，扩展名为 .json 时写入 JSON，否则写入 Markdown
+func WriteDictionary(filename string, dict []DictStruct) error {
+	var out []byte
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		data, err := json.MarshalIndent(dict, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化数据字典失败: %v", err)
+		}
+		out = append(data, '\n')
+	} else {
+		out = []byte(markdownDictionary(dict))
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("创建数据字典目录失败: %v", err)
+	}
+	if err := os.WriteFile(filename, out, 0644); err != nil {
+		return fmt.Errorf("写入数据字典失败: %v", err)
+	}
+	return nil
+}
+
+// markdownDictionary 以 Markdown 表格输出数据字典，每个结构体一节
+func markdownDictionary(dict []DictStruct) string {
+	var sb strings.Builder
+	sb.WriteString(dictionaryHeader)
+	for _, ds := range dict {
+		sb.WriteString(fmt.Sprintf("\n## %s\n\n", ds.Name))
+		sb.WriteString(fmt.Sprintf("文件 `%s`", ds.File))
+		if ds.Doc != "" {
+			sb.WriteString("：" + markdownCell(ds.Doc))
+		}
+		sb.WriteString("\n\n| 字段 | 类型 | JSON | 列名 | 说明 |\n|---|---|---|---|---|\n")
+		for _, df := range ds.Fields {
+			sb.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s | %s |\n",
+				df.Name, markdownCell(df.Type), markdownCell(df.JSON), markdownCell(df.Column), markdownCell(df.Description)))
+		}
+	}
+	return sb.String()
+}
+
+// markdownCell 转义表格单元格中的竖线，并把换行合并为空格
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,128 @@
+package logic
+
+import (
+	"strings"
+)
+
+// lineOp 是行级编辑脚本中的一步：'=' 两侧相同，'-' 删除 a 中的行，'+' 插入 b 中的行
+type lineOp struct {
+	kind byte
+	a, b int
+}
+
+// ChangedLines 返回把 before 改为 after 需要增删的行数（最短编辑脚本的长度）
+func ChangedLines(before, after []byte) int {
+	n := 0
+	for _, op := range diffLines(splitLines(before), splitLines(after)) {
+		if op.kind != '=' {
+			n++
+		}
+	}
+	return n
+}
+
+// diffLines 使用 Myers 差分算法计算把 a 改为 b 的最短行级编辑脚本
+func diffLines(a, b []string) []lineOp {
+	// 公共前缀和后缀直接视为相同，缩小比较范围
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []lineOp
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, lineOp{'=', i, i})
+	}
+	for _, op := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		op.a += prefix
+		op.b += prefix
+		ops = append(ops, op)
+	}
+	for i := suffix; i > 0; i-- {
+		ops = append(ops, lineOp{'=', len(a) - i, len(b) - i})
+	}
+	return ops
+}
+
+// myers 计算编辑脚本，trace[d] 保存第 d 步之前对角线 -d..d 上到达的最远位置，用于回溯
+func myers(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+	done := false
+	for d := 0; d <= max && !done; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+	}
+
+	// 从终点沿 trace 回溯
+	var ops []lineOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		prev := func(k int) int { return trace[d][k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev(k-1) < prev(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = prev(prevK)
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, lineOp{'=', x, y})
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, lineOp{'+', x, y - 1})
+			} else {
+				ops = append(ops, lineOp{'-', x - 1, y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// splitLines 将源码按行拆分，每行保留换行符，空内容没有行
+func splitLines(src []byte) []string {
+	if len(src) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(src), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,119 @@
+package logic
+
+import (
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// TextEdit 表示对原始源码的一处文本替换（与 gopls 的建议修复相同的形式），
+// Start、End 为字节偏移，Line、EndLine 为对应的行号，便于阅读
+type TextEdit struct {
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Line    int    `json:"line"`
+	EndLine int    `json:"end_line"`
+	NewText string `json:"new_text"`
+}
+
+// ComputeEdits 计算把 original 改为 modified 的文本编辑。按行比较，比较时只看行内的记号，
+// 只有空白或对齐不同的行视为未修改，从而保留原始源码中未被触及代码的空白和对齐
+func ComputeEdits(original, modified []byte) []TextEdit {
+	o, m := splitLines(original), splitLines(modified)
+	ok, mk := make([]string, len(o)), make([]string, len(m))
+	for i, line := range o {
+		ok[i] = lineKey(line)
+	}
+	for i, line := range m {
+		mk[i] = lineKey(line)
+	}
+
+	// 原始源码每行的起始偏移
+	offsets := make([]int, len(o)+1)
+	for i, line := range o {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+
+	var edits []TextEdit
+	var cur *TextEdit
+	flush := func() {
+		if cur != nil {
+			edits = append(edits, *cur)
+			cur = nil
+		}
+	}
+	for _, op := range diffLines(ok, mk) {
+		switch op.kind {
+		case '=':
+			flush()
+		case '-':
+			if cur == nil {
+				cur = &TextEdit{Start: offsets[op.a], Line: op.a + 1}
+			}
+			cur.End = offsets[op.a+1]
+			cur.EndLine = op.a + 1
+		case '+':
+			if cur == nil {
+				cur = &TextEdit{Start: offsets[op.a], End: offsets[op.a], Line: op.a + 1, EndLine: op.a}
+			}
+			cur.NewText += m[op.b]
+		}
+	}
+	flush()
+	return edits
+}
+
+// ApplyEdits 将文本编辑应用到源码上，编辑之间不能重叠
+func ApplyEdits(src []byte, edits []TextEdit) []byte {
+	sorted := append([]TextEdit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	var sb strings.Builder
+	last := 0
+	for _, e := range sorted {
+		sb.Write(src[last:e.Start])
+		sb.WriteString(e.NewText)
+		last = e.End
+	}
+	sb.Write(src[last:])
+	return []byte(sb.String())
+}
+
+// lineKey 返回比较用的行内容：行内记号以单个空格连接，忽略空白和对齐；
+// 无法单独扫描的行（如跨行的原始字符串或块注释）按原文比较
+func lineKey(line string) string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(line))
+	var s scanner.Scanner
+	failed := false
+	s.Init(file, []byte(line), func(token.Position, string) { failed = true }, scanner.ScanComments)
+	var parts []string
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		parts = append(parts, lit)
+	}
+	if failed {
+		return line
+	}
+	return strings.Join(parts, " ")
+}
+
+// PreserveFormat 把修改以最小文本编辑的形式应用到原始源码上，保留未被修改触及的代码的
+// 原有空白和对齐，original 可以没有经过 gofmt；结果无法解析时返回 modified
+func PreserveFormat(original, modified []byte) []byte {
+	out := ApplyEdits(original, ComputeEdits(original, modified))
+	if _, err := parser.ParseFile(token.NewFileSet(), "", out, parser.ParseComments); err != nil {
+		return modified
+	}
+	return out
+}
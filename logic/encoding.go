@@ -0,0 +1,60 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// utf8BOM UTF-8 字节顺序标记
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// unsupportedBOMs 不支持的编码对应的字节顺序标记，UTF-32 需排在 UTF-16 之前匹配
+var unsupportedBOMs = []struct {
+	name string
+	bom  []byte
+}{
+	{"UTF-32LE", []byte{0xFF, 0xFE, 0x00, 0x00}},
+	{"UTF-32BE", []byte{0x00, 0x00, 0xFE, 0xFF}},
+	{"UTF-16LE", []byte{0xFF, 0xFE}},
+	{"UTF-16BE", []byte{0xFE, 0xFF}},
+}
+
+// SplitBOM 检查源码编码，返回 UTF-8 BOM（如有）和去掉 BOM 后的源码。
+// go/printer 不会输出 BOM，调用方写回时需要重新加上；
+// 非 UTF-8 编码的文件无法无损改写，直接返回错误
+func SplitBOM(src []byte) ([]byte, []byte, error) {
+	for _, enc := range unsupportedBOMs {
+		if bytes.HasPrefix(src, enc.bom) {
+			return nil, nil, fmt.Errorf("文件使用 %s 编码，astauto 只支持 UTF-8 源文件", enc.name)
+		}
+	}
+
+	var bom []byte
+	if bytes.HasPrefix(src, utf8BOM) {
+		bom, src = utf8BOM, src[len(utf8BOM):]
+	}
+	if !utf8.Valid(src) {
+		line, col := invalidUTF8Position(src)
+		return nil, nil, fmt.Errorf("文件第 %d 行第 %d 列不是有效的 UTF-8 编码（可能是 GBK 等编码），astauto 只支持 UTF-8 源文件", line, col)
+	}
+	return bom, src, nil
+}
+
+// invalidUTF8Position 返回第一个无效 UTF-8 字节所在的行号和列号（按字节计，从 1 开始）
+func invalidUTF8Position(src []byte) (int, int) {
+	line, col := 1, 1
+	for len(src) > 0 {
+		r, size := utf8.DecodeRune(src)
+		if r == utf8.RuneError && size <= 1 {
+			return line, col
+		}
+		if r == '\n' {
+			line, col = line+1, 1
+		} else {
+			col += size
+		}
+		src = src[size:]
+	}
+	return line, col
+}
@@ -0,0 +1,114 @@
+package logic
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ExpandEnv 展开字符串中的 ${NAME} 为环境变量的值，$${NAME} 转义为字面的 ${NAME}。
+// 引用的环境变量未设置（设置为空字符串不算）或 ${ 没有闭合时返回错误
+func ExpandEnv(s string) (string, error) {
+	return expandVars(s, nil)
+}
+
+// expandVars 与 ExpandEnv 相同，但优先使用配置 [vars] 中的同名变量
+func expandVars(s string, vars map[string]string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+		// $${ 转义
+		if i > 0 && s[i-1] == '$' {
+			sb.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%q 中的 ${ 没有闭合", s)
+		}
+		name := s[i+2 : i+end]
+		if !isEnvName(name) {
+			return "", fmt.Errorf("环境变量名 %q 无效", name)
+		}
+		value, ok := vars[name]
+		if !ok {
+			if value, ok = os.LookupEnv(name); !ok && vars == nil {
+				return "", fmt.Errorf("环境变量 %s 未设置", name)
+			} else if !ok {
+				return "", fmt.Errorf("变量 %s 没有在 vars 中定义，环境变量也未设置", name)
+			}
+		}
+		sb.WriteString(s[:i] + value)
+		s = s[i+end+1:]
+	}
+}
+
+// isEnvName 判断是否为合法的环境变量名：字母或下划线开头，由字母、数字和下划线组成
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// expandImportsEnv 展开导入路径中的变量
+func expandImportsEnv(imports []Import, vars map[string]string) error {
+	for i := range imports {
+		path, err := expandVars(imports[i].Path, vars)
+		if err != nil {
+			return fmt.Errorf("导入 %s: %v", imports[i].Path, err)
+		}
+		imports[i].Path = path
+	}
+	return nil
+}
+
+// expandRuleEnv 展开规则中 file、导入路径、字段类型和标签里的变量
+func expandRuleEnv(rule *Rule, vars map[string]string) error {
+	var err error
+	if rule.File, err = expandVars(rule.File, vars); err != nil {
+		return fmt.Errorf("file: %v", err)
+	}
+	if rule.Entity != nil {
+		if rule.Entity.File, err = expandVars(rule.Entity.File, vars); err != nil {
+			return fmt.Errorf("file: %v", err)
+		}
+	}
+	if err := expandImportsEnv(rule.Imports, vars); err != nil {
+		return err
+	}
+	for i := range rule.Structs {
+		st := &rule.Structs[i]
+		for j := range st.Fields {
+			field := &st.Fields[j]
+			if field.Type, err = expandVars(field.Type, vars); err != nil {
+				return fmt.Errorf("字段 %s.%s 的类型: %v", st.Name, field.Name, err)
+			}
+			tags, err := expandVars(string(field.Tags), vars)
+			if err != nil {
+				return fmt.Errorf("字段 %s.%s 的标签: %v", st.Name, field.Name, err)
+			}
+			field.Tags = FieldTags(tags)
+			for tag, typ := range field.Types {
+				if field.Types[tag], err = expandVars(typ, vars); err != nil {
+					return fmt.Errorf("字段 %s.%s 在构建标签 %s 下的类型: %v", st.Name, field.Name, tag, err)
+				}
+			}
+		}
+	}
+	return nil
+}
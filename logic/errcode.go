@@ -0,0 +1,120 @@
+package logic
+
+import (
+	"errors"
+	"go/scanner"
+	"os"
+	"strings"
+)
+
+// 错误码，按失败类别划分。serve 模式在响应中返回错误码和对应语言的说明，
+// 客户端据此给出可操作的提示，而不必解析日志文本；错误码同时作为失败指标的类型标签
+const (
+	// CodeConfig 配置无法解析或校验失败
+	CodeConfig = "config"
+	// CodeParse 目标文件有语法错误
+	CodeParse = "parse"
+	// CodeNotFound 目标文件不存在
+	CodeNotFound = "not_found"
+	// CodeMatch 规则中的结构体或锚点无法在目标文件中定位
+	CodeMatch = "match"
+	// CodeConflict 配置与文件中已有的内容冲突
+	CodeConflict = "conflict"
+	// CodeFormat 修改后的源码无法格式化或没有通过校验
+	CodeFormat = "format"
+	// CodeLimit 修改超过了安全阈值
+	CodeLimit = "limit"
+	// CodeIO 读写文件失败
+	CodeIO = "io"
+	// CodeRule 其他规则执行错误
+	CodeRule = "rule"
+)
+
+// errorMessages 各语言下错误码的说明
+var errorMessages = map[string]map[string]string{
+	"zh": {
+		CodeConfig:   "配置无效，请检查配置文件",
+		CodeParse:    "目标文件有语法错误，请先修复",
+		CodeNotFound: "目标文件不存在，请检查规则的 file 和 -path",
+		CodeMatch:    "规则中的结构体或锚点在目标文件中找不到",
+		CodeConflict: "配置与文件中已有的内容冲突",
+		CodeFormat:   "修改后的源码无法格式化或没有通过校验",
+		CodeLimit:    "修改超过了安全阈值",
+		CodeIO:       "读写文件失败",
+		CodeRule:     "规则执行失败",
+	},
+	"en": {
+		CodeConfig:   "The config is invalid; check the config file",
+		CodeParse:    "The target file has syntax errors; fix them first",
+		CodeNotFound: "The target file does not exist; check the rule's file and -path",
+		CodeMatch:    "A struct or anchor of the rule cannot be found in the target file",
+		CodeConflict: "The config conflicts with existing code in the file",
+		CodeFormat:   "The modified source cannot be formatted or failed verification",
+		CodeLimit:    "The changes exceed the safety limits",
+		CodeIO:       "Reading or writing a file failed",
+		CodeRule:     "The rule failed",
+	},
+}
+
+// CodedError 带错误码的错误
+type CodedError struct {
+	Code string
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// WithCode 给错误标上错误码，err 为 nil 时返回 nil
+func WithCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// ErrorCode 返回错误的错误码：优先使用 WithCode 标注的错误码，否则按错误类型判断
+func ErrorCode(err error) string {
+	var coded *CodedError
+	var syntaxErr *SyntaxError
+	var parseErr scanner.ErrorList
+	var pathErr *os.PathError
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, os.ErrNotExist):
+		return CodeNotFound
+	case errors.As(err, &syntaxErr), errors.As(err, &parseErr):
+		return CodeParse
+	case errors.As(err, &pathErr):
+		return CodeIO
+	default:
+		return CodeRule
+	}
+}
+
+// MatchLanguage 按 Accept-Language 选择说明的语言，没有匹配时使用中文
+func MatchLanguage(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := errorMessages[lang]; ok {
+			return lang
+		}
+	}
+	return "zh"
+}
+
+// ErrorMessage 返回错误码在给定语言下的说明
+func ErrorMessage(code, lang string) string {
+	messages, ok := errorMessages[lang]
+	if !ok {
+		messages = errorMessages["zh"]
+	}
+	return messages[code]
+}
@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// StructInfo 导出的结构体当前状态
+type StructInfo struct {
+	File   string      `json:"file"`
+	Name   string      `json:"name"`
+	Line   int         `json:"line"`
+	Doc    string      `json:"doc,omitempty"`
+	Rules  []string    `json:"rules"`
+	Fields []FieldInfo `json:"fields"`
+}
+
+// FieldInfo 导出的字段当前状态，一个声明多个名称的字段按名称拆开
+type FieldInfo struct {
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	Tag      string    `json:"tag,omitempty"`
+	Tags     []TagPair `json:"tags,omitempty"`
+	Embedded bool      `json:"embedded,omitempty"`
+	Doc      string    `json:"doc,omitempty"`
+	Comment  string    `json:"comment,omitempty"`
+	Line     int       `json:"line"`
+	Column   int       `json:"column"`
+}
+
+// ExportStruct 从源码中读取结构体的字段、类型、标签和位置，file 为输出中显示的文件名，
+// 文件中没有该结构体时返回 nil
+func ExportStruct(file string, src []byte, name string) (*StructInfo, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析文件失败: %v", err)
+	}
+	var structType *ast.StructType
+	var doc *ast.CommentGroup
+	if spec := findTypeSpec(f, name); spec != nil {
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return nil, fmt.Errorf("类型 %s 不是结构体", name)
+		}
+		structType, doc = st, typeDoc(f, spec)
+	} else if IsStructPath(name) {
+		// 内联结构体没有自己的文档注释
+		WalkStructs(f, func(path string, st *ast.StructType) {
+			if path == name {
+				structType = st
+			}
+		})
+	}
+	if structType == nil {
+		return nil, nil
+	}
+
+	info := &StructInfo{File: file, Name: name, Line: fset.Position(structType.Pos()).Line, Fields: []FieldInfo{}}
+	if doc != nil {
+		info.Doc = strings.TrimSpace(doc.Text())
+	}
+	for _, field := range structType.Fields.List {
+		fi := FieldInfo{Type: types.ExprString(field.Type)}
+		if field.Tag != nil {
+			if tag, err := strconv.Unquote(field.Tag.Value); err == nil {
+				fi.Tag = tag
+				fi.Tags, _ = ParseTag(tag)
+			}
+		}
+		if field.Doc != nil {
+			fi.Doc = strings.TrimSpace(field.Doc.Text())
+		}
+		if field.Comment != nil {
+			fi.Comment = strings.TrimSpace(field.Comment.Text())
+		}
+		if len(field.Names) == 0 {
+			// 嵌入字段的名称为类型名，去掉指针和包名
+			pos := fset.Position(field.Type.Pos())
+			fi.Name, fi.Embedded = embeddedName(field.Type), true
+			fi.Line, fi.Column = pos.Line, pos.Column
+			info.Fields = append(info.Fields, fi)
+			continue
+		}
+		for _, ident := range field.Names {
+			pos := fset.Position(ident.Pos())
+			fi.Name = ident.Name
+			fi.Line, fi.Column = pos.Line, pos.Column
+			info.Fields = append(info.Fields, fi)
+		}
+	}
+	return info, nil
+}
+
+// typeDoc 返回类型声明的文档注释，单个类型的声明注释写在 type 关键字之前
+func typeDoc(file *ast.File, spec *ast.TypeSpec) *ast.CommentGroup {
+	if spec.Doc != nil {
+		return spec.Doc
+	}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && len(gen.Specs) == 1 && gen.Specs[0] == spec {
+			return gen.Doc
+		}
+	}
+	return nil
+}
+
+// embeddedName 返回嵌入字段的字段名
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	}
+	return types.ExprString(expr)
+}
@@ -0,0 +1,410 @@
+package logic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr 是 when 条件表达式解析后的语法树。
+//
+// 支持的语法：
+//   - 字面量：字符串 "abc"、整数 42、true / false
+//   - 变量：package、file、build_tags 等，由 Env 提供
+//   - 函数调用：has_field("ID")、has_tag("json") 等，由 Env 提供
+//   - 运算符：|| && ! == != < <= > >= in，以及括号分组
+type Expr interface {
+	Eval(env *Env) (any, error)
+}
+
+// Env 表达式求值时可用的变量和函数
+type Env struct {
+	Vars  map[string]any
+	Funcs map[string]func(args []any) (any, error)
+}
+
+// ParseExpr 解析条件表达式
+func ParseExpr(src string) (Expr, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("表达式 %q 在 %q 处有多余内容", src, p.peek().text)
+	}
+	return expr, nil
+}
+
+// EvalBool 解析并求值条件表达式，结果必须是布尔值
+func EvalBool(src string, env *Env) (bool, error) {
+	expr, err := ParseExpr(src)
+	if err != nil {
+		return false, err
+	}
+	v, err := expr.Eval(env)
+	if err != nil {
+		return false, fmt.Errorf("表达式 %q 求值失败: %v", src, err)
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("表达式 %q 的结果不是布尔值: %v", src, v)
+	}
+	return b, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokInt
+	tokOp
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string
+}
+
+// lexExpr 将表达式切分为词法单元
+func lexExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("表达式 %q 中的字符串没有结束", src)
+			}
+			s, err := strconv.Unquote(string(runes[i : j+1]))
+			if err != nil {
+				return nil, fmt.Errorf("表达式 %q 中的字符串无效: %v", src, err)
+			}
+			tokens = append(tokens, exprToken{kind: tokString, text: s})
+			i = j + 1
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: tokInt, text: string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: tokIdent, text: string(runes[i:j])})
+			i = j
+		default:
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					tokens = append(tokens, exprToken{kind: tokOp, text: two})
+					i += 2
+					continue
+				}
+			}
+			if strings.ContainsRune("!<>(),", r) {
+				tokens = append(tokens, exprToken{kind: tokOp, text: string(r)})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("表达式 %q 中有无法识别的字符 %q", src, r)
+		}
+	}
+	return append(tokens, exprToken{kind: tokEOF}), nil
+}
+
+// exprParser 递归下降解析器
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) isOp(text string) bool {
+	t := p.peek()
+	return t.kind == tokOp && t.text == text
+}
+
+func (p *exprParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (Expr, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+// compareOps 比较运算符，不可连续使用
+var compareOps = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+func (p *exprParser) parseCompare() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if (t.kind == tokOp && compareOps[t.text]) || (t.kind == tokIdent && t.text == "in") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpr{op: t.text, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (Expr, error) {
+	if p.isOp("!") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return &literalExpr{value: t.text}, nil
+	case tokInt:
+		n, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("无效的整数 %s", t.text)
+		}
+		return &literalExpr{value: n}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literalExpr{value: true}, nil
+		case "false":
+			return &literalExpr{value: false}, nil
+		}
+		if !p.isOp("(") {
+			return &identExpr{name: t.text}, nil
+		}
+		p.next()
+		call := &callExpr{name: t.text}
+		for !p.isOp(")") {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.isOp(",") {
+				p.next()
+			} else if !p.isOp(")") {
+				return nil, fmt.Errorf("函数 %s 的参数列表缺少 )", t.text)
+			}
+		}
+		p.next()
+		return call, nil
+	case tokOp:
+		if t.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOp(")") {
+				return nil, fmt.Errorf("缺少 )")
+			}
+			p.next()
+			return x, nil
+		}
+	}
+	if t.kind == tokEOF {
+		return nil, fmt.Errorf("表达式意外结束")
+	}
+	return nil, fmt.Errorf("意外的 %q", t.text)
+}
+
+type literalExpr struct {
+	value any
+}
+
+func (e *literalExpr) Eval(*Env) (any, error) {
+	return e.value, nil
+}
+
+type identExpr struct {
+	name string
+}
+
+func (e *identExpr) Eval(env *Env) (any, error) {
+	v, ok := env.Vars[e.name]
+	if !ok {
+		return nil, fmt.Errorf("未知变量 %s", e.name)
+	}
+	return v, nil
+}
+
+type callExpr struct {
+	name string
+	args []Expr
+}
+
+func (e *callExpr) Eval(env *Env) (any, error) {
+	fn, ok := env.Funcs[e.name]
+	if !ok {
+		return nil, fmt.Errorf("未知函数 %s", e.name)
+	}
+	args := make([]any, 0, len(e.args))
+	for _, arg := range e.args {
+		v, err := arg.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	return fn(args)
+}
+
+type notExpr struct {
+	x Expr
+}
+
+func (e *notExpr) Eval(env *Env) (any, error) {
+	v, err := e.x.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return nil, fmt.Errorf("! 的操作数不是布尔值: %v", v)
+	}
+	return !b, nil
+}
+
+type binaryExpr struct {
+	op          string
+	left, right Expr
+}
+
+func (e *binaryExpr) Eval(env *Env) (any, error) {
+	left, err := e.left.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// 逻辑运算短路求值
+	if e.op == "&&" || e.op == "||" {
+		lb, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s 的操作数不是布尔值: %v", e.op, left)
+		}
+		if (e.op == "&&" && !lb) || (e.op == "||" && lb) {
+			return lb, nil
+		}
+		right, err := e.right.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s 的操作数不是布尔值: %v", e.op, right)
+		}
+		return rb, nil
+	}
+
+	right, err := e.right.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "in":
+		list, ok := right.([]string)
+		if !ok {
+			return nil, fmt.Errorf("in 的右侧不是列表: %v", right)
+		}
+		s, ok := left.(string)
+		if !ok {
+			return nil, fmt.Errorf("in 的左侧不是字符串: %v", left)
+		}
+		for _, item := range list {
+			if item == s {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "==":
+		return fmt.Sprint(left) == fmt.Sprint(right), nil
+	case "!=":
+		return fmt.Sprint(left) != fmt.Sprint(right), nil
+	}
+
+	// 其余为大小比较，只支持整数
+	ln, lok := left.(int)
+	rn, rok := right.(int)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s 只能比较整数: %v %s %v", e.op, left, e.op, right)
+	}
+	switch e.op {
+	case "<":
+		return ln < rn, nil
+	case "<=":
+		return ln <= rn, nil
+	case ">":
+		return ln > rn, nil
+	default:
+		return ln >= rn, nil
+	}
+}
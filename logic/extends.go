@@ -0,0 +1,68 @@
+package logic
+
+import "reflect"
+
+// ruleTarget 标识规则作用的 (文件, 结构体)
+type ruleTarget struct {
+	file, structName string
+}
+
+// extendConfig 把子配置叠加到父配置上并返回结果：
+//   - 子配置中与父配置 ID 相同的规则整体替换父规则；
+//   - 子配置的规则处理的 (文件, 结构体) 从父规则中去掉，父规则因此不再有结构体时整条去掉；
+//   - 父规则排在子规则之前；其他列表追加在父配置之后，表中的同名项和非零的设置以子配置为准
+func extendConfig(parent, child *Config) *Config {
+	ids := make(map[string]bool)
+	targets := make(map[ruleTarget]bool)
+	for _, rule := range child.Rules {
+		if rule.ID != "" {
+			ids[rule.ID] = true
+		}
+		for _, st := range rule.Structs {
+			targets[ruleTarget{rule.File, st.Name}] = true
+		}
+	}
+	var rules []*Rule
+	for _, rule := range parent.Rules {
+		if rule.ID != "" && ids[rule.ID] {
+			continue
+		}
+		var structs []Struct
+		for _, st := range rule.Structs {
+			if !targets[ruleTarget{rule.File, st.Name}] {
+				structs = append(structs, st)
+			}
+		}
+		if len(structs) == 0 && len(rule.Structs) > 0 {
+			continue
+		}
+		rule.Structs = structs
+		rules = append(rules, rule)
+	}
+	parent.Rules = rules
+
+	p, c := reflect.ValueOf(parent).Elem(), reflect.ValueOf(child).Elem()
+	for i := 0; i < p.NumField(); i++ {
+		pf, cf := p.Field(i), c.Field(i)
+		switch pf.Kind() {
+		case reflect.Slice:
+			pf.Set(reflect.AppendSlice(pf, cf))
+		case reflect.Map:
+			if cf.Len() == 0 {
+				continue
+			}
+			if pf.IsNil() {
+				pf.Set(reflect.MakeMap(pf.Type()))
+			}
+			iter := cf.MapRange()
+			for iter.Next() {
+				pf.SetMapIndex(iter.Key(), iter.Value())
+			}
+		default:
+			if !cf.IsZero() {
+				pf.Set(cf)
+			}
+		}
+	}
+	return parent
+}
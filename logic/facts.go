@@ -0,0 +1,158 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"reflect"
+	"strconv"
+)
+
+// FileEnv 根据文件内容构建 when 表达式的求值环境。
+//
+// 变量：package（包名）、file（规则中的文件路径）、build_tags（构建约束中出现的标签）、structs（文件中声明的结构体名）
+// 函数：has_import("path")
+func FileEnv(filename string, file *ast.File) *Env {
+	var structs []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+				if _, ok := typeSpec.Type.(*ast.StructType); ok {
+					structs = append(structs, typeSpec.Name.Name)
+				}
+			}
+		}
+	}
+
+	return &Env{
+		Vars: map[string]any{
+			"package":    file.Name.Name,
+			"file":       filename,
+			"build_tags": BuildTags(file),
+			"structs":    structs,
+		},
+		Funcs: map[string]func(args []any) (any, error){
+			"has_import": func(args []any) (any, error) {
+				path, err := stringArg("has_import", args)
+				if err != nil {
+					return nil, err
+				}
+				for _, imp := range file.Imports {
+					if p, _ := strconv.Unquote(imp.Path.Value); p == path {
+						return true, nil
+					}
+				}
+				return false, nil
+			},
+		},
+	}
+}
+
+// StructEnv 在文件环境的基础上加入结构体相关的事实。
+//
+// 变量：struct（结构体名）、field_count（字段数量）
+// 函数：has_field("Name")、has_tag("json")（任一字段带有该标签键）
+func StructEnv(fileEnv *Env, name string, structType *ast.StructType) *Env {
+	env := &Env{Vars: make(map[string]any), Funcs: make(map[string]func(args []any) (any, error))}
+	for k, v := range fileEnv.Vars {
+		env.Vars[k] = v
+	}
+	for k, v := range fileEnv.Funcs {
+		env.Funcs[k] = v
+	}
+
+	count := 0
+	for _, field := range structType.Fields.List {
+		if len(field.Names) == 0 {
+			count++
+		}
+		count += len(field.Names)
+	}
+	env.Vars["struct"] = name
+	env.Vars["field_count"] = count
+
+	env.Funcs["has_field"] = func(args []any) (any, error) {
+		fieldName, err := stringArg("has_field", args)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range structType.Fields.List {
+			for _, ident := range field.Names {
+				if ident.Name == fieldName {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+	env.Funcs["has_tag"] = func(args []any) (any, error) {
+		key, err := stringArg("has_tag", args)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range structType.Fields.List {
+			if field.Tag == nil {
+				continue
+			}
+			tag, _ := strconv.Unquote(field.Tag.Value)
+			if _, ok := reflect.StructTag(tag).Lookup(key); ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return env
+}
+
+// BuildTags 返回文件 //go:build 约束中出现的所有标签
+func BuildTags(file *ast.File) []string {
+	var tags []string
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if !constraint.IsGoBuild(c.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				continue
+			}
+			collectTags(expr, &tags)
+		}
+	}
+	return tags
+}
+
+// collectTags 递归收集构建约束表达式中的标签
+func collectTags(expr constraint.Expr, tags *[]string) {
+	switch e := expr.(type) {
+	case *constraint.TagExpr:
+		*tags = append(*tags, e.Tag)
+	case *constraint.NotExpr:
+		collectTags(e.X, tags)
+	case *constraint.AndExpr:
+		collectTags(e.X, tags)
+		collectTags(e.Y, tags)
+	case *constraint.OrExpr:
+		collectTags(e.X, tags)
+		collectTags(e.Y, tags)
+	}
+}
+
+// stringArg 检查函数只有一个字符串参数
+func stringArg(name string, args []any) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("%s 需要 1 个参数，实际为 %d 个", name, len(args))
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return "", fmt.Errorf("%s 的参数必须是字符串", name)
+	}
+	return s, nil
+}
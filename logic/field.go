@@ -0,0 +1,44 @@
+package logic
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TagValue 返回字段最终的标签内容（不含反引号），
+// 配置了 Example 且 Tags 中没有 example 键时追加 example 标签
+func (f Field) TagValue() string {
+	tags := string(f.Tags)
+	if f.Example != "" {
+		if _, ok := reflect.StructTag(tags).Lookup("example"); !ok {
+			if tags != "" {
+				tags += " "
+			}
+			tags += "example:" + strconv.Quote(f.Example)
+		}
+	}
+	return tags
+}
+
+// DocLines 将字段说明拆分为注释行，每行带 "// " 前缀
+func (f Field) DocLines() []string {
+	return docLines(f.Description)
+}
+
+// DocLines 将结构体说明拆分为注释行，每行带 "// " 前缀
+func (s Struct) DocLines() []string {
+	return docLines(s.Description)
+}
+
+// docLines 将说明文字拆分为注释行
+func docLines(description string) []string {
+	if strings.TrimSpace(description) == "" {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		lines = append(lines, strings.TrimRight("// "+strings.TrimSpace(line), " "))
+	}
+	return lines
+}
@@ -0,0 +1,41 @@
+package logic
+
+import "fmt"
+
+// applyFieldsets 把结构体引用的字段组追加到字段列表中，结构体自己已有的同名字段保持不变
+func applyFieldsets(rule *Rule, fieldsets map[string][]Field) error {
+	for i := range rule.Structs {
+		st := &rule.Structs[i]
+		if len(st.Fieldsets) == 0 {
+			continue
+		}
+		names := make(map[string]bool, len(st.Fields))
+		for _, field := range st.Fields {
+			names[field.Name] = true
+		}
+		// 复制字段，避免多个结构体共享同一组字段后被逐个改写
+		fields := append([]Field(nil), st.Fields...)
+		for _, name := range st.Fieldsets {
+			set, ok := fieldsets[name]
+			if !ok {
+				return fmt.Errorf("结构体 %s 引用的字段组 %s 不存在", st.Name, name)
+			}
+			for _, field := range set {
+				if names[field.Name] {
+					continue
+				}
+				names[field.Name] = true
+				if field.Types != nil {
+					types := make(map[string]string, len(field.Types))
+					for tag, typ := range field.Types {
+						types[tag] = typ
+					}
+					field.Types = types
+				}
+				fields = append(fields, field)
+			}
+		}
+		st.Fields = fields
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package logic
+
+import (
+	"fmt"
+	"io"
+)
+
+// 检查发现的问题类别
+const (
+	// FindingDrift 文件与配置不同步，执行规则会修改文件
+	FindingDrift = "drift"
+	// FindingInvalid 配置或源文件无效，规则无法执行
+	FindingInvalid = "invalid"
+	// FindingSkipped 规则或结构体被跳过
+	FindingSkipped = "skipped"
+	// FindingDuplicateName 结构体中有编码后名称相同的字段，encoding/json 会静默忽略它们
+	FindingDuplicateName = "duplicate-name"
+	// FindingLint astauto lint 的标签检查发现的问题
+	FindingLint = "lint"
+)
+
+// 问题级别，取值与 SARIF 的 level 一致
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Finding 表示检查模式发现的一个问题
+type Finding struct {
+	Kind  string `json:"kind"`
+	Level string `json:"level"`
+	// Code 规则无法执行时的错误码，见 ErrorCode
+	Code    string `json:"code,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// Failed 返回问题列表中是否有导致检查失败的问题
+func Failed(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Level == LevelError {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText 以 文件:行号: [级别] 信息 的文本形式输出问题
+func WriteText(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		location := f.File
+		if location == "" {
+			location = "-"
+		}
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, f.Line)
+		}
+		if _, err := fmt.Fprintf(w, "%s: [%s] %s\n", location, f.Level, f.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
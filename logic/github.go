@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// githubLevels 问题级别对应的 GitHub Actions 工作流命令
+var githubLevels = map[string]string{
+	LevelError:   "error",
+	LevelWarning: "warning",
+	LevelNote:    "notice",
+}
+
+// WriteGitHub 以 GitHub Actions 工作流命令（::error file=...,line=...::message）输出问题，
+// 使检查结果直接标注在 PR 的改动上
+func WriteGitHub(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		command, ok := githubLevels[f.Level]
+		if !ok {
+			command = "notice"
+		}
+		var props []string
+		if f.File != "" {
+			props = append(props, "file="+escapeGitHubProperty(filepath.ToSlash(f.File)))
+		}
+		if f.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", f.Line))
+		}
+		props = append(props, "title="+escapeGitHubProperty("astauto "+f.Kind))
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), escapeGitHubData(f.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeGitHubData 转义工作流命令中的消息内容
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeGitHubProperty 转义工作流命令中的属性值
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,195 @@
+package logic
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// IsFileGlob 判断规则的 file 是否为通配模式：支持 *、?、[...]，以及匹配任意层目录的 **
+func IsFileGlob(file string) bool {
+	return strings.ContainsAny(file, "*?[")
+}
+
+// validateFileGlob 检查通配模式的语法
+func validateFileGlob(pattern string) error {
+	for _, part := range strings.Split(pattern, "/") {
+		if part == "**" {
+			continue
+		}
+		if _, err := path.Match(part, ""); err != nil {
+			return fmt.Errorf("通配模式 %q 无效: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Excluded 判断相对 root、以 / 分隔的路径是否与 exclude 中的某个通配模式匹配。
+// dir 为 true 时路径是目录，以 /** 结尾的模式（如 vendor/**）也匹配目录本身，遍历时整个目录被跳过
+func Excluded(exclude []string, rel string, dir bool) bool {
+	name := strings.Split(rel, "/")
+	for _, pattern := range exclude {
+		parts := strings.Split(path.Clean(pattern), "/")
+		if matchGlob(parts, name) {
+			return true
+		}
+		if dir && len(parts) > 1 && parts[len(parts)-1] == "**" && matchGlob(parts[:len(parts)-1], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// GlobFiles 返回 root 下与通配模式匹配的文件（相对 root、以 / 分隔），按路径排序。
+// 模式中的 ** 匹配零层或多层目录，跳过的目录和文件见 findFiles
+func GlobFiles(root, pattern string, exclude, tags []string) ([]string, error) {
+	parts := strings.Split(path.Clean(pattern), "/")
+	files, err := findFiles(root, func(rel string) bool {
+		return matchGlob(parts, strings.Split(rel, "/"))
+	}, exclude, tags)
+	if err != nil {
+		return nil, fmt.Errorf("查找匹配 %s 的文件失败: %v", pattern, err)
+	}
+	return files, nil
+}
+
+// RegexFiles 返回 root 下相对路径（以 / 分隔）与正则表达式匹配的文件，按路径排序，
+// 跳过的目录和文件见 findFiles
+func RegexFiles(root string, re *regexp.Regexp, exclude, tags []string) ([]string, error) {
+	files, err := findFiles(root, re.MatchString, exclude, tags)
+	if err != nil {
+		return nil, fmt.Errorf("查找匹配 %s 的文件失败: %v", re, err)
+	}
+	return files, nil
+}
+
+// findFiles 遍历 root 返回 match 为 true 的文件（相对 root、以 / 分隔），按路径排序。
+// 以 . 开头的目录和与 exclude 匹配的路径不进入，生成的文件按 IncludeGenerated 跳过，
+// tags 不为空时跳过构建约束不满足的文件，见 matchBuildTags
+func findFiles(root string, match func(rel string) bool, exclude, tags []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(d.Name(), ".") || Excluded(exclude, rel, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !match(rel) || Excluded(exclude, rel, false) || skipGenerated(p) {
+			return nil
+		}
+		if ok, err := matchBuildTags(p, tags); err != nil || !ok {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// matchGlob 按路径段匹配通配模式
+func matchGlob(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchGlob(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], name[1:])
+}
+
+// ExpandTargets 把 file 为通配模式或设置了 file_regex 的规则展开为每个匹配文件一条的规则，把设置了 package 的规则
+// 展开为包中每个声明了其结构体的文件一条规则，没有 file 的规则在整个 root 下查找结构体，文件相对 root 查找，
+// 跳过与规则的 exclude 匹配的路径和不满足 build_tags 的文件。返回新的配置，原配置不变，
+// 以便同一配置在不同的根目录下重复展开；没有匹配文件的规则跳过
+func ExpandTargets(config *Config, root string) (*Config, error) {
+	expanded := *config
+	expanded.Rules = nil
+	for _, rule := range config.Rules {
+		if rule.Registry != nil {
+			Warnf("规则 %s 需要先同步 schema registry，本次跳过", rule.Name())
+			continue
+		}
+		if rule.Entity != nil {
+			r, err := ResolveEntity(rule, root)
+			if err != nil {
+				return nil, err
+			}
+			expanded.Rules = append(expanded.Rules, r)
+			continue
+		}
+		if rule.FileRegex != "" {
+			re, err := regexp.Compile(rule.FileRegex)
+			if err != nil {
+				return nil, fmt.Errorf("规则 %s 的 file_regex 无效: %v", rule.Name(), err)
+			}
+			files, err := RegexFiles(root, re, rule.Exclude, rule.BuildTags)
+			if err != nil {
+				return nil, err
+			}
+			if len(files) == 0 {
+				Warnf("规则 %s 的 file_regex %s 没有匹配的文件，跳过", rule.Name(), rule.FileRegex)
+				continue
+			}
+			for _, file := range files {
+				r := *rule
+				r.File, r.FileRegex = file, ""
+				expanded.Rules = append(expanded.Rules, &r)
+			}
+			continue
+		}
+		if rule.File == "" {
+			rules, err := expandPackage(rule, root)
+			if err != nil {
+				return nil, err
+			}
+			expanded.Rules = append(expanded.Rules, rules...)
+			continue
+		}
+		if !IsFileGlob(rule.File) {
+			expanded.Rules = append(expanded.Rules, rule)
+			continue
+		}
+		files, err := GlobFiles(root, rule.File, rule.Exclude, rule.BuildTags)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			Warnf("规则 %s 的 file 模式 %s 没有匹配的文件，跳过", rule.Name(), rule.File)
+			continue
+		}
+		for _, file := range files {
+			r := *rule
+			r.File = file
+			expanded.Rules = append(expanded.Rules, &r)
+		}
+	}
+	return &expanded, nil
+}
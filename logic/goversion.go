@@ -0,0 +1,155 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/version"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// genericsVersion 引入泛型和 any 的 Go 版本
+const genericsVersion = "go1.18"
+
+// rangeIntVersion 引入 range 整数的 Go 版本
+const rangeIntVersion = "go1.22"
+
+// NormalizeGoVersion 将配置中的 go_version（如 1.17 或 go1.17）规范为 go1.17 的形式，空字符串表示不限制
+func NormalizeGoVersion(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	normalized := v
+	if !strings.HasPrefix(normalized, "go") {
+		normalized = "go" + normalized
+	}
+	if !version.IsValid(normalized) {
+		return "", fmt.Errorf("无效的 Go 版本 %q", v)
+	}
+	return normalized, nil
+}
+
+// beforeGenerics 判断目标版本是否早于泛型，为空表示不限制
+func beforeGenerics(goVersion string) bool {
+	return goVersion != "" && version.Compare(goVersion, genericsVersion) < 0
+}
+
+// GateType 按目标 Go 版本调整类型表达式：早于 go1.18 时把 any 改写为 interface{}，
+// 使用泛型实例化时返回错误。返回调整后的类型表达式
+func GateType(expr ast.Expr, goVersion string) (ast.Expr, error) {
+	if !beforeGenerics(goVersion) {
+		return expr, nil
+	}
+	var gateErr error
+	result := astutil.Apply(expr, func(c *astutil.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.IndexListExpr:
+			gateErr = fmt.Errorf("泛型类型需要 %s，目标版本为 %s", genericsVersion, goVersion)
+			return false
+		case *ast.IndexExpr:
+			gateErr = fmt.Errorf("泛型类型需要 %s，目标版本为 %s", genericsVersion, goVersion)
+			return false
+		case *ast.Ident:
+			if n.Name == "any" {
+				c.Replace(&ast.InterfaceType{Methods: &ast.FieldList{}})
+			}
+		}
+		return true
+	}, nil)
+	if gateErr != nil {
+		return nil, gateErr
+	}
+	return result.(ast.Expr), nil
+}
+
+// GateCode 按目标 Go 版本调整代码片段：早于 go1.22 时把 range 整数改写为 range make([]struct{}, n)，
+// 早于 go1.18 时把 any 改写为 interface{}，声明类型参数时返回错误
+func GateCode(code, goVersion string) (string, error) {
+	if goVersion == "" || version.Compare(goVersion, rangeIntVersion) >= 0 {
+		return code, nil
+	}
+	generics := beforeGenerics(goVersion)
+	const header = "package snippet\n"
+	src := header + code
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("解析代码片段失败: %v", err)
+	}
+
+	// edits 在 offset 处插入 text，替换原文中的 n 个字节
+	type edit struct {
+		offset, n int
+		text      string
+	}
+	var gateErr error
+	var edits []edit
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.RangeStmt:
+			if isIntExpr(n.X) {
+				edits = append(edits,
+					edit{offset: fset.Position(n.X.Pos()).Offset, text: "make([]struct{}, "},
+					edit{offset: fset.Position(n.X.End()).Offset, text: ")"})
+			}
+		}
+		if !generics {
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.FuncType:
+			if n.TypeParams != nil {
+				gateErr = fmt.Errorf("泛型函数需要 %s，目标版本为 %s", genericsVersion, goVersion)
+			}
+		case *ast.TypeSpec:
+			if n.TypeParams != nil {
+				gateErr = fmt.Errorf("泛型类型 %s 需要 %s，目标版本为 %s", n.Name.Name, genericsVersion, goVersion)
+			}
+		case *ast.IndexListExpr:
+			gateErr = fmt.Errorf("泛型实例化需要 %s，目标版本为 %s", genericsVersion, goVersion)
+		case *ast.Ident:
+			// 没有解析到声明的 any 是预声明标识符
+			if n.Name == "any" && n.Obj == nil {
+				edits = append(edits, edit{offset: fset.Position(n.Pos()).Offset, n: len("any"), text: "interface{}"})
+			}
+		}
+		return gateErr == nil
+	})
+	if gateErr != nil {
+		return "", gateErr
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].offset > edits[j].offset })
+	for _, e := range edits {
+		src = src[:e.offset] + e.text + src[e.offset+e.n:]
+	}
+	return src[len(header):], nil
+}
+
+// isIntExpr 判断不做类型检查时能否确定表达式是整数：整数字面量、len、cap 和 int 转换的调用，
+// 以及由它们组成的算术表达式
+func isIntExpr(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.INT
+	case *ast.ParenExpr:
+		return isIntExpr(e.X)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+			return isIntExpr(e.X) && isIntExpr(e.Y)
+		}
+	case *ast.CallExpr:
+		if fn, ok := e.Fun.(*ast.Ident); ok && fn.Obj == nil {
+			switch fn.Name {
+			case "len", "cap", "int":
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// hclBlock 描述 HCL 配置中一种块对应的配置键
+type hclBlock struct {
+	// key 块在配置中对应的键
+	key string
+	// label 块标签对应的键，为空表示块没有标签
+	label string
+	// optional 标签可以省略
+	optional bool
+	// mapped 块按标签组成表（如 snippet "name" {...}），而不是数组
+	mapped bool
+	// single 块只能出现一次，对应一个对象
+	single bool
+}
+
+// hclBlocks HCL 配置中支持的块，其余设置写成属性，键名与 TOML 中一致，例如
+//
+//	rule "users" {
+//	  file = "models/user.go"
+//	  import "time" {}
+//	  struct "User" {
+//	    field "CreatedAt" { type = "time.Time" }
+//	  }
+//	}
+var hclBlocks = map[string]hclBlock{
+	"rule":          {key: "rules", label: "id", optional: true},
+	"import":        {key: "imports", label: "path"},
+	"struct":        {key: "structs", label: "name"},
+	"field":         {key: "fields", label: "name"},
+	"remove":        {key: "remove", label: "name"},
+	"rename_tag":    {key: "rename_tags", label: "field"},
+	"deprecate":     {key: "deprecate", label: "name"},
+	"widen":         {key: "widen", label: "name"},
+	"apply_snippet": {key: "apply_snippet", label: "name"},
+	"model":         {key: "models", label: "file"},
+	"entity":        {key: "entities", label: "name"},
+	"registry":      {key: "registry", label: "subject"},
+	"snippet":       {key: "snippets", mapped: true},
+	"preset":        {key: "presets", mapped: true},
+	"secret":        {key: "secrets", mapped: true},
+	"hook":          {key: "hook", single: true},
+	"lint":          {key: "lint", single: true},
+	"header":        {key: "header", single: true},
+	"provenance":    {key: "provenance", single: true},
+	"deprecation":   {key: "deprecation", single: true},
+	"codegen":       {key: "codegen", single: true},
+}
+
+// ParseHCL 从HCL文件解析配置：块按 hclBlocks 转换为数组或表，属性按值转换，
+// 再解码为 JSON 到 Config。属性中不能引用变量和函数
+func ParseHCL(filename string) (*Config, error) {
+	return parseConfigFile(filename, decodeHCL)
+}
+
+// decodeHCL 解码HCL配置文件，不展开和检查
+func decodeHCL(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开HCL文件: %v", err)
+	}
+	file, diags := hclsyntax.ParseConfig(data, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("解析HCL文件失败: %v", diags)
+	}
+	doc, err := hclBody(file.Body.(*hclsyntax.Body))
+	if err != nil {
+		return nil, fmt.Errorf("解析HCL文件失败: %v", err)
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("解析HCL文件失败: %v", err)
+	}
+	var config Config
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("解析HCL文件失败: %v", err)
+	}
+	return &config, nil
+}
+
+// hclBody 把 HCL 块体转换为通用的表
+func hclBody(body *hclsyntax.Body) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for name, attr := range body.Attributes {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		raw, err := ctyjson.SimpleJSONValue{Value: value}.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("%s: 属性 %s 的值无法转换: %v", attr.SrcRange, name, err)
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		m[name] = v
+	}
+
+	for _, block := range body.Blocks {
+		spec, ok := hclBlocks[block.Type]
+		if !ok {
+			return nil, fmt.Errorf("%s: 未知的块 %s", block.TypeRange, block.Type)
+		}
+		wantLabels := 1
+		if spec.single || (spec.optional && len(block.Labels) == 0) {
+			wantLabels = 0
+		}
+		if len(block.Labels) != wantLabels {
+			return nil, fmt.Errorf("%s: 块 %s 应当有 %d 个标签", block.TypeRange, block.Type, wantLabels)
+		}
+		inner, err := hclBody(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := inner[spec.label]; spec.label != "" && dup && wantLabels > 0 {
+			return nil, fmt.Errorf("%s: 块 %s 的 %s 已由标签给出", block.TypeRange, block.Type, spec.label)
+		}
+
+		switch {
+		case spec.single:
+			if _, dup := m[spec.key]; dup {
+				return nil, fmt.Errorf("%s: 块 %s 只能出现一次", block.TypeRange, block.Type)
+			}
+			m[spec.key] = inner
+		case spec.mapped:
+			table, _ := m[spec.key].(map[string]interface{})
+			if table == nil {
+				table = make(map[string]interface{})
+				m[spec.key] = table
+			}
+			if _, dup := table[block.Labels[0]]; dup {
+				return nil, fmt.Errorf("%s: %s %q 重复定义", block.TypeRange, block.Type, block.Labels[0])
+			}
+			table[block.Labels[0]] = inner
+		default:
+			if wantLabels > 0 {
+				inner[spec.label] = block.Labels[0]
+			}
+			list, _ := m[spec.key].([]interface{})
+			m[spec.key] = append(list, inner)
+		}
+	}
+	return m, nil
+}
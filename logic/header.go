@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// 文件头的添加范围
+const (
+	// HeaderTouched 只给规则修改过的文件添加（默认）
+	HeaderTouched = "touched"
+	// HeaderMatched 给规则处理的所有目标文件添加，即使规则没有其他修改
+	HeaderMatched = "matched"
+)
+
+// FileHeader 结构体表示需要出现在文件顶部的许可证或归属声明
+type FileHeader struct {
+	// Text 文件头内容，每行自动加上 "// "；已经以 // 或 /* 开头时按原样使用
+	Text string `json:"text" toml:"text"`
+	// Scope 添加范围：touched（默认）或 matched
+	Scope string `json:"scope" toml:"scope"`
+}
+
+// Validate 检查文件头配置
+func (h FileHeader) Validate() error {
+	switch h.Scope {
+	case "", HeaderTouched, HeaderMatched:
+	default:
+		return fmt.Errorf("header.scope %q 无效，只支持 touched 和 matched", h.Scope)
+	}
+	if h.Text == "" {
+		return nil
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", h.Comment()+"\n\npackage p\n", parser.ParseComments); err != nil {
+		return fmt.Errorf("header.text 不是有效的注释: %v", err)
+	}
+	return nil
+}
+
+// Comment 返回文件头的注释源码，不含末尾换行
+func (h FileHeader) Comment() string {
+	text := strings.TrimRight(h.Text, "\n")
+	if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "/*") {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = "//"
+		} else {
+			lines[i] = "// " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// EnsureHeader 确保文件头出现在 package 子句之前的注释中，缺少时插入到文件最前面并空一行，
+// 避免成为包文档；返回是否插入了文件头
+func EnsureHeader(src []byte, header FileHeader) ([]byte, bool, error) {
+	if header.Text == "" {
+		return src, false, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil, false, fmt.Errorf("解析文件失败: %v", err)
+	}
+	comment := header.Comment()
+	if strings.Contains(string(src[:fset.Position(file.Package).Offset]), comment) {
+		return src, false, nil
+	}
+	out := make([]byte, 0, len(comment)+2+len(src))
+	out = append(out, comment...)
+	out = append(out, "\n\n"...)
+	out = append(out, src...)
+	return out, true, nil
+}
@@ -0,0 +1,248 @@
+package logic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 导入冲突的处理策略：文件中已有同一路径的导入，但名称与配置的别名不同
+const (
+	// ImportConflictError 报告冲突并中止规则（默认）
+	ImportConflictError = "error"
+	// ImportConflictKeep 沿用已有的名称，配置中类型的包名随之改写
+	ImportConflictKeep = "keep"
+	// ImportConflictRewrite 把已有导入及文件中对它的引用改为配置的别名
+	ImportConflictRewrite = "rewrite"
+)
+
+// AddImports 以文本方式向源码添加导入，返回新源码、实际添加的导入路径，
+// 以及按 keep 策略沿用已有名称时配置别名到已有名称的映射。
+// 不使用 astutil 修改语法树，因为它会丢失或挪动导入块中的分组注释和行尾注释；
+// 新导入插入到路径最接近的导入所在行之后，从而落在同一个分组里
+func AddImports(src []byte, imports []Import, policy string) ([]byte, []string, map[string]string, error) {
+	return addImports(src, imports, policy, false)
+}
+
+// AddImportsPartial 与 AddImports 相同，但允许源码有语法错误：只要导入声明都位于第一处错误之前，
+// 就在不完整的语法树上添加导入。结果不经过格式化，也不支持 rewrite 策略
+func AddImportsPartial(src []byte, imports []Import, policy string) ([]byte, []string, map[string]string, error) {
+	return addImports(src, imports, policy, true)
+}
+
+// addImports 逐个添加导入，partial 为 true 时容忍导入声明之后的语法错误
+func addImports(src []byte, imports []Import, policy string, partial bool) ([]byte, []string, map[string]string, error) {
+	var added []string
+	renames := make(map[string]string)
+	changed := false
+	for _, imp := range imports {
+		out, ok, existing, err := addImport(src, imp, policy, partial)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if existing != "" {
+			renames[importName(imp.Alias, imp.Path)] = existing
+		}
+		if ok {
+			added = append(added, imp.Path)
+		}
+		if !bytes.Equal(out, src) {
+			src = out
+			changed = true
+		}
+	}
+	if !changed || partial {
+		return src, added, renames, nil
+	}
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("格式化添加导入后的源码失败: %v", err)
+	}
+	return out, added, renames, nil
+}
+
+// importName 返回导入在文件中使用的名称：有别名时为别名，否则为默认包名
+func importName(alias, path string) string {
+	if alias != "" {
+		return alias
+	}
+	return ImportBaseName(path)
+}
+
+// addImport 添加单个导入，导入已存在时返回 false。已有同一路径但名称不同的导入时按 policy 处理：
+// keep 时返回已有的名称，rewrite 时返回改名后的源码
+func addImport(src []byte, imp Import, policy string, partial bool) ([]byte, bool, string, error) {
+	fset := token.NewFileSet()
+	mode := parser.ParseComments
+	if partial {
+		mode |= parser.AllErrors
+	}
+	file, err := parser.ParseFile(fset, "", src, mode)
+	if err != nil {
+		var list scanner.ErrorList
+		if !partial || !errors.As(err, &list) || !importsBeforeError(fset, file, list) {
+			return nil, false, "", fmt.Errorf("解析源码失败: %v", err)
+		}
+	}
+
+	// 检查同一路径是否已以其他名称导入，空白导入不算冲突
+	want := importName(imp.Alias, imp.Path)
+	for _, is := range file.Imports {
+		path, err := strconv.Unquote(is.Path.Value)
+		if err != nil || path != imp.Path {
+			continue
+		}
+		name := ""
+		if is.Name != nil {
+			name = is.Name.Name
+		}
+		if name == "_" {
+			continue
+		}
+		have := importName(name, path)
+		if have == want {
+			return src, false, "", nil
+		}
+		switch policy {
+		case ImportConflictKeep:
+			return src, false, have, nil
+		case ImportConflictRewrite:
+			out, err := renameImport(fset, file, src, is, have, want)
+			return out, false, "", err
+		default:
+			return nil, false, "", WithCode(CodeConflict, fmt.Errorf("导入 %s 已以名称 %s 存在，与配置的名称 %s 冲突（可设置 import_conflict = \"keep\" 或 \"rewrite\"）", imp.Path, have, want))
+		}
+	}
+
+	spec := strconv.Quote(imp.Path)
+	if imp.Alias != "" {
+		spec = imp.Alias + " " + spec
+	}
+
+	// 查找已有导入，并挑选路径最接近的导入作为插入位置
+	var best *ast.ImportSpec
+	var bestDecl *ast.GenDecl
+	bestScore := -1
+	var lastDecl *ast.GenDecl
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for _, s := range gen.Specs {
+			is := s.(*ast.ImportSpec)
+			path, err := strconv.Unquote(is.Path.Value)
+			if err != nil {
+				continue
+			}
+			if path == "C" {
+				continue
+			}
+			if score := importScore(path, imp.Path); score >= bestScore {
+				best, bestDecl, bestScore = is, gen, score
+			}
+		}
+		lastDecl = gen
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case best != nil && bestDecl.Lparen.IsValid():
+		// 插入到导入块中最接近的导入所在行之后
+		eol := lineEnd(src, fset.Position(best.End()).Offset)
+		buf.Write(src[:eol])
+		buf.WriteString("\n\t" + spec)
+		buf.Write(src[eol:])
+	case best != nil:
+		// 单行导入改写为导入块，保留其文档注释和行尾注释
+		kw := fset.Position(bestDecl.TokPos).Offset + len("import")
+		eol := lineEnd(src, fset.Position(bestDecl.End()).Offset)
+		buf.Write(src[:kw])
+		buf.WriteString(" (\n\t")
+		buf.Write(bytes.TrimLeft(src[kw:eol], " \t"))
+		buf.WriteString("\n\t" + spec + "\n)")
+		buf.Write(src[eol:])
+	default:
+		// 没有可合并的导入时新建一条导入声明，位于 import "C" 之后或 package 子句之后
+		pos := file.Name.End()
+		if lastDecl != nil {
+			pos = lastDecl.End()
+		}
+		eol := lineEnd(src, fset.Position(pos).Offset)
+		buf.Write(src[:eol])
+		buf.WriteString("\n\nimport " + spec + "\n")
+		buf.Write(src[eol:])
+	}
+	return buf.Bytes(), true, "", nil
+}
+
+// renameImport 把导入 spec 的名称从 from 改为 to，并改写文件中以 from 引用该包的选择器表达式
+func renameImport(fset *token.FileSet, file *ast.File, src []byte, spec *ast.ImportSpec, from, to string) ([]byte, error) {
+	if to == "." || from == "." {
+		return nil, fmt.Errorf("无法改写点导入 %s", spec.Path.Value)
+	}
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	if spec.Name != nil {
+		edits = append(edits, edit{fset.Position(spec.Name.Pos()).Offset, fset.Position(spec.Name.End()).Offset, to})
+	} else {
+		offset := fset.Position(spec.Path.Pos()).Offset
+		edits = append(edits, edit{offset, offset, to + " "})
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		// 没有解析到局部对象的标识符才是包名，避免误改同名的局部变量
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == from && x.Obj == nil {
+			edits = append(edits, edit{fset.Position(x.Pos()).Offset, fset.Position(x.End()).Offset, to})
+		}
+		return true
+	})
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	return out, nil
+}
+
+// importScore 计算两个导入路径的接近程度：公共前缀的段数越多越接近，
+// 同为标准库或同为第三方包时再加一分
+func importScore(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	score := 0
+	for i := 0; i < len(as) && i < len(bs) && as[i] == bs[i]; i++ {
+		score += 2
+	}
+	if isStdImport(a) == isStdImport(b) {
+		score++
+	}
+	return score
+}
+
+// isStdImport 判断导入路径是否属于标准库：首段不含点号
+func isStdImport(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// lineEnd 返回 offset 所在行的行尾偏移（换行符的位置）
+func lineEnd(src []byte, offset int) int {
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(src)
+}
@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// configExts 目录中被当作配置文件合并的扩展名
+var configExts = map[string]bool{".toml": true, ".yaml": true, ".yml": true, ".json": true, ".cue": true, ".hcl": true, ".star": true}
+
+// configLoader 加载配置文件并合并 include 引用的文件，记录每条规则的来源以便报告重复
+type configLoader struct {
+	// loaded 已合并的文件，同一文件被多次引用（包括循环引用）时只合并一次
+	loaded  map[string]bool
+	sources map[*Rule]string
+	// depth 当前 include 的嵌套层数
+	depth int
+	// dir 合并的是配置目录，解码错误需要带上文件名
+	dir bool
+}
+
+func newConfigLoader() *configLoader {
+	return &configLoader{loaded: make(map[string]bool), sources: make(map[*Rule]string)}
+}
+
+// parseConfigFile 解码配置文件，合并 include 引用的文件后展开并检查
+func parseConfigFile(filename string, decode func(string) (*Config, error)) (*Config, error) {
+	l := newConfigLoader()
+	l.loaded[absPath(filename)] = true
+	config, err := l.load(filename, decode)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.checkDuplicates(config.Rules); err != nil {
+		return nil, err
+	}
+	return prepareConfig(config)
+}
+
+// parseConfigDir 按文件名顺序合并目录下（不含子目录）的所有配置文件，再展开并检查
+func parseConfigDir(dir string) (*Config, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置目录失败: %v", err)
+	}
+	l := newConfigLoader()
+	l.dir = true
+	var config *Config
+	for _, entry := range entries {
+		if entry.IsDir() || !configExts[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		filename := filepath.Join(dir, entry.Name())
+		if l.loaded[absPath(filename)] {
+			continue
+		}
+		l.loaded[absPath(filename)] = true
+		sub, err := l.load(filename, decoderFor(filename))
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			config = sub
+		} else if err := mergeConfig(config, sub, filename); err != nil {
+			return nil, err
+		}
+	}
+	if config == nil {
+		return nil, fmt.Errorf("配置目录 %s 中没有配置文件", dir)
+	}
+	if err := l.checkDuplicates(config.Rules); err != nil {
+		return nil, err
+	}
+	return prepareConfig(config)
+}
+
+// load 解码一个配置文件，并按 include 中的顺序合并引用的文件（通配符的匹配结果按文件名排序）
+func (l *configLoader) load(filename string, decode func(string) (*Config, error)) (*Config, error) {
+	config, err := decode(filename)
+	if err != nil {
+		if l.dir || l.depth > 0 {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		return nil, err
+	}
+	if err := upgradeConfig(filename, config); err != nil {
+		return nil, err
+	}
+	for _, rule := range config.Rules {
+		l.sources[rule] = filename
+	}
+
+	// 先加载父配置，本文件的设置叠加在上面
+	if config.Extends != "" {
+		base := filepath.Join(filepath.Dir(filename), config.Extends)
+		if l.loaded[absPath(base)] {
+			return nil, fmt.Errorf("%s extends 的 %s 已经加载过，不能循环或重复引用", filename, config.Extends)
+		}
+		l.loaded[absPath(base)] = true
+		l.depth++
+		parent, err := l.load(base, decoderFor(base))
+		l.depth--
+		if err != nil {
+			return nil, err
+		}
+		config.Extends = ""
+		config = extendConfig(parent, config)
+	}
+
+	includes := config.Include
+	config.Include = nil
+	for _, pattern := range includes {
+		matches, err := filepath.Glob(filepath.Join(filepath.Dir(filename), pattern))
+		if err != nil {
+			return nil, fmt.Errorf("%s 中的 include %q 无效: %v", filename, pattern, err)
+		}
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return nil, fmt.Errorf("%s 中 include 的文件 %s 不存在", filename, pattern)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if l.loaded[absPath(match)] {
+				continue
+			}
+			l.loaded[absPath(match)] = true
+			l.depth++
+			sub, err := l.load(match, decoderFor(match))
+			l.depth--
+			if err != nil {
+				return nil, err
+			}
+			if err := mergeConfig(config, sub, match); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return config, nil
+}
+
+// checkDuplicates 检查合并后的规则中重复的 ID 和内容完全相同的规则，报告它们的来源文件
+func (l *configLoader) checkDuplicates(rules []*Rule) error {
+	ids := make(map[string]*Rule)
+	bodies := make(map[string]*Rule)
+	for _, rule := range rules {
+		if rule.ID != "" {
+			if prev, ok := ids[rule.ID]; ok {
+				return fmt.Errorf("规则 ID %s 重复: 分别定义在 %s 和 %s", rule.ID, l.sources[prev], l.sources[rule])
+			}
+			ids[rule.ID] = rule
+		}
+		body, err := json.Marshal(rule)
+		if err != nil {
+			return err
+		}
+		if prev, ok := bodies[string(body)]; ok {
+			return fmt.Errorf("规则 %s 重复: %s 和 %s 中的规则完全相同", rule.Name(), l.sources[prev], l.sources[rule])
+		}
+		bodies[string(body)] = rule
+	}
+	return nil
+}
+
+// mergeConfig 把 src 合并到 dst：列表追加在后面，表按名称合并且同名的定义必须相同，
+// 其他设置只能在一个文件中设置，或者各处取值相同
+func mergeConfig(dst, src *Config, source string) error {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for i := 0; i < d.NumField(); i++ {
+		key := strings.Split(d.Type().Field(i).Tag.Get("toml"), ",")[0]
+		df, sf := d.Field(i), s.Field(i)
+		switch df.Kind() {
+		case reflect.Slice:
+			df.Set(reflect.AppendSlice(df, sf))
+		case reflect.Map:
+			if sf.Len() == 0 {
+				continue
+			}
+			if df.IsNil() {
+				df.Set(reflect.MakeMap(df.Type()))
+			}
+			iter := sf.MapRange()
+			for iter.Next() {
+				if prev := df.MapIndex(iter.Key()); prev.IsValid() && !reflect.DeepEqual(prev.Interface(), iter.Value().Interface()) {
+					return fmt.Errorf("%s 中的 %s.%v 与已有的定义冲突", source, key, iter.Key())
+				}
+				df.SetMapIndex(iter.Key(), iter.Value())
+			}
+		default:
+			if sf.IsZero() {
+				continue
+			}
+			if !df.IsZero() && !reflect.DeepEqual(df.Interface(), sf.Interface()) {
+				return fmt.Errorf("%s 中的 %s 与已有的设置冲突", source, key)
+			}
+			df.Set(sf)
+		}
+	}
+	return nil
+}
+
+// absPath 返回用于识别同一文件的绝对路径，失败时使用原路径
+func absPath(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		return abs
+	}
+	return filename
+}
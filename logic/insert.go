@@ -0,0 +1,154 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// insertKey 标识字段的插入位置：结构体名和锚点注释，锚点为空表示结构体末尾
+type insertKey struct {
+	structName string
+	anchor     string
+}
+
+// fieldInsert 同一插入位置登记的字段源码，order 为该位置第一次登记的次序
+type fieldInsert struct {
+	order int
+	lines []string
+}
+
+// FieldInserts 记录打印后需要插入到结构体中的字段源码。
+// 新字段不直接追加到语法树，因为没有位置信息的节点会让 go/printer 把上一个字段的行尾注释挪到新字段后面
+type FieldInserts map[insertKey]*fieldInsert
+
+// Add 登记一个需要插入的字段源码，anchor 为空时插入到结构体末尾，同一位置的字段按登记顺序排列
+func (f FieldInserts) Add(structName, anchor, line string) {
+	key := insertKey{structName: structName, anchor: anchor}
+	if f[key] == nil {
+		f[key] = &fieldInsert{order: len(f)}
+	}
+	f[key].lines = append(f[key].lines, line)
+}
+
+// keys 按登记顺序返回所有插入位置
+func (f FieldInserts) keys() []insertKey {
+	keys := make([]insertKey, 0, len(f))
+	for key := range f {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return f[keys[i]].order < f[keys[j]].order })
+	return keys
+}
+
+// FieldSource 返回字段的单行源码，形如 Name Type `tag`
+func FieldSource(field *ast.Field) string {
+	var names []string
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	src := strings.Join(names, ", ") + " " + types.ExprString(field.Type)
+	if field.Tag != nil {
+		src += " " + field.Tag.Value
+	}
+	return src
+}
+
+// InsertFields 在格式化后的源码中插入登记的字段：设置了锚点的插入到结构体内锚点注释所在行之后，
+// 否则插入到右花括号所在行之前。结构体中找不到锚点注释时返回错误，避免字段落到非预期的位置
+func InsertFields(src []byte, inserts FieldInserts) ([]byte, error) {
+	if len(inserts) == 0 {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+
+	type insertion struct {
+		offset int
+		text   string
+	}
+	var insertions []insertion
+	// 不同锚点可能落在同一位置（如锚点注释在最后一个字段之后），同一位置的字段按登记顺序合并
+	at := make(map[int]int)
+	found := make(map[insertKey]bool)
+	keys := inserts.keys()
+	WalkStructs(file, func(name string, structType *ast.StructType) {
+		for _, key := range keys {
+			if key.structName != name {
+				continue
+			}
+			lines := inserts[key].lines
+			var offset int
+			if key.anchor == "" {
+				// 右花括号所在行之前；字段列表写在一行时直接插在右花括号前
+				closing := fset.Position(structType.Fields.Closing).Offset
+				offset = strings.LastIndexByte(string(src[:closing]), '\n') + 1
+				if fset.Position(structType.Fields.Opening).Line == fset.Position(structType.Fields.Closing).Line {
+					offset = closing
+					lines = append([]string{""}, lines...)
+				}
+			} else {
+				// 锚点注释所在行之后
+				comment := findAnchor(file, structType, key.anchor)
+				if comment == nil {
+					continue
+				}
+				end := fset.Position(comment.End()).Offset
+				if i := strings.IndexByte(string(src[end:]), '\n'); i >= 0 {
+					end += i + 1
+				}
+				offset = end
+			}
+			found[key] = true
+			text := strings.Join(lines, "\n") + "\n"
+			if i, ok := at[offset]; ok {
+				insertions[i].text += text
+				continue
+			}
+			at[offset] = len(insertions)
+			insertions = append(insertions, insertion{offset: offset, text: text})
+		}
+	})
+
+	for _, key := range keys {
+		if !found[key] {
+			if key.anchor == "" {
+				return nil, WithCode(CodeMatch, fmt.Errorf("没有找到结构体 %s", key.structName))
+			}
+			return nil, WithCode(CodeMatch, fmt.Errorf("结构体 %s 中没有找到锚点注释 %q", key.structName, key.anchor))
+		}
+	}
+
+	// 从后往前插入，避免偏移量失效
+	sort.Slice(insertions, func(i, j int) bool { return insertions[i].offset > insertions[j].offset })
+	out := string(src)
+	for _, ins := range insertions {
+		out = out[:ins.offset] + ins.text + out[ins.offset:]
+	}
+	return format.Source([]byte(out))
+}
+
+// findAnchor 查找结构体字段列表内文本为锚点的注释
+func findAnchor(file *ast.File, structType *ast.StructType, anchor string) *ast.Comment {
+	for _, group := range file.Comments {
+		if group.Pos() < structType.Fields.Opening || group.End() > structType.Fields.Closing {
+			continue
+		}
+		for _, c := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if text == anchor {
+				return c
+			}
+		}
+	}
+	return nil
+}
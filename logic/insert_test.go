@@ -0,0 +1,37 @@
+package logic
+
+import "testing"
+
+func TestInsertFieldsSharedOffset(t *testing.T) {
+	src := `package m
+
+type User struct {
+	ID int
+	// fields
+}
+`
+	want := `package m
+
+type User struct {
+	ID int
+	// fields
+	A int
+	C int
+	B int
+}
+`
+	// 锚点注释在最后一行时与结构体末尾是同一位置，多次执行确认顺序不受 map 遍历影响
+	for i := 0; i < 20; i++ {
+		inserts := make(FieldInserts)
+		inserts.Add("User", "fields", "A int")
+		inserts.Add("User", "", "B int")
+		inserts.Add("User", "fields", "C int")
+		got, err := InsertFields([]byte(src), inserts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultLifecycleFile 记录字段废弃阶段的文件的默认位置，相对于模块根目录。
+// 这个文件需要和代码一起提交，新的克隆和 CI 中才能确认字段已经过废弃阶段
+const DefaultLifecycleFile = "astauto.deprecations.json"
+
+// 字段在先废弃、后删除流程中的阶段
+const (
+	PhaseDeprecated = "deprecated"
+	PhaseRemoved    = "removed"
+)
+
+// Deprecation 结构体表示配置中的 [deprecation]：删除字段之前必须先经过废弃阶段。
+// 字段在 deprecate 中出现并写回后，废弃的时间记录在 journal 文件中
+type Deprecation struct {
+	// Require 删除字段（remove）前要求该字段已经由 deprecate 标记为废弃
+	Require bool `json:"require" toml:"require"`
+	// GraceDays 标记废弃后至少经过多少天才能删除，大于 0 时同样要求先废弃
+	GraceDays int `json:"grace_days" toml:"grace_days"`
+	// Journal 记录字段废弃阶段的文件，相对路径相对于模块根目录，默认为 astauto.deprecations.json。
+	// 与运行状态目录不同，这个文件需要提交到版本库
+	Journal string `json:"journal" toml:"journal"`
+}
+
+// Enabled 返回删除字段时是否需要检查废弃阶段
+func (d Deprecation) Enabled() bool {
+	return d.Require || d.GraceDays > 0
+}
+
+// Validate 检查废弃流程的配置
+func (d Deprecation) Validate() error {
+	if d.GraceDays < 0 {
+		return fmt.Errorf("deprecation.grace_days 不能为负数")
+	}
+	return nil
+}
+
+// JournalPath 返回记录字段废弃阶段的文件：journal 为绝对路径时原样使用，
+// 为相对路径时相对于处理目录 root 所在模块的根目录
+func (d Deprecation) JournalPath(root string) string {
+	journal := d.Journal
+	if journal == "" {
+		journal = DefaultLifecycleFile
+	}
+	if filepath.IsAbs(journal) {
+		return journal
+	}
+	return filepath.Join(ModuleRoot(root), journal)
+}
+
+// FieldPhase 记录字段所处的阶段和进入该阶段的时间
+type FieldPhase struct {
+	Phase string    `json:"phase"`
+	Since time.Time `json:"since"`
+}
+
+// Lifecycle 废弃记录中字段的废弃阶段，键为 文件#结构体.字段，文件相对于处理目录
+type Lifecycle map[string]FieldPhase
+
+// lifecycleKey 返回字段在记录中的键
+func lifecycleKey(file, structName, field string) string {
+	return filepath.ToSlash(file) + "#" + structName + "." + field
+}
+
+// LoadLifecycle 读取废弃记录文件，文件不存在时返回空记录
+func LoadLifecycle(path string) (Lifecycle, error) {
+	l := make(Lifecycle)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取废弃记录失败: %v", err)
+	}
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("解析废弃记录 %s 失败: %v", path, err)
+	}
+	return l, nil
+}
+
+// Save 把废弃记录写回文件 path，先写临时文件再改名，避免中断时留下不完整的记录
+func (l Lifecycle) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建废弃记录所在的目录失败: %v", err)
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入废弃记录失败: %v", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("写入废弃记录失败: %v", err)
+	}
+	return nil
+}
+
+// MarkDeprecated 记录字段进入废弃阶段，已经处于废弃阶段时保留最早的时间；返回记录是否变化
+func (l Lifecycle) MarkDeprecated(file, structName, field string, now time.Time) bool {
+	key := lifecycleKey(file, structName, field)
+	if l[key].Phase == PhaseDeprecated {
+		return false
+	}
+	l[key] = FieldPhase{Phase: PhaseDeprecated, Since: now.UTC().Truncate(time.Second)}
+	return true
+}
+
+// MarkRemoved 记录字段已被删除
+func (l Lifecycle) MarkRemoved(file, structName, field string, now time.Time) {
+	l[lifecycleKey(file, structName, field)] = FieldPhase{Phase: PhaseRemoved, Since: now.UTC().Truncate(time.Second)}
+}
+
+// CheckRemoval 按废弃流程的配置检查字段现在能否删除：字段必须处于废弃阶段，
+// 并且设置了 grace_days 时从标记废弃起已经过了足够的天数
+func (l Lifecycle) CheckRemoval(policy Deprecation, file, structName, field string, now time.Time) error {
+	if !policy.Enabled() {
+		return nil
+	}
+	phase, ok := l[lifecycleKey(file, structName, field)]
+	if !ok || phase.Phase != PhaseDeprecated {
+		return fmt.Errorf("字段 %s.%s 没有经过废弃阶段，先在 deprecate 中标记废弃并写回后才能删除", structName, field)
+	}
+	deadline := phase.Since.AddDate(0, 0, policy.GraceDays)
+	if now.Before(deadline) {
+		return fmt.Errorf("字段 %s.%s 于 %s 标记废弃，deprecation.grace_days = %d，%s 之后才能删除",
+			structName, field, phase.Since.Format("2006-01-02"), policy.GraceDays, deadline.Format("2006-01-02"))
+	}
+	return nil
+}
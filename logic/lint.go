@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// 内置的标签检查
+const (
+	// LintJSONCase json 标签中的名称符合约定的命名风格
+	LintJSONCase = "json-case"
+	// LintGormColumn gorm 标签中 column 的值为蛇形命名
+	LintGormColumn = "gorm-column"
+	// LintDBTag 结构体中有字段带 db 标签时，其余导出字段也都带 db 标签
+	LintDBTag = "db-tag"
+)
+
+// LintChecks 全部内置检查，配置中没有指定时全部启用
+var LintChecks = []string{LintJSONCase, LintGormColumn, LintDBTag}
+
+// Lint 结构体表示 astauto lint 的配置，检查范围与规则选中的结构体相同
+type Lint struct {
+	// Checks 启用的检查，为空时启用 LintChecks 中的全部检查
+	Checks []string `json:"checks" toml:"checks"`
+	// JSONCase json 名称的命名风格：snake（默认）或 camel
+	JSONCase string `json:"json_case" toml:"json_case"`
+}
+
+// Validate 检查 lint 配置
+func (l Lint) Validate() error {
+	for _, check := range l.Checks {
+		if !containsCheck(LintChecks, check) {
+			return fmt.Errorf("未知的 lint 检查 %q，支持 %s", check, strings.Join(LintChecks, "、"))
+		}
+	}
+	switch l.JSONCase {
+	case "", "snake", "camel":
+	default:
+		return fmt.Errorf("lint 的 json_case %q 无效，只支持 snake 和 camel", l.JSONCase)
+	}
+	return nil
+}
+
+// enabled 判断检查是否启用
+func (l Lint) enabled(check string) bool {
+	return len(l.Checks) == 0 || containsCheck(l.Checks, check)
+}
+
+var (
+	snakePattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+	camelPattern = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+)
+
+// LintStruct 对结构体的字段标签执行启用的检查，返回的问题没有填写 Rule 和 File
+func LintStruct(fset *token.FileSet, name string, st *ast.StructType, conf Lint) []Finding {
+	var findings []Finding
+	report := func(check string, field *ast.Ident, format string, args ...interface{}) {
+		findings = append(findings, Finding{
+			Kind:    FindingLint,
+			Level:   LevelError,
+			Line:    fset.Position(field.Pos()).Line,
+			Message: fmt.Sprintf("[%s] %s.%s: ", check, name, field.Name) + fmt.Sprintf(format, args...),
+		})
+	}
+
+	jsonCase, pattern, convert := "snake", snakePattern, SnakeCase
+	if conf.JSONCase == "camel" {
+		jsonCase, pattern, convert = "camel", camelPattern, CamelCase
+	}
+	hasDB := anyTag(st, "db")
+	for _, field := range st.Fields.List {
+		tag := fieldTag(field)
+		for _, ident := range field.Names {
+			if conf.enabled(LintJSONCase) {
+				jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+				if jsonName != "" && jsonName != "-" && !pattern.MatchString(jsonName) {
+					report(LintJSONCase, ident, "json 名称 %q 不是 %s 风格，建议写成 %q", jsonName, jsonCase, convert(ident.Name))
+				}
+			}
+			if conf.enabled(LintGormColumn) {
+				if column := gormSetting(tag.Get("gorm"), "column"); column != "" && !snakePattern.MatchString(column) {
+					report(LintGormColumn, ident, "gorm 列名 %q 不是蛇形命名，建议写成 %q", column, SnakeCase(ident.Name))
+				}
+			}
+			if conf.enabled(LintDBTag) && hasDB && ident.IsExported() {
+				if _, ok := tag.Lookup("db"); !ok {
+					report(LintDBTag, ident, "结构体中其他字段带有 db 标签，该导出字段缺少 db 标签，建议写成 db:%q", SnakeCase(ident.Name))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// gormSetting 返回 gorm 标签中指定设置的值，如 column:user_id;not null 中 column 的值
+func gormSetting(tag, key string) string {
+	for _, part := range strings.Split(tag, ";") {
+		k, v, ok := strings.Cut(part, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(k), key) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// containsCheck 判断检查名是否在列表中
+func containsCheck(checks []string, check string) bool {
+	for _, c := range checks {
+		if c == check {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockFile 运行锁文件在运行状态目录中的文件名
+const LockFile = "run.lock"
+
+// lockPollInterval 等待锁时的重试间隔
+const lockPollInterval = 100 * time.Millisecond
+
+// RunLock 是处理目录上的运行锁，防止多个 astauto 进程交错写入同一批文件
+type RunLock struct {
+	file *os.File
+	path string
+}
+
+// AcquireLock 获取运行状态目录 stateDir 中的运行锁，被其他进程持有时最多等待 wait，超时返回错误
+func AcquireLock(stateDir string, wait time.Duration) (*RunLock, error) {
+	path := filepath.Join(stateDir, LockFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建锁目录失败: %v", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		file, ok, err := tryLock(path)
+		if err != nil {
+			return nil, fmt.Errorf("获取运行锁失败: %v", err)
+		}
+		if ok {
+			// 记录持有者的进程号，便于排查
+			file.Truncate(0)
+			file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+			return &RunLock{file: file, path: path}, nil
+		}
+		if time.Now().After(deadline) {
+			owner, _ := os.ReadFile(path)
+			return nil, fmt.Errorf("另一个 astauto 进程（pid %s）正在运行，等待运行锁 %s 超时", strings.TrimSpace(string(owner)), path)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Release 释放运行锁
+func (l *RunLock) Release() error {
+	return unlock(l.file, l.path)
+}
@@ -0,0 +1,27 @@
+//go:build !unix
+
+package logic
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock 在不支持 flock 的平台上以独占创建锁文件的方式加锁，
+// 进程异常退出后需要手动删除遗留的锁文件
+func tryLock(path string) (*os.File, bool, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return file, true, nil
+}
+
+// unlock 关闭并删除锁文件
+func unlock(file *os.File, path string) error {
+	file.Close()
+	return os.Remove(path)
+}
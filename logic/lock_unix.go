@@ -0,0 +1,31 @@
+//go:build unix
+
+package logic
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock 以 flock 尝试对锁文件加排他锁，进程退出时内核会自动释放
+func tryLock(path string) (*os.File, bool, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return file, true, nil
+}
+
+// unlock 释放 flock 并关闭锁文件，锁文件本身保留以免与正在等待的进程竞争
+func unlock(file *os.File, path string) error {
+	defer file.Close()
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,125 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// ManagedMarker 标记由 astauto 注入的字段和声明的注释前缀
+const ManagedMarker = "astauto:managed"
+
+// ManagedComment 返回规则的标记注释，形如 // astauto:managed rule=xyz
+func ManagedComment(rule string) string {
+	return "// " + ManagedMarker + " rule=" + rule
+}
+
+// MarkDecls 在代码片段的每个顶层声明前加上标记注释，标记作为文档注释的最后一行
+func MarkDecls(code, rule string) (string, error) {
+	const header = "package snippet\n"
+	src := header + code
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("解析代码片段失败: %v", err)
+	}
+	var sb strings.Builder
+	last := len(header)
+	for _, decl := range file.Decls {
+		offset := lineStart(src, fset.Position(decl.Pos()).Offset)
+		sb.WriteString(src[last:offset])
+		sb.WriteString(ManagedComment(rule) + "\n")
+		last = offset
+	}
+	sb.WriteString(src[last:])
+	return sb.String(), nil
+}
+
+// isManaged 判断注释组中是否含有标记注释
+func isManaged(group *ast.CommentGroup) bool {
+	if group == nil {
+		return false
+	}
+	for _, c := range group.List {
+		if strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(c.Text, "//")), ManagedMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+// StripManaged 删除源码中带有标记注释的字段（行尾注释）和顶层声明（文档注释），
+// 连同它们的文档注释一起删除，返回新源码和删除的数量
+func StripManaged(src []byte) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析源码失败: %v", err)
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	add := func(doc *ast.CommentGroup, node ast.Node, end token.Pos) {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		spans = append(spans, span{
+			start: lineStart(string(src), fset.Position(start).Offset),
+			end:   lineEnd(src, fset.Position(end).Offset) + 1,
+		})
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if isManaged(d.Doc) {
+				add(d.Doc, d, d.End())
+				continue
+			}
+		case *ast.GenDecl:
+			if isManaged(d.Doc) {
+				add(d.Doc, d, d.End())
+				continue
+			}
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			st, ok := n.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			for _, field := range st.Fields.List {
+				if isManaged(field.Comment) {
+					add(field.Doc, field, field.Comment.End())
+				}
+			}
+			return true
+		})
+	}
+	if len(spans) == 0 {
+		return src, 0, nil
+	}
+
+	// 从后往前删除，避免偏移失效
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+	out := append([]byte(nil), src...)
+	for _, s := range spans {
+		if s.end > len(out) {
+			s.end = len(out)
+		}
+		out = append(out[:s.start], out[s.end:]...)
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, 0, fmt.Errorf("格式化源码失败: %v", err)
+	}
+	return formatted, len(spans), nil
+}
+
+// lineStart 返回 offset 所在行的行首偏移
+func lineStart(src string, offset int) int {
+	return strings.LastIndexByte(src[:offset], '\n') + 1
+}
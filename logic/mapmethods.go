@@ -0,0 +1,235 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MapSpec 描述需要生成 ToMap 和 FromMap 的结构体
+type MapSpec struct {
+	// Key 作为 map 键的标签，如 json 或 db；字段没有该标签时使用字段名，标签为 - 的字段跳过
+	Key string
+	// Receiver 方法接收者的名称，为空时使用类型名的首字母小写
+	Receiver string
+	// ValueReceiver ToMap 使用值接收者，见 Codegen.Receiver
+	ValueReceiver bool
+}
+
+// mapLocals 生成的方法中使用的局部变量名，接收者不能与之重名
+var mapLocals = map[string]bool{"m": true, "k": true, "v": true, "x": true, "ok": true}
+
+// MapMethods 按源码中结构体当前的字段生成 ToMap 和 FromMap 方法的源码：结构体名 -> 配置。
+// 只处理顶层结构体的具名导出字段，目标版本早于 go1.18 时使用 interface{} 代替 any
+func MapMethods(src []byte, specs map[string]MapSpec, goVersion string) (string, error) {
+	if len(specs) == 0 {
+		return "", nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("解析源码失败: %v", err)
+	}
+	anyType := "any"
+	if beforeGenerics(goVersion) {
+		anyType = "interface{}"
+	}
+
+	var names []string
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		structType := topLevelStruct(file, name)
+		if structType == nil {
+			continue
+		}
+		sb.WriteString(mapMethodsSource(name, structType, specs[name], anyType))
+	}
+	return sb.String(), nil
+}
+
+// topLevelStruct 返回文件中顶层声明的结构体类型
+func topLevelStruct(file *ast.File, name string) *ast.StructType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if st, ok := ts.Type.(*ast.StructType); ok && ts.Name.Name == name && ts.TypeParams == nil {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// mapMethodsSource 生成单个结构体的 ToMap 和 FromMap
+func mapMethodsSource(name string, structType *ast.StructType, spec MapSpec, anyType string) string {
+	recv := spec.Receiver
+	if recv == "" {
+		recv = string(unicode.ToLower([]rune(name)[0]))
+	}
+	if mapLocals[recv] {
+		recv = "s"
+	}
+
+	type entry struct{ key, field, typ string }
+	var entries []entry
+	for _, field := range structType.Fields.List {
+		key := ""
+		if field.Tag != nil {
+			if tag, err := strconv.Unquote(field.Tag.Value); err == nil {
+				key, _, _ = strings.Cut(reflect.StructTag(tag).Get(spec.Key), ",")
+			}
+		}
+		if key == "-" {
+			continue
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			k := key
+			if k == "" {
+				k = ident.Name
+			}
+			entries = append(entries, entry{key: k, field: ident.Name, typ: types.ExprString(field.Type)})
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n// ToMap 返回以 %s 标签名为键的字段值，由 astauto 按字段生成\n", spec.Key)
+	toMapRecv := recv + " *" + name
+	if spec.ValueReceiver {
+		toMapRecv = recv + " " + name
+	}
+	fmt.Fprintf(&sb, "func (%s) ToMap() map[string]%s {\n", toMapRecv, anyType)
+	fmt.Fprintf(&sb, "\treturn map[string]%s{\n", anyType)
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "\t\t%s: %s.%s,\n", strconv.Quote(e.key), recv, e.field)
+	}
+	sb.WriteString("\t}\n}\n")
+
+	fmt.Fprintf(&sb, "\n// FromMap 按 %s 标签名设置字段，值的类型与字段不符时返回错误，由 astauto 按字段生成\n", spec.Key)
+	fmt.Fprintf(&sb, "func (%s *%s) FromMap(m map[string]%s) error {\n", recv, name, anyType)
+	if len(entries) > 0 {
+		sb.WriteString("\tfor k, v := range m {\n\t\tswitch k {\n")
+		for _, e := range entries {
+			fmt.Fprintf(&sb, "\t\tcase %s:\n", strconv.Quote(e.key))
+			fmt.Fprintf(&sb, "\t\t\tx, ok := v.(%s)\n", e.typ)
+			fmt.Fprintf(&sb, "\t\t\tif !ok {\n\t\t\t\treturn fmt.Errorf(\"%%s: %%T 不能赋值给 %s\", k, v)\n\t\t\t}\n", strings.ReplaceAll(e.typ, `"`, `\"`))
+			fmt.Fprintf(&sb, "\t\t\t%s.%s = x\n", recv, e.field)
+		}
+		sb.WriteString("\t\t}\n\t}\n")
+	}
+	sb.WriteString("\treturn nil\n}\n")
+	return sb.String()
+}
+
+// ReplaceDecls 将代码中的声明写入源码：同名声明不存在时追加到末尾，已存在但内容（含文档注释）不同时原地替换。
+// 返回新的源码、追加的和替换的声明名称
+func ReplaceDecls(src []byte, code string) ([]byte, []string, []string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("解析源码失败: %v", err)
+	}
+	type span struct{ start, end int }
+	existing := make(map[string]span)
+	for _, decl := range file.Decls {
+		start, end := declSpan(fset, decl)
+		for _, key := range DeclKeys(decl) {
+			existing[key] = span{start, end}
+		}
+	}
+
+	// 代码只包含声明，补上包名后格式化，用格式化后的代码比较，避免仅因排版不同而替换
+	const header = "package snippet\n"
+	formatted, err := format.Source([]byte(header + code))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("解析生成的代码失败: %v", err)
+	}
+	fset = token.NewFileSet()
+	codeFile, err := parser.ParseFile(fset, "", formatted, parser.ParseComments)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("解析生成的代码失败: %v", err)
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	var appended strings.Builder
+	var added, replaced []string
+	for _, decl := range codeFile.Decls {
+		start, end := declSpan(fset, decl)
+		text := string(formatted[start:end])
+		// 常量组等包含多个名称的声明按其中任一已有的名称匹配
+		keys := DeclKeys(decl)
+		found := false
+		for _, key := range keys {
+			s, ok := existing[key]
+			if !ok {
+				continue
+			}
+			found = true
+			if string(src[s.start:s.end]) != text {
+				edits = append(edits, edit{s.start, s.end, text})
+				replaced = append(replaced, keys[0])
+			}
+			break
+		}
+		if !found {
+			appended.WriteString("\n" + text + "\n")
+			added = append(added, keys[0])
+		}
+	}
+	if len(edits) == 0 && len(added) == 0 {
+		return src, nil, nil, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	if appended.Len() > 0 {
+		out = append(bytes.TrimRight(out, "\n"), []byte("\n"+appended.String())...)
+	}
+	result, err := format.Source(out)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("格式化源码失败: %v", err)
+	}
+	return result, added, replaced, nil
+}
+
+// declSpan 返回声明连同文档注释在源码中的起止偏移
+func declSpan(fset *token.FileSet, decl ast.Decl) (int, int) {
+	start := decl.Pos()
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+	}
+	return fset.Position(start).Offset, fset.Position(decl.End()).Offset
+}
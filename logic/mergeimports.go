@@ -0,0 +1,150 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// importChunk 是合并导入时的一项：导入的源码（连同其上方的注释和行尾注释）及是否开始新的分组
+type importChunk struct {
+	key      string
+	text     string
+	newGroup bool
+}
+
+// MergeImports 把多个导入声明合并为一个导入块，并删除名称和路径都相同的重复导入，
+// 返回新源码和被合并或删除的导入声明数量。各声明中的注释和分组（空行）保留，
+// 原来的每个声明各自成为一组；import "C" 带有 cgo 前导注释，保持独立
+func MergeImports(src []byte) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析源码失败: %v", err)
+	}
+
+	var decls []*ast.GenDecl
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || isCgoImport(gen) {
+			continue
+		}
+		decls = append(decls, gen)
+	}
+	if len(decls) == 0 {
+		return src, 0, nil
+	}
+
+	text := string(src)
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	var chunks []importChunk
+	seen := make(map[string]bool)
+	removed := 0
+	add := func(spec *ast.ImportSpec, chunkText string, newGroup bool) {
+		key := spec.Path.Value
+		if spec.Name != nil {
+			key = spec.Name.Name + " " + key
+		}
+		if seen[key] {
+			removed++
+			return
+		}
+		seen[key] = true
+		chunks = append(chunks, importChunk{key: key, text: strings.Trim(chunkText, "\n"), newGroup: newGroup})
+	}
+	for i, gen := range decls {
+		if !gen.Lparen.IsValid() {
+			// 单行导入声明，文档注释随导入一起移动
+			spec := gen.Specs[0].(*ast.ImportSpec)
+			chunkText := text[offset(spec.Pos()):lineEnd(src, offset(spec.End()))]
+			if i > 0 && gen.Doc != nil {
+				chunkText = text[offset(gen.Doc.Pos()):offset(gen.Doc.End())] + "\n" + chunkText
+			}
+			add(spec, chunkText, true)
+			continue
+		}
+
+		// 导入块中的每一项从上一项的行尾开始，包含其上方的注释
+		start := lineEnd(src, offset(gen.Lparen)) + 1
+		for j, s := range gen.Specs {
+			spec := s.(*ast.ImportSpec)
+			end := lineEnd(src, offset(spec.End()))
+			chunkText := text[start:end]
+			start = end + 1
+			if j == 0 && i > 0 && gen.Doc != nil {
+				// 导入块的文档注释移到它的第一项上方
+				chunkText = text[offset(gen.Doc.Pos()):offset(gen.Doc.End())] + "\n" + chunkText
+			}
+
+			// 与上一项之间有空行时开始新的分组
+			add(spec, chunkText, j == 0 || hasBlankLine(chunkText))
+		}
+	}
+	if len(decls) == 1 && removed == 0 {
+		return src, 0, nil
+	}
+
+	// 生成合并后的导入块
+	var sb strings.Builder
+	sb.WriteString("import (\n")
+	for i, chunk := range chunks {
+		if i > 0 && chunk.newGroup {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(chunk.text + "\n")
+	}
+	sb.WriteString(")")
+
+	// 第一个导入声明替换为合并后的导入块，其余的删除（连同文档注释）
+	var out strings.Builder
+	last := 0
+	for i, gen := range decls {
+		start := offset(gen.Pos())
+		if i > 0 && gen.Doc != nil {
+			start = offset(gen.Doc.Pos())
+		}
+		end := offset(gen.End())
+		if i > 0 {
+			start = lineStart(text, start)
+			end = lineEnd(src, end)
+		}
+		out.WriteString(text[last:start])
+		if i == 0 {
+			out.WriteString(sb.String())
+		}
+		last = end
+	}
+	out.WriteString(text[last:])
+
+	formatted, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return nil, 0, fmt.Errorf("格式化合并导入后的源码失败: %v", err)
+	}
+	return formatted, len(decls) - 1 + removed, nil
+}
+
+// isCgoImport 判断导入声明是否为 import "C"
+func isCgoImport(gen *ast.GenDecl) bool {
+	for _, s := range gen.Specs {
+		if path, err := strconv.Unquote(s.(*ast.ImportSpec).Path.Value); err == nil && path == "C" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBlankLine 判断文本中除最后一行外是否有空白行
+func hasBlankLine(text string) bool {
+	lines := strings.Split(text, "\n")
+	for _, l := range lines[:len(lines)-1] {
+		if strings.TrimSpace(l) == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// MethodKeys 返回文件中全部方法的键，形如 User.Save，与 DeclKeys 一致
+func MethodKeys(file *ast.File) map[string]bool {
+	keys := make(map[string]bool)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil {
+			for _, key := range DeclKeys(fn) {
+				keys[key] = true
+			}
+		}
+	}
+	return keys
+}
+
+// RemoveMethods 删除源码中键（接收者类型.方法名）在 methods 中的方法，连同文档注释一起删除
+func RemoveMethods(src []byte, methods map[string]bool) ([]byte, error) {
+	if len(methods) == 0 {
+		return src, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析源码失败: %v", err)
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || !methods[DeclKeys(fn)[0]] {
+			continue
+		}
+		start := fn.Pos()
+		if fn.Doc != nil {
+			start = fn.Doc.Pos()
+		}
+		spans = append(spans, span{
+			start: lineStart(string(src), fset.Position(start).Offset),
+			end:   lineEnd(src, fset.Position(fn.End()).Offset),
+		})
+	}
+	if len(spans) == 0 {
+		return src, nil
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+	out := append([]byte(nil), src...)
+	for _, s := range spans {
+		out = append(out[:s.start], out[s.end:]...)
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("格式化源码失败: %v", err)
+	}
+	return formatted, nil
+}
+
+// ReceiverRename 记录一个方法接收者的改名
+type ReceiverRename struct {
+	Struct string
+	Method string
+	Old    string
+	New    string
+}
+
+// NormalizeReceivers 把源码中各类型方法的接收者统一改为配置的名称：类型名 -> 接收者名，
+// 方法体中对接收者的引用一起改写。匿名接收者和 _ 保持不变；
+// 方法中已有同名的标识符时改名会改变语义，返回错误
+func NormalizeReceivers(src []byte, receivers map[string]string) ([]byte, []ReceiverRename, error) {
+	if len(receivers) == 0 {
+		return src, nil, nil
+	}
+	fset := token.NewFileSet()
+	// 依赖解析器的标识符解析区分接收者和方法内同名的其他声明
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析源码失败: %v", err)
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	var renames []ReceiverRename
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+			continue
+		}
+		recv := fn.Recv.List[0]
+		typeName := receiverTypeName(recv.Type)
+		want, ok := receivers[typeName]
+		if !ok || len(recv.Names) == 0 {
+			continue
+		}
+		ident := recv.Names[0]
+		if ident.Name == want || ident.Name == "_" {
+			continue
+		}
+
+		refs := []*ast.Ident{ident}
+		var clash *ast.Ident
+		ast.Inspect(fn, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				// x.Sel 中的 Sel 不会与接收者冲突
+				ast.Inspect(n.X, func(m ast.Node) bool {
+					if id, ok := m.(*ast.Ident); ok {
+						if id != ident && ident.Obj != nil && id.Obj == ident.Obj {
+							refs = append(refs, id)
+						} else if id.Name == want && clash == nil {
+							clash = id
+						}
+					}
+					return true
+				})
+				return false
+			case *ast.Ident:
+				if n == ident {
+					return true
+				}
+				if ident.Obj != nil && n.Obj == ident.Obj {
+					refs = append(refs, n)
+				} else if n.Name == want && clash == nil {
+					clash = n
+				}
+			}
+			return true
+		})
+		if clash != nil {
+			return nil, nil, fmt.Errorf("方法 %s.%s 第 %d 行已使用标识符 %s，接收者无法改名", typeName, fn.Name.Name, fset.Position(clash.Pos()).Line, want)
+		}
+		for _, id := range refs {
+			edits = append(edits, edit{start: fset.Position(id.Pos()).Offset, end: fset.Position(id.End()).Offset, text: want})
+		}
+		renames = append(renames, ReceiverRename{Struct: typeName, Method: fn.Name.Name, Old: ident.Name, New: want})
+	}
+	if len(edits) == 0 {
+		return src, nil, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := string(src)
+	for _, e := range edits {
+		out = out[:e.start] + e.text + out[e.end:]
+	}
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		return nil, nil, fmt.Errorf("格式化源码失败: %v", err)
+	}
+	return formatted, renames, nil
+}
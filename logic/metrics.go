@@ -0,0 +1,131 @@
+package logic
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBuckets 规则执行耗时直方图的桶上界（秒），与 Prometheus 客户端默认值一致
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics 记录运行指标，并以 Prometheus 文本格式导出
+type Metrics struct {
+	mu             sync.Mutex
+	filesProcessed int64
+	filesModified  int64
+	rulesApplied   int64
+	rulesSkipped   int64
+	errors         map[string]int64
+	buckets        []int64
+	latencyCount   int64
+	latencySum     float64
+}
+
+// NewMetrics 创建空的指标集合
+func NewMetrics() *Metrics {
+	return &Metrics{
+		errors:  make(map[string]int64),
+		buckets: make([]int64, len(latencyBuckets)),
+	}
+}
+
+// ObserveRule 记录一次规则执行
+func (m *Metrics) ObserveRule(d time.Duration, skipped bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if skipped {
+		m.rulesSkipped++
+	} else {
+		m.rulesApplied++
+	}
+
+	seconds := d.Seconds()
+	m.latencyCount++
+	m.latencySum += seconds
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			m.buckets[i]++
+		}
+	}
+}
+
+// ObserveProcessed 记录一次运行中执行了规则的文件数，多条规则处理同一个文件时只计一次
+func (m *Metrics) ObserveProcessed(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filesProcessed += int64(n)
+}
+
+// ObserveFiles 记录一次运行写回的文件数，多条规则修改同一个文件时只计一次
+func (m *Metrics) ObserveFiles(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filesModified += int64(n)
+}
+
+// ObserveError 按错误类型记录一次失败
+func (m *Metrics) ObserveError(kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[kind]++
+}
+
+// WriteTo 以 Prometheus 文本格式输出所有指标
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	write := func(format string, args ...interface{}) error {
+		c, err := fmt.Fprintf(w, format, args...)
+		n += int64(c)
+		return err
+	}
+
+	lines := []struct {
+		name, help string
+		value      int64
+	}{
+		{"astauto_files_processed_total", "Number of distinct files rules were applied to.", m.filesProcessed},
+		{"astauto_files_modified_total", "Number of files rewritten by rules.", m.filesModified},
+		{"astauto_rules_applied_total", "Number of rules applied.", m.rulesApplied},
+		{"astauto_rules_skipped_total", "Number of rules skipped because their condition was not met.", m.rulesSkipped},
+	}
+	for _, line := range lines {
+		if err := write("# HELP %s %s\n# TYPE %s counter\n%s %d\n", line.name, line.help, line.name, line.name, line.value); err != nil {
+			return n, err
+		}
+	}
+
+	if err := write("# HELP astauto_errors_total Number of failures by error type.\n# TYPE astauto_errors_total counter\n"); err != nil {
+		return n, err
+	}
+	kinds := make([]string, 0, len(m.errors))
+	for kind := range m.errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		if err := write("astauto_errors_total{type=%q} %d\n", kind, m.errors[kind]); err != nil {
+			return n, err
+		}
+	}
+
+	const name = "astauto_transform_duration_seconds"
+	if err := write("# HELP %s Time spent applying a rule to a file.\n# TYPE %s histogram\n", name, name); err != nil {
+		return n, err
+	}
+	for i, bound := range latencyBuckets {
+		if err := write("%s_bucket{le=\"%g\"} %d\n", name, bound, m.buckets[i]); err != nil {
+			return n, err
+		}
+	}
+	if err := write("%s_bucket{le=\"+Inf\"} %d\n%s_sum %g\n%s_count %d\n", name, m.latencyCount, name, m.latencySum, name, m.latencyCount); err != nil {
+		return n, err
+	}
+	return n, nil
+}
@@ -0,0 +1,136 @@
+package logic
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ConfigVersion 当前的配置格式版本，不兼容的格式变更需要增加版本并在 migrations 中登记迁移
+const ConfigVersion = 2
+
+// migration 把配置从 from 版本升级到下一版本
+type migration struct {
+	from int
+	// note 说明这一版本的变化，迁移时输出提醒
+	note string
+	// apply 就地修改解码后的配置，返回是否改变了版本号以外的内容
+	apply func(config *Config) bool
+}
+
+// migrations 按版本顺序登记的迁移
+var migrations = []migration{
+	{
+		from: 1,
+		note: "版本 1 是没有 version 的配置，版本 2 起必须声明 version，格式没有其他变化",
+		apply: func(*Config) bool {
+			return false
+		},
+	},
+}
+
+// configVersion 返回配置声明的版本，没有声明时为 1
+func configVersion(config *Config) int {
+	if config.Version == 0 {
+		return 1
+	}
+	return config.Version
+}
+
+// migrateConfig 把解码后的配置升级到当前版本，返回是否改变了版本号以外的内容。
+// 版本高于当前支持的版本时报错，避免用旧的 astauto 错误地解析新格式
+func migrateConfig(config *Config) (bool, error) {
+	version := configVersion(config)
+	if version > ConfigVersion {
+		return false, fmt.Errorf("配置的 version = %d 高于当前 astauto 支持的版本 %d，请升级 astauto", version, ConfigVersion)
+	}
+	if version < 1 {
+		return false, fmt.Errorf("配置的 version = %d 无效", config.Version)
+	}
+	changed := false
+	for _, m := range migrations {
+		if m.from == version {
+			if m.apply(config) {
+				changed = true
+			}
+			version++
+		}
+	}
+	config.Version = version
+	return changed, nil
+}
+
+// upgradeConfig 在加载配置文件时升级旧版本的配置，并提醒执行 migrate-config 改写文件
+func upgradeConfig(filename string, config *Config) error {
+	version := configVersion(config)
+	if _, err := migrateConfig(config); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	if version < ConfigVersion {
+		Warnf("配置 %s 的版本为 %d，已按版本 %d 读取，执行 astauto migrate-config %s 改写文件", filename, version, ConfigVersion, filename)
+	}
+	return nil
+}
+
+// versionLine 匹配 TOML 顶层的 version 设置
+var versionLine = regexp.MustCompile(`(?m)^version\s*=.*$`)
+
+// tableLine 匹配 TOML 中表或表数组的开头
+var tableLine = regexp.MustCompile(`(?m)^\s*\[`)
+
+// MigrateConfigFile 把配置文件改写为当前版本，返回原来的版本。迁移只改变版本号时，
+// TOML 文件只写入或更新 version 一行，保留注释和排版；否则按当前版本重新编码，注释会丢失。
+// 其他格式的文件需要手工迁移
+func MigrateConfigFile(filename string) (int, error) {
+	config, err := decoderFor(filename)(filename)
+	if err != nil {
+		return 0, err
+	}
+	version := configVersion(config)
+	if version == ConfigVersion {
+		return version, nil
+	}
+	changed, err := migrateConfig(config)
+	if err != nil {
+		return version, err
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml", ".json", ".cue", ".hcl":
+		return version, fmt.Errorf("只支持迁移 TOML 配置，请在 %s 中手工设置 version = %d", filename, ConfigVersion)
+	}
+
+	var out []byte
+	if changed {
+		var sb strings.Builder
+		if err := WriteConfig(&sb, config); err != nil {
+			return version, err
+		}
+		out = []byte(sb.String())
+	} else {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return version, fmt.Errorf("无法打开TOML文件: %v", err)
+		}
+		// 只在第一个表之前查找顶层的 version
+		top := len(data)
+		if loc := tableLine.FindIndex(data); loc != nil {
+			top = loc[0]
+		}
+		line := fmt.Sprintf("version = %d", ConfigVersion)
+		if loc := versionLine.FindIndex(data[:top]); loc != nil {
+			out = append(append(append([]byte{}, data[:loc[0]]...), line...), data[loc[1]:]...)
+		} else {
+			out = append([]byte(line+"\n\n"), data...)
+		}
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return version, err
+	}
+	if err := os.WriteFile(filename, out, info.Mode().Perm()); err != nil {
+		return version, fmt.Errorf("写入配置失败: %v", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,73 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"text/template"
+)
+
+// Model 表示一个模型清单：一个模型文件及其中的实体。
+// 文件不存在时按模板生成骨架（包声明和导入），之后的每次运行都按普通规则增量维护实体
+type Model struct {
+	File    string `json:"file" toml:"file"`
+	Package string `json:"package" toml:"package"`
+	// Template 骨架模板文件路径（text/template，数据为 Model），为空时使用内置模板
+	Template string   `json:"template" toml:"template"`
+	Imports  []Import `json:"imports" toml:"imports"`
+	Entities []Struct `json:"entities" toml:"entities"`
+}
+
+// defaultModelTemplate 内置的模型文件骨架模板
+const defaultModelTemplate = `package {{.Package}}
+{{if .Imports}}
+import (
+{{- range .Imports}}
+	{{if .Alias}}{{.Alias}} {{end}}"{{.Path}}"
+{{- end}}
+)
+{{end}}`
+
+// Rule 将模型清单转换为规则，清单中的实体在文件中不存在时会被创建
+func (m *Model) Rule() *Rule {
+	rule := &Rule{
+		ID:      "model:" + m.File,
+		File:    m.File,
+		Imports: m.Imports,
+		Model:   m,
+	}
+	for _, entity := range m.Entities {
+		entity.Create = true
+		rule.Structs = append(rule.Structs, entity)
+	}
+	return rule
+}
+
+// Skeleton 按模板生成模型文件的骨架
+func (m *Model) Skeleton() ([]byte, error) {
+	if m.Package == "" {
+		return nil, fmt.Errorf("模型清单 %s 缺少 package", m.File)
+	}
+	text := defaultModelTemplate
+	if m.Template != "" {
+		data, err := os.ReadFile(m.Template)
+		if err != nil {
+			return nil, fmt.Errorf("读取模型模板失败: %v", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New(m.File).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析模型模板失败: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, m); err != nil {
+		return nil, fmt.Errorf("渲染模型模板失败: %v", err)
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化模型文件骨架失败: %v", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expandFiles 把设置了 files 的规则展开为每个文件一条规则，各自带一份结构体的副本，
+// 与为每个文件重复写一遍规则相同。原规则有 ID 时副本的 ID 为 ID:文件，
+// 依赖原规则 ID 的规则改为依赖全部副本
+func expandFiles(rules []*Rule) ([]*Rule, error) {
+	var out []*Rule
+	renamed := make(map[string][]string)
+	for _, rule := range rules {
+		if len(rule.Files) == 0 {
+			out = append(out, rule)
+			continue
+		}
+		name := rule.ID
+		if name == "" {
+			name = strings.Join(rule.Files, ",")
+		}
+		if rule.File != "" || rule.FileRegex != "" || rule.Package != "" {
+			return nil, fmt.Errorf("规则 %s 设置了 files，不能再设置 file、file_regex 或 package", name)
+		}
+		seen := make(map[string]bool, len(rule.Files))
+		var ids []string
+		for _, file := range rule.Files {
+			if file == "" {
+				return nil, fmt.Errorf("规则 %s 的 files 中有空路径", name)
+			}
+			if seen[file] {
+				return nil, fmt.Errorf("规则 %s 的 files 中 %s 重复", name, file)
+			}
+			seen[file] = true
+			copied := fileRule(rule, file)
+			out = append(out, copied)
+			ids = append(ids, copied.ID)
+		}
+		if rule.ID != "" {
+			renamed[rule.ID] = ids
+		}
+	}
+
+	rewrite := func(refs []string) []string {
+		var deps []string
+		for _, dep := range refs {
+			if ids, ok := renamed[dep]; ok {
+				deps = append(deps, ids...)
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+		return deps
+	}
+	for _, rule := range out {
+		rule.DependsOn = rewrite(rule.DependsOn)
+		rule.Requires = rewrite(rule.Requires)
+	}
+	return out, nil
+}
+
+// fileRule 复制规则并指定目标文件，结构体和字段列表另行复制，使后续按规则展开的字段组、模式和预设互不影响
+func fileRule(rule *Rule, file string) *Rule {
+	copied := *rule
+	copied.File, copied.Files = file, nil
+	if rule.ID != "" {
+		copied.ID = rule.ID + ":" + file
+	}
+	copied.Imports = append([]Import(nil), rule.Imports...)
+	copied.Structs = make([]Struct, len(rule.Structs))
+	for i, st := range rule.Structs {
+		st.Fields = append([]Field(nil), st.Fields...)
+		copied.Structs[i] = st
+	}
+	return &copied
+}
@@ -0,0 +1,155 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// NameClash 结构体中编码后名称相同的一组字段，encoding/json 等会静默忽略这些字段
+type NameClash struct {
+	Struct string
+	// Key 标签键，json 或 yaml
+	Key string
+	// Name 编码后的名称
+	Name   string
+	Fields []string
+	// Line 第二个同名字段所在的行
+	Line int
+}
+
+func (c NameClash) String() string {
+	return fmt.Sprintf("结构体 %s 的字段 %s 的 %s 名称都是 %q", c.Struct, strings.Join(c.Fields, "、"), c.Key, c.Name)
+}
+
+// DuplicateNames 检查源码中指定结构体的字段是否有相同的 json 或 yaml 名称。
+// json 总是检查；yaml 只在结构体中有字段带 yaml 标签时检查，未写名称的字段按 yaml 的规则取小写的字段名。
+// 没有写名称的嵌入字段会展开提升字段，规则较复杂，不参与检查
+func DuplicateNames(src []byte, structs map[string]bool) ([]NameClash, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("解析源码失败: %v", err)
+	}
+
+	var clashes []NameClash
+	WalkStructs(file, func(name string, st *ast.StructType) {
+		if !structs[name] {
+			return
+		}
+		for _, key := range []string{"json", "yaml"} {
+			if key == "yaml" && !anyTag(st, key) {
+				continue
+			}
+			clashes = append(clashes, structClashes(fset, name, st, key)...)
+		}
+	})
+	return clashes, nil
+}
+
+// structClashes 按一种标签键查找结构体中同名的字段
+func structClashes(fset *token.FileSet, name string, st *ast.StructType, key string) []NameClash {
+	fields := make(map[string][]string)
+	lines := make(map[string]int)
+	var order []string
+	for _, field := range st.Fields.List {
+		tag := fieldTag(field)
+		for _, ident := range fieldIdents(field) {
+			encoded, ok := encodedName(ident, field, tag, key)
+			if !ok {
+				continue
+			}
+			if _, seen := fields[encoded]; !seen {
+				order = append(order, encoded)
+			} else if lines[encoded] == 0 {
+				lines[encoded] = fset.Position(ident.Pos()).Line
+			}
+			fields[encoded] = append(fields[encoded], ident.Name)
+		}
+	}
+
+	var clashes []NameClash
+	for _, encoded := range order {
+		if len(fields[encoded]) > 1 {
+			clashes = append(clashes, NameClash{Struct: name, Key: key, Name: encoded, Fields: fields[encoded], Line: lines[encoded]})
+		}
+	}
+	return clashes
+}
+
+// fieldIdents 返回字段的名称；嵌入字段返回其类型名
+func fieldIdents(field *ast.Field) []*ast.Ident {
+	if len(field.Names) > 0 {
+		return field.Names
+	}
+	if name := embeddedIdent(field.Type); name != nil {
+		return []*ast.Ident{name}
+	}
+	return nil
+}
+
+// embeddedIdent 返回嵌入字段类型的名称标识符，如 *pkg.Base 的 Base
+func embeddedIdent(expr ast.Expr) *ast.Ident {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t
+	case *ast.StarExpr:
+		return embeddedIdent(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	case *ast.IndexExpr:
+		return embeddedIdent(t.X)
+	case *ast.IndexListExpr:
+		return embeddedIdent(t.X)
+	}
+	return nil
+}
+
+// fieldTag 返回字段的标签内容（不含引号）
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+// anyTag 判断结构体中是否有字段带指定的标签键
+func anyTag(st *ast.StructType, key string) bool {
+	for _, field := range st.Fields.List {
+		if _, ok := fieldTag(field).Lookup(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// encodedName 返回字段按标签键编码后的名称，字段不参与编码或无法确定名称时返回 false
+func encodedName(ident *ast.Ident, field *ast.Field, tag reflect.StructTag, key string) (string, bool) {
+	value := tag.Get(key)
+	if value == "-" {
+		return "", false
+	}
+	name, opts, _ := strings.Cut(value, ",")
+	if key == "yaml" && strings.Contains(","+opts+",", ",inline,") {
+		return "", false
+	}
+	if name != "" {
+		return name, true
+	}
+	// 没有写名称的嵌入字段会被展开，未导出的字段不参与编码
+	if len(field.Names) == 0 || !ident.IsExported() {
+		return "", false
+	}
+	if key == "yaml" {
+		return strings.ToLower(ident.Name), true
+	}
+	return ident.Name, true
+}
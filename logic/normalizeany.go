@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// NormalizeAny 在源码中把空接口统一写成 to（any 或 interface{}），返回新源码和改写的次数。
+// structs 不为 nil 时只改写这些结构体的字段类型，否则改写整个文件；
+// 预声明标识符 any 被同名声明遮蔽时不改写
+func NormalizeAny(src []byte, to string, structs map[string]bool) ([]byte, int, error) {
+	if to != "any" && to != "interface{}" {
+		return nil, 0, fmt.Errorf("normalize_any 只支持 any 或 interface{}，而不是 %q", to)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析源码失败: %v", err)
+	}
+
+	type edit struct{ start, end int }
+	var edits []edit
+	collect := func(root ast.Node) {
+		ast.Inspect(root, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.InterfaceType:
+				// 只改写没有方法、内部没有注释的空接口
+				if to == "any" && len(n.Methods.List) == 0 && !hasCommentIn(file, n) {
+					edits = append(edits, edit{fset.Position(n.Pos()).Offset, fset.Position(n.End()).Offset})
+				}
+			case *ast.Ident:
+				if to == "interface{}" && n.Name == "any" && n.Obj == nil {
+					edits = append(edits, edit{fset.Position(n.Pos()).Offset, fset.Position(n.End()).Offset})
+				}
+			}
+			return true
+		})
+	}
+	if structs == nil {
+		collect(file)
+	} else {
+		WalkStructs(file, func(name string, st *ast.StructType) {
+			if structs[name] {
+				collect(st)
+			}
+		})
+	}
+	if len(edits) == 0 {
+		return src, 0, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(to), out[e.end:]...)...)
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, 0, fmt.Errorf("格式化源码失败: %v", err)
+	}
+	return formatted, len(edits), nil
+}
+
+// hasCommentIn 判断节点范围内是否有注释
+func hasCommentIn(file *ast.File, n ast.Node) bool {
+	for _, group := range file.Comments {
+		if group.Pos() >= n.Pos() && group.End() <= n.End() {
+			return true
+		}
+	}
+	return false
+}
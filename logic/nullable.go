@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"go/version"
+)
+
+// 可空字段映射为 Go 类型的策略
+const (
+	// NullablePointer 使用指针 *T（默认）
+	NullablePointer = "pointer"
+	// NullableSQL 使用 database/sql 的 NullString、NullInt64 等，其他类型使用 sql.Null[T]
+	NullableSQL = "sql"
+	// NullableOption 使用配置的泛型 Option[T] 类型
+	NullableOption = "option"
+)
+
+// sqlGenericVersion 引入 sql.Null[T] 的 Go 版本
+const sqlGenericVersion = "go1.22"
+
+// sqlNullTypes database/sql 中各基础类型对应的可空类型
+var sqlNullTypes = map[string]string{
+	"string":    "sql.NullString",
+	"int64":     "sql.NullInt64",
+	"int32":     "sql.NullInt32",
+	"int16":     "sql.NullInt16",
+	"byte":      "sql.NullByte",
+	"uint8":     "sql.NullByte",
+	"float64":   "sql.NullFloat64",
+	"bool":      "sql.NullBool",
+	"time.Time": "sql.NullTime",
+}
+
+// ApplyNullable 按策略改写规则中 nullable 字段的类型，并把策略需要的导入加入规则。
+// option 为 option 策略使用的泛型类型及其导入（如 type = "opt.Option"）
+func ApplyNullable(rule *Rule, policy string, option Preset) error {
+	for i := range rule.Structs {
+		st := &rule.Structs[i]
+		for j := range st.Fields {
+			field := &st.Fields[j]
+			if !field.Nullable {
+				continue
+			}
+			typ, imports, err := nullableType(field.Type, policy, option, rule.GoVersion)
+			if err != nil {
+				return fmt.Errorf("字段 %s.%s: %v", st.Name, field.Name, err)
+			}
+			field.Type = typ
+			rule.Imports = mergeImports(imports, rule.Imports)
+		}
+	}
+	return nil
+}
+
+// nullableType 返回类型 typ 按策略对应的可空类型及需要的导入
+func nullableType(typ, policy string, option Preset, goVersion string) (string, []Import, error) {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return "", nil, fmt.Errorf("类型 %q 无效: %v", typ, err)
+	}
+	switch policy {
+	case "", NullablePointer:
+		// 本身可以为 nil 的类型不再加指针
+		switch expr.(type) {
+		case *ast.StarExpr, *ast.ArrayType, *ast.MapType, *ast.InterfaceType, *ast.FuncType, *ast.ChanType:
+			if arr, ok := expr.(*ast.ArrayType); !ok || arr.Len == nil {
+				return typ, nil, nil
+			}
+		}
+		return "*" + typ, nil, nil
+	case NullableSQL:
+		imports := []Import{{Path: "database/sql"}}
+		if null, ok := sqlNullTypes[types.ExprString(expr)]; ok {
+			return null, imports, nil
+		}
+		if goVersion != "" && version.Compare(goVersion, sqlGenericVersion) < 0 {
+			return "", nil, fmt.Errorf("database/sql 没有 %s 对应的可空类型，sql.Null[T] 需要 %s，目标版本为 %s", typ, sqlGenericVersion, goVersion)
+		}
+		return "sql.Null[" + typ + "]", imports, nil
+	case NullableOption:
+		if option.Type == "" {
+			return "", nil, fmt.Errorf("nullable 策略为 option 时必须配置 option_type")
+		}
+		if beforeGenerics(goVersion) {
+			return "", nil, fmt.Errorf("%s[T] 需要 %s，目标版本为 %s", option.Type, genericsVersion, goVersion)
+		}
+		return option.Type + "[" + typ + "]", option.Imports, nil
+	}
+	return "", nil, fmt.Errorf("nullable 策略 %q 无效，只支持 pointer、sql 和 option", policy)
+}
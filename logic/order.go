@@ -0,0 +1,95 @@
+package logic
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SortRules 按 depends_on 和 requires 对规则做拓扑排序。
+// 没有依赖约束的规则保持配置文件中的原始顺序；引用不存在的规则或存在循环依赖时返回错误
+func SortRules(rules []*Rule) ([]*Rule, error) {
+	index := make(map[string]int)
+	for i, rule := range rules {
+		if rule.ID == "" {
+			continue
+		}
+		if _, ok := index[rule.ID]; ok {
+			return nil, fmt.Errorf("规则 ID %s 重复", rule.ID)
+		}
+		index[rule.ID] = i
+	}
+
+	// 统计入度并建立 依赖 -> 被依赖者 的边
+	inDegree := make([]int, len(rules))
+	dependents := make([][]int, len(rules))
+	for i, rule := range rules {
+		for _, dep := range append(append([]string(nil), rule.DependsOn...), rule.Requires...) {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("规则 %s 依赖的规则 %s 不存在", rule.Name(), dep)
+			}
+			inDegree[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	// Kahn 算法，每次取原始顺序最靠前的可执行规则，保证结果稳定
+	sorted := make([]*Rule, 0, len(rules))
+	done := make([]bool, len(rules))
+	for len(sorted) < len(rules) {
+		next := -1
+		for i := range rules {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var cycle []string
+			for i, rule := range rules {
+				if !done[i] {
+					cycle = append(cycle, rule.Name())
+				}
+			}
+			return nil, fmt.Errorf("规则之间存在循环依赖: %s", strings.Join(cycle, ", "))
+		}
+		done[next] = true
+		sorted = append(sorted, rules[next])
+		for _, i := range dependents[next] {
+			inDegree[i]--
+		}
+	}
+	return sorted, nil
+}
+
+// dropDisabled 去掉 enabled = false 的规则，以及 requires 中有被禁用规则的规则，返回留下的规则和被禁用的规则名称。
+// rules 已按依赖排序，被依赖的规则总是先于依赖它的规则处理
+func dropDisabled(rules []*Rule) ([]*Rule, []string) {
+	var kept []*Rule
+	var names []string
+	disabled := make(map[string]bool)
+	for _, rule := range rules {
+		off := rule.Enabled != nil && !*rule.Enabled
+		if off {
+			log.Printf("规则 %s 已禁用", rule.Name())
+		} else {
+			for _, id := range rule.Requires {
+				if disabled[id] {
+					log.Printf("规则 %s 需要的规则 %s 已禁用，跳过", rule.Name(), id)
+					off = true
+					break
+				}
+			}
+		}
+		if !off {
+			kept = append(kept, rule)
+			continue
+		}
+		if rule.ID != "" {
+			disabled[rule.ID] = true
+		}
+		names = append(names, rule.Name())
+	}
+	return kept, names
+}
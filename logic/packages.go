@@ -0,0 +1,147 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// validatePackagePattern 检查规则的 package：必须是以 ./ 开头的相对路径，可以以 /... 结尾
+func validatePackagePattern(pattern string) error {
+	if pattern != "." && !strings.HasPrefix(pattern, "./") {
+		return fmt.Errorf("package %q 必须是以 ./ 开头的相对路径，如 ./internal/models/...", pattern)
+	}
+	return nil
+}
+
+// PackageFiles 返回 root 下与包路径匹配的目录中的 Go 文件（相对 root、以 / 分隔），按路径排序。
+// 包路径以 /... 结尾时包括所有子目录，与 go 命令一样跳过 vendor、testdata 以及以 . 或 _ 开头的目录；
+// tests 为 false 时不包括 _test.go 文件，与 exclude 匹配的目录和文件也不包括，见 Excluded；
+// 生成的文件按 IncludeGenerated 跳过，tags 不为空时跳过构建约束不满足的文件，见 matchBuildTags
+func PackageFiles(root, pattern string, tests bool, exclude, tags []string) ([]string, error) {
+	dir, recursive := strings.TrimSuffix(pattern, "/..."), strings.HasSuffix(pattern, "/...")
+	if pattern == "./..." {
+		dir = "."
+	}
+	start := filepath.Join(root, filepath.FromSlash(dir))
+	var files []string
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if p == start {
+				return nil
+			}
+			name := d.Name()
+			if !recursive || name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || Excluded(exclude, rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") || !tests && strings.HasSuffix(p, "_test.go") || Excluded(exclude, rel, false) || skipGenerated(p) {
+			return nil
+		}
+		if ok, err := matchBuildTags(p, tags); err != nil || !ok {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查找包 %s 中的文件失败: %v", pattern, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// declaredTypes 返回文件中声明的顶层类型名
+func declaredTypes(filename string) (map[string]bool, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	_, src, err = SplitBOM(src)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+			for _, spec := range gen.Specs {
+				names[spec.(*ast.TypeSpec).Name.Name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+// expandPackage 把 package 规则展开为每个声明了其中结构体的文件一条规则，规则只保留该文件中声明的结构体。
+// 结构体按路径的第一段（类型名）查找，所有文件中都没有的结构体记录日志。
+// 规则没有 package 时在 root 下的全部文件中查找，同一结构体在多个文件中声明时报错，避免改错文件；
+// 按平台分文件声明的结构体（如 model_linux.go 和 model_windows.go）可以用 build_tags 选定其中一个
+func expandPackage(rule *Rule, root string) ([]*Rule, error) {
+	pattern, search := rule.Package, rule.Package == ""
+	if search {
+		pattern = "./..."
+	}
+	files, err := PackageFiles(root, pattern, rule.IncludeTests, rule.Exclude, rule.BuildTags)
+	if err != nil {
+		return nil, err
+	}
+	var rules []*Rule
+	found := make(map[string]string)
+	for _, file := range files {
+		types, err := declaredTypes(filepath.Join(root, file))
+		if err != nil {
+			return nil, fmt.Errorf("规则 %s: 解析文件 %s 失败: %v", rule.Name(), file, err)
+		}
+		var structs []Struct
+		for _, st := range rule.Structs {
+			if !types[structTypeName(st.Name)] {
+				continue
+			}
+			if search && found[st.Name] != "" {
+				return nil, fmt.Errorf("规则 %s: 结构体 %s 在 %s 和 %s 中都有声明，需要用 file、package 或 build_tags 指定", rule.Name(), st.Name, found[st.Name], file)
+			}
+			structs = append(structs, st)
+			found[st.Name] = file
+		}
+		if len(structs) == 0 && len(rule.Structs) > 0 {
+			continue
+		}
+		r := *rule
+		r.File, r.Package, r.Structs = file, "", structs
+		rules = append(rules, &r)
+	}
+	for _, st := range rule.Structs {
+		if found[st.Name] == "" {
+			log.Printf("规则 %s: 包 %s 中没有找到结构体 %s", rule.Name(), pattern, st.Name)
+		}
+	}
+	return rules, nil
+}
+
+// structTypeName 返回结构体路径（如 Config.Server、Users[]）中的类型名
+func structTypeName(structPath string) string {
+	if i := strings.IndexAny(structPath, ".["); i >= 0 {
+		return structPath[:i]
+	}
+	return structPath
+}
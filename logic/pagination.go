@@ -0,0 +1,135 @@
+package logic
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// PatternPagination 分页模式：为实体生成 ListXRequest 和 ListXResponse
+const PatternPagination = "pagination"
+
+// paginationBlock 分页结构体中托管块的名称
+const paginationBlock = "pagination"
+
+// EntityRef 指向生成伴随结构体的实体：实体所在的文件（相对 -path）和结构体名称
+type EntityRef struct {
+	File   string
+	Struct string
+}
+
+// paginationRule 生成分页模式的伴随规则：在 pattern_file（默认为实体所在文件）中创建
+// ListXRequest（page、size）和 ListXResponse（total、items []X）。字段放在托管块中，
+// 每次执行都按实体重新生成；伴随文件在其他包中时 items 的类型在执行时按 ResolveEntity 加上包名
+func paginationRule(rule *Rule, st *Struct) (*Rule, error) {
+	if rule.File == "" || IsFileGlob(rule.File) {
+		return nil, fmt.Errorf("结构体 %s 的 pattern %s 需要规则设置具体的 file", st.Name, st.Pattern)
+	}
+	file := st.PatternFile
+	if file == "" {
+		file = rule.File
+	}
+	request := Struct{
+		Name:        "List" + st.Name + "Request",
+		Description: "List" + st.Name + "Request 分页查询 " + st.Name + " 的请求",
+		Create:      true,
+		Block:       paginationBlock,
+		Fields: []Field{
+			{Name: "Page", Type: "int", Tags: `json:"page" form:"page"`, Description: "页码，从 1 开始"},
+			{Name: "Size", Type: "int", Tags: `json:"size" form:"size"`, Description: "每页条数"},
+		},
+	}
+	response := Struct{
+		Name:        "List" + st.Name + "Response",
+		Description: "List" + st.Name + "Response 分页查询 " + st.Name + " 的响应",
+		Create:      true,
+		Block:       paginationBlock,
+		Fields: []Field{
+			{Name: "Total", Type: "int64", Tags: `json:"total"`, Description: "符合条件的总条数"},
+			{Name: "Items", Type: "[]" + st.Name, Tags: `json:"items"`, Description: "当前页的数据"},
+		},
+	}
+	return &Rule{
+		ID:      "pattern:" + PatternPagination + ":" + st.Name,
+		File:    file,
+		Structs: []Struct{request, response},
+		Model:   &Model{File: file},
+		Entity:  &EntityRef{File: rule.File, Struct: st.Name},
+	}, nil
+}
+
+// ResolveEntity 按 root 补全伴随规则中与实体所在包有关的信息：新建文件使用的包名，
+// 以及伴随文件在其他包中时实体类型的包名限定和导入。返回新的规则，原规则不变
+func ResolveEntity(rule *Rule, root string) (*Rule, error) {
+	entity := rule.Entity
+	pkg, err := packageName(filepath.Join(root, entity.File))
+	if err != nil {
+		return nil, fmt.Errorf("规则 %s: 读取实体 %s 所在文件的包名失败: %v", rule.Name(), entity.Struct, err)
+	}
+	r := *rule
+	model := *rule.Model
+	r.Model = &model
+	dir := path.Dir(rule.File)
+	if dir == path.Dir(entity.File) {
+		model.Package = pkg
+		return &r, nil
+	}
+
+	importPath, err := PackageImportPath(root, path.Dir(entity.File))
+	if err != nil {
+		return nil, fmt.Errorf("规则 %s: %v", rule.Name(), err)
+	}
+	model.Package = path.Base(dir)
+	r.Imports = mergeImports([]Import{{Path: importPath}}, rule.Imports)
+	r.Structs = make([]Struct, len(rule.Structs))
+	for i, st := range rule.Structs {
+		st.Fields = append([]Field(nil), st.Fields...)
+		for j, field := range st.Fields {
+			if field.Type == "[]"+entity.Struct {
+				st.Fields[j].Type = "[]" + pkg + "." + entity.Struct
+			}
+		}
+		r.Structs[i] = st
+	}
+	return &r, nil
+}
+
+// packageName 读取 Go 文件的包名
+func packageName(filename string) (string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return "", err
+	}
+	return file.Name.Name, nil
+}
+
+// PackageImportPath 返回 root 下目录 dir（相对 root、以 / 分隔）的导入路径，按所在模块的 go.mod 计算
+func PackageImportPath(root, dir string) (string, error) {
+	abs, err := filepath.Abs(filepath.Join(root, filepath.FromSlash(dir)))
+	if err != nil {
+		return "", err
+	}
+	modRoot := ModuleRoot(abs)
+	data, err := os.ReadFile(filepath.Join(modRoot, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("目录 %s 不在 Go 模块中: %v", dir, err)
+	}
+	module := modfile.ModulePath(data)
+	if module == "" {
+		return "", fmt.Errorf("%s 中没有 module 声明", filepath.Join(modRoot, "go.mod"))
+	}
+	rel, err := filepath.Rel(modRoot, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("目录 %s 不在模块 %s 中", dir, module)
+	}
+	if rel == "." {
+		return module, nil
+	}
+	return module + "/" + filepath.ToSlash(rel), nil
+}
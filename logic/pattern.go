@@ -0,0 +1,313 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 结构体可以安装的模式
+const (
+	// PatternSoftDelete 软删除模式：DeletedAt 字段、IsDeleted 方法，以及可选的查询作用域函数
+	PatternSoftDelete = "soft-delete"
+	// PatternVersionLock 乐观锁模式：Version 字段，构造函数把它初始化为 1，Equal 方法同时比较它
+	PatternVersionLock = "version-lock"
+)
+
+// gormImport 软删除模式依赖的 gorm 包
+var gormImport = Import{Path: "gorm.io/gorm"}
+
+// structPattern 描述一个高层模式由哪些基础操作组成：插入的字段、需要的导入、追加的声明、
+// 对文件中已有声明的改写，以及在 pattern_file 中维护伴随结构体的规则
+type structPattern struct {
+	fields  func(st *Struct) []Field
+	imports []Import
+	source  func(st *Struct) string
+	// patch 改写已有的声明，返回修改过的声明名称
+	patch func(src []byte, st *Struct) ([]byte, []string, error)
+	// companion 生成维护伴随结构体的规则
+	companion func(rule *Rule, st *Struct) (*Rule, error)
+	// scoped 模式支持 pattern_scope
+	scoped bool
+}
+
+// patterns 结构体的 pattern 可以使用的模式
+var patterns = map[string]structPattern{
+	PatternSoftDelete: {
+		fields: func(st *Struct) []Field {
+			return []Field{{
+				Name:        "DeletedAt",
+				Type:        "gorm.DeletedAt",
+				Tags:        `gorm:"index" json:"deleted_at"`,
+				Description: "删除时间，不为空时记录已被软删除",
+			}}
+		},
+		imports: []Import{gormImport},
+		source:  softDeleteSource,
+		scoped:  true,
+	},
+	PatternVersionLock: {
+		fields: func(st *Struct) []Field {
+			return []Field{{
+				Name:        "Version",
+				Type:        "int64",
+				Tags:        `gorm:"default:1" json:"version"`,
+				Description: "乐观锁版本号，每次更新时加一，更新条件中带上旧值",
+				Default:     "1",
+			}}
+		},
+		patch: versionLockPatch,
+	},
+	PatternPagination: {
+		companion: paginationRule,
+	},
+}
+
+// patternNames 返回支持的模式名称，用于错误信息
+func patternNames() string {
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "、")
+}
+
+// applyPatterns 把规则中结构体的 pattern 展开为字段和导入，结构体自己已有的同名字段保持不变，
+// 返回模式维护伴随结构体的规则；模式的方法和函数由 PatternSource 生成，执行时追加到文件中
+func applyPatterns(rule *Rule) ([]*Rule, error) {
+	var companions []*Rule
+	for i := range rule.Structs {
+		st := &rule.Structs[i]
+		if st.Pattern == "" {
+			if st.PatternScope || st.PatternFile != "" {
+				return nil, fmt.Errorf("结构体 %s 设置了 pattern_scope 或 pattern_file 但没有设置 pattern", st.Name)
+			}
+			continue
+		}
+		pattern, ok := patterns[st.Pattern]
+		if !ok {
+			return nil, fmt.Errorf("结构体 %s 的 pattern %q 不存在，支持: %s", st.Name, st.Pattern, patternNames())
+		}
+		if st.PatternScope && !pattern.scoped {
+			return nil, fmt.Errorf("结构体 %s 的 pattern %s 不支持 pattern_scope", st.Name, st.Pattern)
+		}
+		if st.PatternFile != "" && pattern.companion == nil {
+			return nil, fmt.Errorf("结构体 %s 的 pattern %s 不支持 pattern_file", st.Name, st.Pattern)
+		}
+		if structTypeName(st.Name) != st.Name {
+			return nil, fmt.Errorf("内联结构体 %s 不能设置 pattern", st.Name)
+		}
+		if pattern.fields != nil {
+			names := make(map[string]bool, len(st.Fields))
+			for _, field := range st.Fields {
+				names[field.Name] = true
+			}
+			fields := append([]Field(nil), st.Fields...)
+			for _, field := range pattern.fields(st) {
+				if !names[field.Name] {
+					fields = append(fields, field)
+				}
+			}
+			st.Fields = fields
+		}
+		rule.Imports = mergeImports(pattern.imports, rule.Imports)
+		if pattern.companion != nil {
+			companion, err := pattern.companion(rule, st)
+			if err != nil {
+				return nil, err
+			}
+			companions = append(companions, companion)
+		}
+	}
+	return companions, nil
+}
+
+// PatternSource 生成结构体 pattern 需要的方法和函数源码，没有设置 pattern 或模式不需要时返回空字符串
+func PatternSource(st Struct) string {
+	pattern, ok := patterns[st.Pattern]
+	if !ok || pattern.source == nil {
+		return ""
+	}
+	return pattern.source(&st)
+}
+
+// PatchPattern 按结构体的 pattern 改写文件中已有的声明（如构造函数），返回改写后的源码和修改过的声明名称
+func PatchPattern(src []byte, st Struct) ([]byte, []string, error) {
+	pattern, ok := patterns[st.Pattern]
+	if !ok || pattern.patch == nil {
+		return src, nil, nil
+	}
+	return pattern.patch(src, &st)
+}
+
+// softDeleteSource 生成软删除模式的 IsDeleted 方法，pattern_scope 为 true 时
+// 再生成只查询已删除记录的 gorm 作用域函数
+func softDeleteSource(st *Struct) string {
+	recv := st.Receiver
+	if recv == "" {
+		recv = string(unicode.ToLower([]rune(st.Name)[0]))
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// IsDeleted 返回 %s 是否已被软删除\n", st.Name)
+	fmt.Fprintf(&sb, "func (%s *%s) IsDeleted() bool {\n\treturn %s.DeletedAt.Valid\n}\n", recv, st.Name, recv)
+	if st.PatternScope {
+		scope := "OnlyDeleted" + st.Name
+		fmt.Fprintf(&sb, "\n// %s 只查询已软删除的 %s，用法为 db.Scopes(%s)\n", scope, st.Name, scope)
+		fmt.Fprintf(&sb, "func %s(db *gorm.DB) *gorm.DB {\n\treturn db.Unscoped().Where(\"deleted_at IS NOT NULL\")\n}\n", scope)
+	}
+	return sb.String()
+}
+
+// versionLockPatch 让已有的构造函数把 Version 初始化为 1，让逐字段比较的 Equal 方法同时比较 Version
+func versionLockPatch(src []byte, st *Struct) ([]byte, []string, error) {
+	var patched []string
+	src, ok, err := ensureLiteralField(src, ConstructorName(st.Name), st.Name, "Version", "1")
+	if err != nil {
+		return nil, nil, err
+	}
+	if ok {
+		patched = append(patched, ConstructorName(st.Name))
+	}
+	src, ok, err = ensureEqualField(src, st.Name, "Version")
+	if err != nil {
+		return nil, nil, err
+	}
+	if ok {
+		patched = append(patched, st.Name+".Equal")
+	}
+	return src, patched, nil
+}
+
+// ensureLiteralField 在函数 funcName 中 structName 的键值形式字面量里补上 field: value，
+// 已有该键或字面量按位置初始化时不修改，返回是否修改
+func ensureLiteralField(src []byte, funcName, structName, field, value string) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, false, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+	var inserts []int
+	var texts []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Name.Name != funcName || fn.Body == nil {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok || typeName(lit.Type) != structName {
+				return true
+			}
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					return false
+				}
+				if key, ok := kv.Key.(*ast.Ident); ok && key.Name == field {
+					return false
+				}
+			}
+			inserts = append(inserts, fset.Position(lit.Rbrace).Offset)
+			switch last := len(lit.Elts) - 1; {
+			case last < 0:
+				texts = append(texts, field+": "+value)
+			case fset.Position(lit.Elts[last].End()).Line == fset.Position(lit.Rbrace).Line:
+				// 单行字面量
+				texts = append(texts, ", "+field+": "+value)
+			default:
+				texts = append(texts, field+": "+value+",\n")
+			}
+			return false
+		})
+	}
+	if len(inserts) == 0 {
+		return src, false, nil
+	}
+	out := string(src)
+	for i := len(inserts) - 1; i >= 0; i-- {
+		out = out[:inserts[i]] + texts[i] + out[inserts[i]:]
+	}
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		return nil, false, err
+	}
+	return formatted, true, nil
+}
+
+// ensureEqualField 在结构体的 Equal 方法中补上对字段的比较。只处理函数体为 return a.X == b.X && ... 的方法，
+// 已比较该字段时不修改；其他写法无法安全改写，记录日志提示手动处理，返回是否修改
+func ensureEqualField(src []byte, structName, field string) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, false, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Name.Name != "Equal" || fn.Body == nil {
+			continue
+		}
+		if receiverTypeName(fn.Recv.List[0].Type) != structName {
+			continue
+		}
+		recv, other := "", ""
+		if names := fn.Recv.List[0].Names; len(names) == 1 {
+			recv = names[0].Name
+		}
+		if params := fn.Type.Params.List; len(params) == 1 && len(params[0].Names) == 1 {
+			other = params[0].Names[0].Name
+		}
+		var ret *ast.ReturnStmt
+		if len(fn.Body.List) == 1 {
+			ret, _ = fn.Body.List[0].(*ast.ReturnStmt)
+		}
+		if recv == "" || other == "" || ret == nil || len(ret.Results) != 1 || !isFieldComparison(ret.Results[0]) {
+			Warnf("%s.Equal 不是逐字段比较的形式，需要手动加上对 %s 的比较", structName, field)
+			return src, false, nil
+		}
+		if comparesField(ret.Results[0], field) {
+			return src, false, nil
+		}
+		at := fset.Position(ret.Results[0].End()).Offset
+		text := fmt.Sprintf(" &&\n%s.%s == %s.%s", recv, field, other, field)
+		out := string(src[:at]) + text + string(src[at:])
+		formatted, err := format.Source([]byte(out))
+		if err != nil {
+			return nil, false, err
+		}
+		return formatted, true, nil
+	}
+	return src, false, nil
+}
+
+// comparesField 判断逐字段比较的表达式中是否已比较了该字段
+func comparesField(expr ast.Expr, field string) bool {
+	bin := expr.(*ast.BinaryExpr)
+	if bin.Op == token.LAND {
+		return comparesField(bin.X, field) || comparesField(bin.Y, field)
+	}
+	return bin.X.(*ast.SelectorExpr).Sel.Name == field || bin.Y.(*ast.SelectorExpr).Sel.Name == field
+}
+
+// isFieldComparison 判断表达式是否为 a.X == b.X 或以 && 连接的这类比较
+func isFieldComparison(expr ast.Expr) bool {
+	bin, ok := expr.(*ast.BinaryExpr)
+	if !ok {
+		return false
+	}
+	switch bin.Op {
+	case token.LAND:
+		return isFieldComparison(bin.X) && isFieldComparison(bin.Y)
+	case token.EQL:
+		_, x := bin.X.(*ast.SelectorExpr)
+		_, y := bin.Y.(*ast.SelectorExpr)
+		return x && y
+	}
+	return false
+}
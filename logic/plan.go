@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// 计划中的操作类型
+const (
+	PlanAdd        = "add"
+	PlanRemove     = "remove"
+	PlanImport     = "import"
+	PlanDecl       = "decl"
+	PlanRewrite    = "rewrite"
+	PlanRename     = "rename"
+	PlanDeprecate  = "deprecate"
+	PlanWiden      = "widen"
+	PlanHeader     = "header"
+	PlanSkipExists = "skip-exists"
+	PlanConflict   = "conflict"
+	PlanMissing    = "missing"
+	PlanSkipWhen   = "skip-when"
+	PlanError      = "error"
+	PlanNoop       = "noop"
+)
+
+// PlanOp 表示 plan 报告中的一项操作：某条规则在某个文件上将要执行或跳过的动作
+type PlanOp struct {
+	Rule   string `json:"rule"`
+	File   string `json:"file"`
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// planActions 汇总时各操作类型的输出顺序
+var planActions = []string{PlanAdd, PlanRemove, PlanImport, PlanDecl, PlanRewrite, PlanRename, PlanDeprecate, PlanWiden, PlanHeader, PlanSkipExists, PlanConflict, PlanMissing, PlanSkipWhen, PlanError, PlanNoop}
+
+// WritePlan 以表格形式输出计划，每行一项操作，末尾附各操作类型的数量
+func WritePlan(w io.Writer, ops []PlanOp) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tFILE\tACTION\tTARGET\tDETAIL")
+	counts := make(map[string]int)
+	for _, op := range ops {
+		counts[op.Action]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", op.Rule, op.File, op.Action, op.Target, op.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	summary := "\n计划:"
+	for _, action := range planActions {
+		summary += fmt.Sprintf(" %s %d", action, counts[action])
+	}
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
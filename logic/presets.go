@@ -0,0 +1,116 @@
+package logic
+
+import (
+	"fmt"
+	"go/version"
+	"regexp"
+)
+
+// Preset 类型预设：把逻辑类型映射为具体的 Go 类型、需要的导入和默认标签。
+// 字段的 type 中以 @名称 引用预设，可以带修饰，如 "@uuid"、"[]@uuid"、"map[string]@decimal"
+type Preset struct {
+	// Type 具体的 Go 类型，如 *time.Time
+	Type string `json:"type" toml:"type"`
+	// Imports 类型依赖的导入，添加到引用它的规则中
+	Imports []Import `json:"imports" toml:"imports"`
+	// Tags 默认标签，字段自己写了的键优先
+	Tags FieldTags `json:"tags" toml:"tags"`
+}
+
+// BuiltinPresets 内置的类型预设，配置中的同名预设会覆盖它们。与目标 Go 版本有关的内置预设见 versionedPresets
+var BuiltinPresets = map[string]Preset{
+	"timestamp": {Type: "*time.Time", Imports: []Import{{Path: "time"}}},
+	"uuid":      {Type: "uuid.UUID", Imports: []Import{{Path: "github.com/google/uuid"}}},
+	"decimal":   {Type: "decimal.Decimal", Imports: []Import{{Path: "github.com/shopspring/decimal"}}},
+	"money":     {Type: "decimal.Decimal", Imports: []Import{{Path: "github.com/shopspring/decimal"}}},
+}
+
+// versionedPreset 随目标 Go 版本选择的内置预设
+type versionedPreset struct {
+	// Since 可以使用该预设的最低 Go 版本，为空表示不限制
+	Since  string
+	Preset Preset
+}
+
+// versionedPresets 随目标 Go 版本变化的内置预设，按从新到旧排列，取目标版本可用的第一项；
+// 没有设置目标版本时使用最新的一项
+var versionedPresets = map[string][]versionedPreset{
+	"logger": {
+		{Since: "go1.21", Preset: Preset{Type: "*slog.Logger", Imports: []Import{{Path: "log/slog"}}}},
+		{Preset: Preset{Type: "*log.Logger", Imports: []Import{{Path: "log"}}}},
+	},
+}
+
+// builtinPreset 返回目标版本下的内置预设
+func builtinPreset(name, goVersion string) (Preset, bool) {
+	if preset, ok := BuiltinPresets[name]; ok {
+		return preset, true
+	}
+	for _, v := range versionedPresets[name] {
+		if v.Since == "" || goVersion == "" || version.Compare(goVersion, v.Since) >= 0 {
+			return v.Preset, true
+		}
+	}
+	return Preset{}, false
+}
+
+// presetRef 匹配类型中对预设的引用
+var presetRef = regexp.MustCompile(`@([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ApplyPresets 展开规则中字段类型里的预设引用：替换为具体类型，把预设的导入加入规则，
+// 把预设的标签合并到字段标签中（字段自己写了的键优先）
+func ApplyPresets(rule *Rule, presets map[string]Preset) error {
+	for i := range rule.Structs {
+		st := &rule.Structs[i]
+		for j := range st.Fields {
+			field := &st.Fields[j]
+			var used []Preset
+			var missing string
+			field.Type = presetRef.ReplaceAllStringFunc(field.Type, func(ref string) string {
+				preset, ok := presets[ref[1:]]
+				if !ok {
+					preset, ok = builtinPreset(ref[1:], rule.GoVersion)
+				}
+				if !ok {
+					missing = ref
+					return ref
+				}
+				used = append(used, preset)
+				return preset.Type
+			})
+			if missing != "" {
+				return fmt.Errorf("字段 %s.%s 引用了未定义的类型预设 %s", st.Name, field.Name, missing)
+			}
+			for _, preset := range used {
+				rule.Imports = mergeImports(preset.Imports, rule.Imports)
+				tags, err := mergeTags(field.Tags, preset.Tags)
+				if err != nil {
+					return fmt.Errorf("字段 %s.%s: %v", st.Name, field.Name, err)
+				}
+				field.Tags = tags
+			}
+		}
+	}
+	return nil
+}
+
+// mergeTags 把 defaults 中 tags 没有的键追加到 tags 之后
+func mergeTags(tags, defaults FieldTags) (FieldTags, error) {
+	if defaults == "" {
+		return tags, nil
+	}
+	pairs, err := ParseTag(string(tags))
+	if err != nil {
+		return "", fmt.Errorf("标签无效: %v", err)
+	}
+	extra, err := ParseTag(string(defaults))
+	if err != nil {
+		return "", fmt.Errorf("预设的标签无效: %v", err)
+	}
+	for _, pair := range extra {
+		if !hasTagPair(pairs, pair.Key) {
+			pairs = append(pairs, pair)
+		}
+	}
+	return FieldTags(FormatTag(pairs)), nil
+}
@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// provenanceMarker 来源注释的第一行，用于找到并更新已有的注释
+const provenanceMarker = "// astauto:provenance"
+
+// Provenance 结构体配置文件末尾的来源注释，说明文件由哪些规则维护、应当修改哪份配置。
+// 不记录时间，重复执行时注释保持不变
+type Provenance struct {
+	// Rules 列出以该文件为目标的全部规则
+	Rules bool `json:"rules" toml:"rules"`
+	// ConfigHash 记录生效配置的哈希，配置变化时注释随之更新
+	ConfigHash bool `json:"config_hash" toml:"config_hash"`
+	// Scope 添加范围，与 header.scope 相同：touched（默认）或 matched
+	Scope string `json:"scope" toml:"scope"`
+}
+
+// Enabled 返回是否需要添加来源注释
+func (p Provenance) Enabled() bool {
+	return p.Rules || p.ConfigHash
+}
+
+// Validate 检查来源注释配置
+func (p Provenance) Validate() error {
+	switch p.Scope {
+	case "", HeaderTouched, HeaderMatched:
+		return nil
+	}
+	return fmt.Errorf("provenance.scope %q 无效，只支持 touched 和 matched", p.Scope)
+}
+
+// Comment 返回来源注释的源码，不含末尾换行；rules 为维护文件的规则，configFile 为配置文件路径，
+// hash 为 ConfigHash 的结果
+func (p Provenance) Comment(rules []string, configFile, hash string) string {
+	lines := []string{provenanceMarker}
+	if p.Rules {
+		lines = append(lines, "// 维护规则: "+strings.Join(rules, ", "))
+	}
+	config := "// 配置: " + configFile
+	if p.ConfigHash {
+		config += " sha256:" + hash
+	}
+	return strings.Join(append(lines, config), "\n")
+}
+
+// ConfigHash 返回生效配置的哈希（前 12 位），与配置文件的注释和排版无关
+func ConfigHash(config *Config) (string, error) {
+	var sb strings.Builder
+	if err := WriteConfig(&sb, config); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])[:12], nil
+}
+
+// EnsureFooter 确保文件末尾是给出的来源注释：删除文件中已有的来源注释，再追加到文件末尾并空一行，
+// 使注释在追加或重新生成声明之后仍然位于末尾；返回是否修改了文件
+func EnsureFooter(src []byte, comment string) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, false, fmt.Errorf("解析文件失败: %v", err)
+	}
+	var groups []*ast.CommentGroup
+	for _, group := range file.Comments {
+		if group.List[0].Text == provenanceMarker {
+			groups = append(groups, group)
+		}
+	}
+	// 唯一的来源注释已经在末尾且内容相同时不修改
+	if len(groups) == 1 {
+		start, end := fset.Position(groups[0].Pos()).Offset, fset.Position(groups[0].End()).Offset
+		if string(src[start:end]) == comment && len(bytes.TrimSpace(src[end:])) == 0 {
+			return src, false, nil
+		}
+	}
+
+	out := append([]byte{}, src...)
+	for i := len(groups) - 1; i >= 0; i-- {
+		start, end := fset.Position(groups[i].Pos()).Offset, fset.Position(groups[i].End()).Offset
+		head := bytes.TrimRight(out[:start], "\n")
+		tail := bytes.TrimLeft(out[end:], "\n")
+		joined := append([]byte{}, head...)
+		if len(tail) > 0 {
+			joined = append(joined, "\n\n"...)
+			joined = append(joined, tail...)
+		}
+		out = joined
+	}
+	out = bytes.TrimRight(out, "\n")
+	out = append(out, "\n\n"...)
+	out = append(out, comment...)
+	out = append(out, '\n')
+	return out, !bytes.Equal(out, src), nil
+}
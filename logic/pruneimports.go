@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// PruneImports 删除修改前（before）被引用、修改后（after）不再被引用的导入，返回新的源码和删除的导入路径。
+// 用于删除字段或方法之后，避免留下无法编译的未使用导入。导入按文件中使用的名称（别名或默认包名）
+// 匹配 pkg.X 形式的选择器；空白导入、点导入和 "C" 不处理，修改前就没有被引用的导入保持不变
+func PruneImports(before, after []byte) ([]byte, []string, error) {
+	beforeFile, err := parser.ParseFile(token.NewFileSet(), "", before, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析源码失败: %v", err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", after, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析源码失败: %v", err)
+	}
+	usedBefore, usedAfter := qualifiers(beforeFile), qualifiers(file)
+
+	var pruned []string
+	for _, imp := range file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || importPath == "C" {
+			continue
+		}
+		alias := ""
+		if imp.Name != nil {
+			alias = imp.Name.Name
+		}
+		if alias == "_" || alias == "." {
+			continue
+		}
+		name := importName(alias, importPath)
+		if usedBefore[name] && !usedAfter[name] {
+			pruned = append(pruned, importPath)
+		}
+	}
+	if len(pruned) == 0 {
+		return after, nil, nil
+	}
+	for _, importPath := range pruned {
+		for _, imp := range file.Imports {
+			if path, _ := strconv.Unquote(imp.Path.Value); path == importPath {
+				alias := ""
+				if imp.Name != nil {
+					alias = imp.Name.Name
+				}
+				astutil.DeleteNamedImport(fset, file, alias, importPath)
+				break
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, nil, fmt.Errorf("格式化源码失败: %v", err)
+	}
+	return buf.Bytes(), pruned, nil
+}
+
+// qualifiers 返回文件中作为选择器 X.Sel 左侧出现的标识符
+func qualifiers(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				names[x.Name] = true
+			}
+		}
+		return true
+	})
+	return names
+}
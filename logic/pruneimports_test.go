@@ -0,0 +1,209 @@
+package logic
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemoveFieldsPrunesImports(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		removes map[string][]string
+		pruned  []string
+		imports []string
+	}{
+		{
+			name: "last user of import",
+			src: `package m
+
+import "time"
+
+type User struct {
+	ID        int
+	CreatedAt time.Time
+}
+`,
+			removes: map[string][]string{"User": {"CreatedAt"}},
+			pruned:  []string{"time"},
+		},
+		{
+			name: "import still used elsewhere",
+			src: `package m
+
+import "time"
+
+type User struct {
+	ID        int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+`,
+			removes: map[string][]string{"User": {"CreatedAt"}},
+			imports: []string{`"time"`},
+		},
+		{
+			name: "aliased import",
+			src: `package m
+
+import (
+	"fmt"
+
+	uuidpkg "github.com/google/uuid"
+)
+
+type User struct {
+	ID uuidpkg.UUID
+}
+
+func (u User) String() string { return fmt.Sprint(u.ID) }
+`,
+			removes: map[string][]string{"User": {"ID"}},
+			pruned:  []string{"github.com/google/uuid"},
+			imports: []string{`"fmt"`},
+		},
+		{
+			name: "blank and unused imports are kept",
+			src: `package m
+
+import (
+	_ "embed"
+	"time"
+)
+
+type User struct {
+	ID   int
+	Name string
+}
+`,
+			removes: map[string][]string{"User": {"Name"}},
+			imports: []string{`_ "embed"`, `"time"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed, err := RemoveFields([]byte(tt.src), tt.removes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, pruned, err := PruneImports([]byte(tt.src), removed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pruned, tt.pruned) {
+				t.Errorf("pruned = %v, want %v", pruned, tt.pruned)
+			}
+			for _, imp := range tt.imports {
+				if !strings.Contains(string(out), imp) {
+					t.Errorf("import %s missing from:\n%s", imp, out)
+				}
+			}
+			for _, path := range tt.pruned {
+				if strings.Contains(string(out), `"`+path+`"`) {
+					t.Errorf("import %q not pruned:\n%s", path, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveMethodsPrunesImports(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		methods map[string]bool
+		pruned  []string
+		imports []string
+	}{
+		{
+			name: "String was the only user of fmt",
+			src: `package m
+
+import "fmt"
+
+type User struct {
+	ID int
+}
+
+func (u User) String() string { return fmt.Sprintf("user %d", u.ID) }
+`,
+			methods: map[string]bool{"User.String": true},
+			pruned:  []string{"fmt"},
+		},
+		{
+			name: "fmt still used by another method",
+			src: `package m
+
+import "fmt"
+
+type User struct {
+	ID int
+}
+
+func (u User) String() string { return fmt.Sprintf("user %d", u.ID) }
+
+func (u User) GoString() string { return fmt.Sprintf("User{%d}", u.ID) }
+`,
+			methods: map[string]bool{"User.String": true},
+			imports: []string{`"fmt"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed, err := RemoveMethods([]byte(tt.src), tt.methods)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, pruned, err := PruneImports([]byte(tt.src), removed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pruned, tt.pruned) {
+				t.Errorf("pruned = %v, want %v", pruned, tt.pruned)
+			}
+			for _, imp := range tt.imports {
+				if !strings.Contains(string(out), imp) {
+					t.Errorf("import %s missing from:\n%s", imp, out)
+				}
+			}
+			for _, path := range tt.pruned {
+				if strings.Contains(string(out), `"`+path+`"`) {
+					t.Errorf("import %q not pruned:\n%s", path, out)
+				}
+			}
+		})
+	}
+}
+
+func TestPruneImportsAfterInsert(t *testing.T) {
+	src := `package m
+
+import "time"
+
+type User struct {
+	ID        int
+	CreatedAt time.Time
+}
+`
+	removed, err := RemoveFields([]byte(src), map[string][]string{"User": {"CreatedAt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inserts := make(FieldInserts)
+	inserts.Add("User", "", "UpdatedAt time.Time")
+	inserted, err := InsertFields(removed, inserts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, pruned, err := PruneImports([]byte(src), inserted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) > 0 {
+		t.Errorf("pruned = %v, want none", pruned)
+	}
+	if !strings.Contains(string(out), `"time"`) || !strings.Contains(string(out), "UpdatedAt time.Time") {
+		t.Errorf("time import or UpdatedAt missing from:\n%s", out)
+	}
+}
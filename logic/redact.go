@@ -0,0 +1,131 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RedactMask 脱敏后非空字符串字段的值
+const RedactMask = "***"
+
+// sensitiveTag 标记敏感字段的标签键，值为 true 的字段在 Redacted 中脱敏
+const sensitiveTag = "sensitive"
+
+// RedactSpec 描述需要生成 Redacted 的结构体
+type RedactSpec struct {
+	// Fields 配置中列出的敏感字段，与带有 sensitive:"true" 标签的字段一起脱敏
+	Fields []string
+	// Receiver 方法接收者的名称，为空时使用类型名的首字母小写
+	Receiver string
+	// ValueReceiver 使用值接收者，见 Codegen.Receiver
+	ValueReceiver bool
+}
+
+// redactLocals 生成的方法中使用的局部变量名，接收者不能与之重名
+var redactLocals = map[string]bool{"redacted": true, "zero": true}
+
+// Redact 按源码中结构体当前的字段生成 Redacted 方法的源码：结构体名 -> 配置。
+// 敏感字段为带有 sensitive:"true" 标签的字段和配置中列出的字段，配置中列出但结构体中没有的字段记录日志
+func Redact(src []byte, specs map[string]RedactSpec) (string, error) {
+	if len(specs) == 0 {
+		return "", nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("解析源码失败: %v", err)
+	}
+
+	var names []string
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		if structType := topLevelStruct(file, name); structType != nil {
+			sb.WriteString(redactSource(name, structType, specs[name]))
+		}
+	}
+	return sb.String(), nil
+}
+
+// redactSource 生成单个结构体的 Redacted：返回副本，非空的字符串字段改为 RedactMask，其他类型的字段置为零值
+func redactSource(name string, structType *ast.StructType, spec RedactSpec) string {
+	recv := spec.Receiver
+	if recv == "" {
+		recv = string(unicode.ToLower([]rune(name)[0]))
+	}
+	if redactLocals[recv] {
+		recv = "s"
+	}
+
+	listed := make(map[string]bool, len(spec.Fields))
+	for _, field := range spec.Fields {
+		listed[field] = true
+	}
+	type sensitive struct {
+		name string
+		text bool
+	}
+	var fields []sensitive
+	for _, field := range structType.Fields.List {
+		tagged := false
+		if field.Tag != nil {
+			if tag, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tagged = reflect.StructTag(tag).Get(sensitiveTag) == "true"
+			}
+		}
+		ident, text := field.Type.(*ast.Ident)
+		text = text && ident.Name == "string"
+		for _, ident := range field.Names {
+			if tagged || listed[ident.Name] {
+				fields = append(fields, sensitive{name: ident.Name, text: text})
+				delete(listed, ident.Name)
+			}
+		}
+	}
+	for _, field := range spec.Fields {
+		if listed[field] {
+			Warnf("结构体 %s 没有敏感字段 %s，Redacted 中跳过", name, field)
+		}
+	}
+
+	var sb strings.Builder
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(&sb, "\n// Redacted 返回 %s 的副本，目前没有敏感字段，由 astauto 按字段生成\n", name)
+	} else {
+		fmt.Fprintf(&sb, "\n// Redacted 返回 %s 的副本，敏感字段 %s 已脱敏，用于记录日志，由 astauto 按字段生成\n", name, strings.Join(names, "、"))
+	}
+	if spec.ValueReceiver {
+		fmt.Fprintf(&sb, "func (%s %s) Redacted() %s {\n\tredacted := %s\n", recv, name, name, recv)
+	} else {
+		fmt.Fprintf(&sb, "func (%s *%s) Redacted() %s {\n\tredacted := *%s\n", recv, name, name, recv)
+	}
+	for _, f := range fields {
+		if !f.text {
+			fmt.Fprintf(&sb, "\tvar zero %s\n", name)
+			break
+		}
+	}
+	for _, f := range fields {
+		if f.text {
+			fmt.Fprintf(&sb, "\tif redacted.%s != \"\" {\n\t\tredacted.%s = %s\n\t}\n", f.name, f.name, strconv.Quote(RedactMask))
+		} else {
+			fmt.Fprintf(&sb, "\tredacted.%s = zero.%s\n", f.name, f.name)
+		}
+	}
+	sb.WriteString("\treturn redacted\n}\n")
+	return sb.String()
+}
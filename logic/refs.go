@@ -0,0 +1,266 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// FieldRef 表示源码中对某个字段的一处引用
+type FieldRef struct {
+	File string
+	Line int
+	Col  int
+	// Expr 引用处的源码
+	Expr string
+	// Rewritable 是否为可以整体替换的简单读取（选择器表达式，且不是赋值目标或取地址）
+	Rewritable bool
+
+	start, end int
+	recv       string
+}
+
+// String 返回 文件:行:列 形式的位置和引用源码
+func (r FieldRef) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", r.File, r.Line, r.Col, r.Expr)
+}
+
+// HasField 判断结构体中是否有指定名称的字段
+func HasField(structType *ast.StructType, name string) bool {
+	for _, field := range structType.Fields.List {
+		for _, ident := range field.Names {
+			if ident.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RemoveFields 在格式化后的源码中删除结构体字段：结构体名 -> 字段名列表。
+// 字段连同文档注释和行尾注释所在的行一起删除；一行声明多个字段名时只删除对应的名字
+func RemoveFields(src []byte, removes map[string][]string) ([]byte, error) {
+	if len(removes) == 0 {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+	tokFile := fset.File(file.Pos())
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	WalkStructs(file, func(name string, structType *ast.StructType) {
+		names, ok := removes[name]
+		if !ok {
+			return
+		}
+		remove := make(map[string]bool, len(names))
+		for _, name := range names {
+			remove[name] = true
+		}
+		for _, field := range structType.Fields.List {
+			var keep []string
+			for _, ident := range field.Names {
+				if !remove[ident.Name] {
+					keep = append(keep, ident.Name)
+				}
+			}
+			if len(keep) == len(field.Names) {
+				continue
+			}
+			if len(keep) > 0 {
+				// 只改写字段名列表
+				edits = append(edits, edit{
+					start: tokFile.Offset(field.Names[0].Pos()),
+					end:   tokFile.Offset(field.Names[len(field.Names)-1].End()),
+					text:  strings.Join(keep, ", "),
+				})
+				continue
+			}
+			start, end := field.Pos(), field.End()
+			if field.Doc != nil {
+				start = field.Doc.Pos()
+			}
+			if field.Comment != nil {
+				end = field.Comment.End()
+			}
+			startOffset := tokFile.Offset(tokFile.LineStart(tokFile.Line(start)))
+			endOffset := tokFile.Offset(end)
+			if i := bytes.IndexByte(src[endOffset:], '\n'); i >= 0 {
+				endOffset += i + 1
+			}
+			edits = append(edits, edit{start: startOffset, end: endOffset})
+		}
+	})
+	if len(edits) == 0 {
+		return src, nil
+	}
+
+	// 从后往前修改，避免偏移量失效
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := string(src)
+	for _, e := range edits {
+		out = out[:e.start] + e.text + out[e.end:]
+	}
+	return format.Source([]byte(out))
+}
+
+// FindFieldRefs 查找源码中对结构体字段的引用：所有 x.Field 选择器表达式，
+// 以及结构体字面量 StructName{Field: ...} 中的键。没有类型信息，同名字段的其他结构体也会被列出
+func FindFieldRefs(filename string, src []byte, structName, fieldName string) ([]FieldRef, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析文件 %s 失败: %v", filename, err)
+	}
+
+	var refs []FieldRef
+	newRef := func(node ast.Node, rewritable bool) FieldRef {
+		pos := fset.Position(node.Pos())
+		end := fset.Position(node.End()).Offset
+		return FieldRef{
+			File:       filename,
+			Line:       pos.Line,
+			Col:        pos.Column,
+			Expr:       string(src[pos.Offset:end]),
+			Rewritable: rewritable,
+			start:      pos.Offset,
+			end:        end,
+		}
+	}
+	astutil.Apply(file, func(c *astutil.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.SelectorExpr:
+			if n.Sel.Name != fieldName {
+				return true
+			}
+			ref := newRef(n, isSimpleRead(c))
+			ref.recv = string(src[fset.Position(n.X.Pos()).Offset:fset.Position(n.X.End()).Offset])
+			refs = append(refs, ref)
+		case *ast.CompositeLit:
+			if typeName(n.Type) != structName {
+				return true
+			}
+			for _, elt := range n.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					if key, ok := kv.Key.(*ast.Ident); ok && key.Name == fieldName {
+						refs = append(refs, newRef(kv, false))
+					}
+				}
+			}
+		}
+		return true
+	}, nil)
+	return refs, nil
+}
+
+// isSimpleRead 判断选择器是否只是被读取：不是赋值、自增自减的目标，也没有被取地址
+func isSimpleRead(c *astutil.Cursor) bool {
+	switch parent := c.Parent().(type) {
+	case *ast.AssignStmt:
+		return c.Name() != "Lhs"
+	case *ast.IncDecStmt:
+		return false
+	case *ast.UnaryExpr:
+		return parent.Op != token.AND
+	case *ast.SelectorExpr:
+		// a.Field.Other 中的 Field 仍然可以替换
+		return c.Name() == "X"
+	}
+	return true
+}
+
+// typeName 返回结构体字面量类型的名称，去掉包名和指针
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.StarExpr:
+		return typeName(t.X)
+	}
+	return ""
+}
+
+// RewriteFieldRefs 用替换表达式改写源码中的字段引用，{{.X}} 表示原选择器的接收表达式，
+// 例如将 u.Legacy 按 "{{.X}}.Name" 改写为 u.Name
+func RewriteFieldRefs(src []byte, refs []FieldRef, replacement string) ([]byte, error) {
+	tmpl, err := template.New("replacement").Option("missingkey=error").Parse(replacement)
+	if err != nil {
+		return nil, fmt.Errorf("解析替换表达式失败: %v", err)
+	}
+
+	sorted := make([]FieldRef, len(refs))
+	copy(sorted, refs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start > sorted[j].start })
+	out := string(src)
+	for _, ref := range sorted {
+		if !ref.Rewritable {
+			return nil, fmt.Errorf("引用 %s 无法自动改写", ref)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, map[string]string{"X": ref.recv}); err != nil {
+			return nil, fmt.Errorf("渲染替换表达式失败: %v", err)
+		}
+		text := buf.String()
+		if _, err := parser.ParseExpr(text); err != nil {
+			return nil, fmt.Errorf("替换结果 %q 不是有效的表达式: %v", text, err)
+		}
+		// 替换结果不是单纯的标识符或选择器时加上括号，避免改变运算优先级
+		if strings.ContainsAny(text, " +-*/%&|^<>!=") {
+			text = "(" + text + ")"
+		}
+		out = out[:ref.start] + text + out[ref.end:]
+	}
+	return format.Source([]byte(out))
+}
+
+// AuditFiles 返回字段引用审计需要扫描的 Go 文件：
+// scope 为 module 时递归扫描 root 下的全部文件（跳过 vendor、testdata、隐藏目录和与 exclude 匹配的路径），
+// 否则只扫描 dir 所在的包
+func AuditFiles(root, dir, scope string, exclude []string) ([]string, error) {
+	if scope != "module" {
+		return filepath.Glob(filepath.Join(dir, "*.go"))
+	}
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || Excluded(exclude, rel, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") && !Excluded(exclude, rel, false) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
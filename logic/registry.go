@@ -0,0 +1,385 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Offline 禁止访问网络（-hermetic 模式），需要下载内容的配置项直接报错
+var Offline bool
+
+// registryBlock 从 schema registry 同步的字段所在托管块的名称
+const registryBlock = "registry"
+
+// RegistrySchema 表示从 Confluent 风格的 schema registry 同步的一个主题：
+// 按主题和版本下载 Avro schema，记录及其嵌套记录生成为目标文件中的结构体。
+// 字段放在名为 registry 的托管块中，每次执行都按 registry 中的定义整体重新生成
+type RegistrySchema struct {
+	// URL registry 的地址，如 https://schema-registry.internal:8081
+	URL string `json:"url" toml:"url"`
+	// Subject 主题名称，如 orders-value
+	Subject string `json:"subject" toml:"subject"`
+	// Version schema 版本号，0 表示最新版本
+	Version int `json:"version" toml:"version"`
+	// Auth 基本认证的凭据（用户名:密码），通常写成密钥引用，见 ResolveSecretRef
+	Auth string `json:"auth" toml:"auth"`
+	// File 生成结构体的目标文件
+	File string `json:"file" toml:"file"`
+	// Package 目标文件不存在时生成骨架使用的包名
+	Package string `json:"package" toml:"package"`
+	// Struct 顶层记录对应的结构体名称，为空时按记录名称生成
+	Struct string `json:"struct" toml:"struct"`
+	// Tags 字段标签的键，值为 schema 中的字段名，默认为 json
+	Tags []string `json:"tags" toml:"tags"`
+}
+
+// registryResponse registry 返回的 schema 版本
+type registryResponse struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+	Version    int    `json:"version"`
+}
+
+// registryRules 检查配置中的 registry 主题，每个主题生成一条还没有结构体的规则。规则与其他规则一起展开和检查，
+// 执行 apply 或 check 之前由 SyncRegistry 下载 schema 补全结构体，解析配置本身不访问网络
+func registryRules(schemas []RegistrySchema) ([]*Rule, error) {
+	var rules []*Rule
+	for i, schema := range schemas {
+		if schema.URL == "" || schema.Subject == "" || schema.File == "" {
+			return nil, fmt.Errorf("registry 必须设置 url、subject 和 file")
+		}
+		if schema.Version < 0 {
+			return nil, fmt.Errorf("registry 主题 %s 的 version 不能为负数", schema.Subject)
+		}
+		model := &Model{File: schema.File, Package: schema.Package}
+		rule := model.Rule()
+		rule.ID = "registry:" + schema.Subject
+		rule.Registry = &schemas[i]
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SyncRegistry 下载配置中 registry 主题的 schema，为对应的规则生成结构体，返回新的配置，原配置不变。
+// 只在 apply 和 check 执行规则之前调用；没有同步的 registry 规则在 ExpandTargets 中跳过
+func SyncRegistry(config *Config) (*Config, error) {
+	synced := *config
+	synced.Rules = make([]*Rule, len(config.Rules))
+	for i, rule := range config.Rules {
+		if rule.Registry == nil {
+			synced.Rules[i] = rule
+			continue
+		}
+		r, err := syncRegistryRule(rule, config)
+		if err != nil {
+			return nil, err
+		}
+		synced.Rules[i] = r
+	}
+	return &synced, nil
+}
+
+// syncRegistryRule 下载规则对应的主题，把记录转换为要创建的结构体，并按配置改写可空字段和合并默认标签
+func syncRegistryRule(rule *Rule, config *Config) (*Rule, error) {
+	schema := *rule.Registry
+	if Offline {
+		return nil, fmt.Errorf("离线模式不能访问 schema registry（主题 %s）", schema.Subject)
+	}
+	resp, err := fetchSchema(schema, config.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	if resp.SchemaType != "" && resp.SchemaType != "AVRO" {
+		return nil, fmt.Errorf("registry 主题 %s 的 schema 类型为 %s，目前只支持 AVRO", schema.Subject, resp.SchemaType)
+	}
+	structs, err := AvroStructs([]byte(resp.Schema), schema.Struct, schema.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("registry 主题 %s 版本 %d: %v", schema.Subject, resp.Version, err)
+	}
+
+	r := *rule
+	model := *rule.Model
+	model.Entities = structs
+	r.Model = &model
+	r.Registry = nil
+	r.Structs = nil
+	for _, st := range structs {
+		st.Create = true
+		for _, field := range st.Fields {
+			if strings.Contains(field.Type, "time.Time") {
+				r.Imports = mergeImports(r.Imports, []Import{{Path: "time"}})
+			}
+		}
+		r.Structs = append(r.Structs, st)
+	}
+	if err := ApplyNullable(&r, r.Nullable, config.OptionType); err != nil {
+		return nil, fmt.Errorf("规则 %s: %v", r.Name(), err)
+	}
+	for i := range r.Structs {
+		if err := ApplyTagDefaults(&r.Structs[i]); err != nil {
+			return nil, fmt.Errorf("规则 %s: %v", r.Name(), err)
+		}
+	}
+	return &r, nil
+}
+
+// fetchSchema 从 registry 下载主题的指定版本
+func fetchSchema(schema RegistrySchema, secrets map[string]Secret) (*registryResponse, error) {
+	version := "latest"
+	if schema.Version > 0 {
+		version = strconv.Itoa(schema.Version)
+	}
+	rawURL := strings.TrimSuffix(schema.URL, "/") + "/subjects/" + url.PathEscape(schema.Subject) + "/versions/" + version
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("registry 地址 %s 无效: %v", schema.URL, err)
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if schema.Auth != "" {
+		auth, err := ResolveSecretRef(schema.Auth, secrets)
+		if err != nil {
+			return nil, fmt.Errorf("registry 主题 %s 的 auth: %v", schema.Subject, err)
+		}
+		user, password, _ := strings.Cut(auth, ":")
+		req.SetBasicAuth(user, password)
+	}
+
+	resp, err := remoteClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载 registry 主题 %s 失败: %v", schema.Subject, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载 registry 主题 %s 版本 %s 失败: %s", schema.Subject, version, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfig+1))
+	if err != nil {
+		return nil, fmt.Errorf("下载 registry 主题 %s 失败: %v", schema.Subject, err)
+	}
+	if len(data) > maxRemoteConfig {
+		return nil, fmt.Errorf("registry 主题 %s 的响应超过 %d 字节", schema.Subject, maxRemoteConfig)
+	}
+	var result registryResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("解析 registry 主题 %s 的响应失败: %v", schema.Subject, err)
+	}
+	return &result, nil
+}
+
+// avroPrimitives Avro 基本类型对应的 Go 类型
+var avroPrimitives = map[string]string{
+	"boolean": "bool",
+	"int":     "int32",
+	"long":    "int64",
+	"float":   "float32",
+	"double":  "float64",
+	"bytes":   "[]byte",
+	"string":  "string",
+}
+
+// avroConverter 把 Avro schema 转换为结构体，嵌套的记录按出现顺序追加为独立的结构体
+type avroConverter struct {
+	tags    []string
+	structs []Struct
+	// named 已定义的命名类型（记录、枚举、fixed）对应的 Go 类型，键为全名和短名
+	named map[string]string
+}
+
+// AvroStructs 把 Avro 记录 schema 转换为结构体，第一个为顶层记录（name 非空时使用该名称），
+// 其后是嵌套的记录。字段名转换为导出的 Go 名称，原名写入 tags 中各键的标签（默认 json）；
+// ["null", T] 形式的联合类型生成为 nullable 字段，其他联合类型为 any
+func AvroStructs(schema []byte, name string, tags []string) ([]Struct, error) {
+	var doc interface{}
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, fmt.Errorf("解析 Avro schema 失败: %v", err)
+	}
+	record, ok := doc.(map[string]interface{})
+	if !ok || record["type"] != "record" {
+		return nil, fmt.Errorf("Avro schema 的顶层必须是 record")
+	}
+	if len(tags) == 0 {
+		tags = []string{"json"}
+	}
+	c := &avroConverter{tags: tags, named: make(map[string]string)}
+	if _, err := c.record(record, name, ""); err != nil {
+		return nil, err
+	}
+	return c.structs, nil
+}
+
+// record 转换一个记录，返回它的 Go 类型名
+func (c *avroConverter) record(record map[string]interface{}, goName, namespace string) (string, error) {
+	name, _ := record["name"].(string)
+	if name == "" {
+		return "", fmt.Errorf("Avro record 缺少 name")
+	}
+	if ns, ok := record["namespace"].(string); ok {
+		namespace = ns
+	}
+	if goName == "" {
+		goName = exportedName(name[strings.LastIndex(name, ".")+1:])
+	}
+	c.define(name, namespace, goName)
+
+	// 先占位，使顶层记录排在嵌套记录之前
+	index := len(c.structs)
+	c.structs = append(c.structs, Struct{})
+	st := Struct{Name: goName, Create: true, Block: registryBlock}
+	st.Description, _ = record["doc"].(string)
+	fields, _ := record["fields"].([]interface{})
+	for _, raw := range fields {
+		f, ok := raw.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("record %s 的字段定义无效", name)
+		}
+		fieldName, _ := f["name"].(string)
+		if fieldName == "" {
+			return "", fmt.Errorf("record %s 的字段缺少 name", name)
+		}
+		typ, nullable, err := c.fieldType(f["type"], namespace)
+		if err != nil {
+			return "", fmt.Errorf("record %s 的字段 %s: %v", name, fieldName, err)
+		}
+		field := Field{Name: exportedName(fieldName), Type: typ, Nullable: nullable}
+		field.Description, _ = f["doc"].(string)
+		pairs := make([]string, len(c.tags))
+		for i, key := range c.tags {
+			pairs[i] = fmt.Sprintf("%s:%q", key, fieldName)
+		}
+		field.Tags = FieldTags(strings.Join(pairs, " "))
+		st.Fields = append(st.Fields, field)
+	}
+	c.structs[index] = st
+	return goName, nil
+}
+
+// define 登记命名类型，引用时可以写全名或短名
+func (c *avroConverter) define(name, namespace, goType string) {
+	c.named[name] = goType
+	if namespace != "" && !strings.Contains(name, ".") {
+		c.named[namespace+"."+name] = goType
+	}
+}
+
+// fieldType 返回字段的 Go 类型，以及它是否为 ["null", T] 形式的可空类型
+func (c *avroConverter) fieldType(schema interface{}, namespace string) (string, bool, error) {
+	union, ok := schema.([]interface{})
+	if !ok {
+		typ, err := c.goType(schema, namespace)
+		return typ, false, err
+	}
+	var branches []interface{}
+	for _, branch := range union {
+		if branch != "null" {
+			branches = append(branches, branch)
+		}
+	}
+	if len(branches) == 1 {
+		typ, err := c.goType(branches[0], namespace)
+		return typ, len(union) == 2, err
+	}
+	return "any", false, nil
+}
+
+// goType 返回非联合类型的 Go 类型，嵌套的记录生成为新的结构体
+func (c *avroConverter) goType(schema interface{}, namespace string) (string, error) {
+	switch s := schema.(type) {
+	case string:
+		if typ, ok := avroPrimitives[s]; ok {
+			return typ, nil
+		}
+		if typ, ok := c.named[s]; ok {
+			return typ, nil
+		}
+		if typ, ok := c.named[namespace+"."+s]; ok {
+			return typ, nil
+		}
+		return "", fmt.Errorf("未知的 Avro 类型 %s", s)
+	case []interface{}:
+		// 数组和映射的元素中出现联合类型
+		typ, nullable, err := c.fieldType(s, namespace)
+		if err != nil {
+			return "", err
+		}
+		if nullable {
+			typ = "*" + typ
+		}
+		return typ, nil
+	case map[string]interface{}:
+		typ, _ := s["type"].(string)
+		switch logical, _ := s["logicalType"].(string); logical {
+		case "timestamp-millis", "timestamp-micros", "date":
+			return "time.Time", nil
+		}
+		switch typ {
+		case "record":
+			return c.record(s, "", namespace)
+		case "enum":
+			name, _ := s["name"].(string)
+			c.define(name, namespace, "string")
+			return "string", nil
+		case "fixed":
+			name, _ := s["name"].(string)
+			size, _ := s["size"].(float64)
+			goType := fmt.Sprintf("[%d]byte", int(size))
+			c.define(name, namespace, goType)
+			return goType, nil
+		case "array":
+			elem, err := c.goType(s["items"], namespace)
+			if err != nil {
+				return "", err
+			}
+			return "[]" + elem, nil
+		case "map":
+			elem, err := c.goType(s["values"], namespace)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + elem, nil
+		}
+		return c.goType(typ, namespace)
+	}
+	return "", fmt.Errorf("无效的 Avro 类型 %v", schema)
+}
+
+// commonInitialisms 生成 Go 名称时整体大写的缩写
+var commonInitialisms = map[string]bool{
+	"id": true, "url": true, "uri": true, "uuid": true, "http": true, "https": true,
+	"api": true, "ip": true, "json": true, "xml": true, "sql": true, "html": true,
+}
+
+// exportedName 把 schema 中的名称（order_id、orderId）转换为导出的 Go 名称（OrderID）
+func exportedName(name string) string {
+	var words []string
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		start := 0
+		runes := []rune(part)
+		for i := 1; i < len(runes); i++ {
+			if unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1]) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	var b strings.Builder
+	for _, word := range words {
+		if commonInitialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	if b.Len() == 0 || !unicode.IsLetter([]rune(b.String())[0]) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
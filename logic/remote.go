@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ConfigChecksum 远程配置内容的 sha256（十六进制），非空时下载的内容必须与之一致，
+// 由 -conf-sha256 设置，用于固定集中分发的规则版本
+var ConfigChecksum string
+
+// maxRemoteConfig 远程配置的大小上限
+const maxRemoteConfig = 10 << 20
+
+// remoteClient 下载远程配置使用的客户端
+var remoteClient = &http.Client{Timeout: 30 * time.Second}
+
+// IsRemoteConfig 返回配置路径是否为 http 或 https 地址
+func IsRemoteConfig(name string) bool {
+	return strings.HasPrefix(name, "https://") || strings.HasPrefix(name, "http://")
+}
+
+// parseRemoteConfig 下载远程配置并按地址中的扩展名解析。http 地址必须用 -conf-sha256 固定内容。
+// 远程配置中的 extends 和 include 无法按本地路径解析，不支持使用；远程配置的内容不受本机控制，
+// 也不能定义 secrets（读取本机的环境变量、文件或执行命令）和 registry（把凭据发往它指定的地址）
+func parseRemoteConfig(rawURL string) (*Config, error) {
+	if strings.HasPrefix(rawURL, "http://") && ConfigChecksum == "" {
+		return nil, fmt.Errorf("远程配置 %s 没有使用 https，必须用 -conf-sha256 固定内容", rawURL)
+	}
+	filename, cleanup, err := fetchConfig(rawURL, ConfigChecksum)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	decode := decoderFor(filename)
+	config, err := decode(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", rawURL, err)
+	}
+	if config.Extends != "" || len(config.Include) > 0 {
+		return nil, fmt.Errorf("远程配置 %s 不支持 extends 和 include", rawURL)
+	}
+	if len(config.Secrets) > 0 {
+		return nil, fmt.Errorf("远程配置 %s 不支持 secrets", rawURL)
+	}
+	if len(config.Registry) > 0 {
+		return nil, fmt.Errorf("远程配置 %s 不支持 registry", rawURL)
+	}
+	return parseConfigFile(filename, decode)
+}
+
+// fetchConfig 把远程配置下载到临时目录中与地址同名的文件，校验 checksum 后返回文件路径和清理函数
+func fetchConfig(rawURL, checksum string) (string, func(), error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("远程配置地址 %s 无效: %v", rawURL, err)
+	}
+	resp, err := remoteClient.Get(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("下载远程配置失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("下载远程配置 %s 失败: %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfig+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("下载远程配置失败: %v", err)
+	}
+	if len(data) > maxRemoteConfig {
+		return "", nil, fmt.Errorf("远程配置 %s 超过 %d 字节", rawURL, maxRemoteConfig)
+	}
+
+	if checksum != "" {
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, checksum) {
+			return "", nil, fmt.Errorf("远程配置 %s 的 sha256 为 %s，与固定的 %s 不一致", rawURL, got, checksum)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "astauto-conf-")
+	if err != nil {
+		return "", nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = "config.toml"
+	}
+	filename := filepath.Join(dir, name)
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("保存远程配置失败: %v", err)
+	}
+	return filename, cleanup, nil
+}
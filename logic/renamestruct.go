@@ -0,0 +1,277 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// StructRename 表示一次结构体改名
+type StructRename struct {
+	Struct string
+	To     string
+}
+
+// String 返回 Old -> New 形式的描述
+func (r StructRename) String() string {
+	return r.Struct + " -> " + r.To
+}
+
+// DeclaresType 判断文件中是否有该名称的顶层类型声明
+func DeclaresType(file *ast.File, name string) bool {
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+			for _, key := range DeclKeys(gen) {
+				if key == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// derivedPrefixes 与结构体名组成派生声明名称的前缀，后面可以跟以大写字母或 _ 开头的后缀，
+// 如 NewUser、NewUserBuilder、TestUser_Validate、ExampleUser、OnlyDeletedUser
+var derivedPrefixes = []string{"New", "Test", "Benchmark", "Fuzz", "Example", "OnlyDeleted"}
+
+// derivedSuffixes 结构体名直接加上后缀组成的派生声明名称，如 UserBuilder、UserColumns、UserColumnID、
+// ListUserRequest；Column 后面还可以跟字段名
+var derivedSuffixes = []string{"Builder", "Columns", "Column", "Request", "Response"}
+
+// derivedName 判断声明名称是否由结构体名 old 派生，返回把其中的 old 换成 to 后的名称
+func derivedName(name, old, to string) (string, bool) {
+	if name == old {
+		return to, true
+	}
+	for _, prefix := range derivedPrefixes {
+		rest, ok := strings.CutPrefix(name, prefix+old)
+		if ok && (rest == "" || rest[0] == '_' || startsUpper(rest)) {
+			return prefix + to + rest, true
+		}
+	}
+	rest, ok := strings.CutPrefix(name, old)
+	if !ok {
+		rest, ok = strings.CutPrefix(name, "List"+old)
+		if !ok || rest != "Request" && rest != "Response" {
+			return "", false
+		}
+		return "List" + to + rest, true
+	}
+	for _, suffix := range derivedSuffixes {
+		if rest == suffix || suffix == "Column" && strings.HasPrefix(rest, suffix) && startsUpper(rest[len(suffix):]) {
+			return to + rest, true
+		}
+	}
+	return "", false
+}
+
+// startsUpper 判断字符串是否以大写字母开头
+func startsUpper(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsUpper(r)
+}
+
+// RenameDecls 返回包中需要随结构体改名的顶层声明：旧名称 -> 新名称，包括结构体本身、构造函数、
+// builder、生成的列名声明和分页结构体，以及测试、基准和示例函数。files 为包的全部文件（包括测试文件）；
+// 新名称已被包中其他声明占用时返回错误
+func RenameDecls(files []*ast.File, rn StructRename) (map[string]string, error) {
+	declared := make(map[string]bool)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil {
+				continue
+			}
+			for _, key := range DeclKeys(decl) {
+				declared[key] = true
+			}
+		}
+	}
+	if !declared[rn.Struct] {
+		return nil, fmt.Errorf("包中没有结构体 %s", rn.Struct)
+	}
+	renames := make(map[string]string)
+	for name := range declared {
+		if to, ok := derivedName(name, rn.Struct, rn.To); ok {
+			renames[name] = to
+		}
+	}
+	for name, to := range renames {
+		if _, renamed := renames[to]; declared[to] && !renamed {
+			return nil, fmt.Errorf("%s 改名为 %s 失败: 包中已有声明 %s", name, to, to)
+		}
+	}
+	return renames, nil
+}
+
+// RenameIdents 在结构体所在包的文件中按 renames 改写标识符，声明的文档注释以旧名称开头时一起改写。
+// 与 FindFieldRefs 一样没有类型信息：字段名、方法名、选择器中的名称和结构体字面量的键不改写，
+// 与声明同名的局部变量会被一起改写。返回改写后的源码和改写的数量
+func RenameIdents(filename string, src []byte, renames map[string]string) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析文件 %s 失败: %v", filename, err)
+	}
+
+	// 不指向顶层声明的标识符
+	skip := make(map[*ast.Ident]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			for _, name := range n.Names {
+				skip[name] = true
+			}
+		case *ast.SelectorExpr:
+			skip[n.Sel] = true
+		case *ast.FuncDecl:
+			if n.Recv != nil {
+				skip[n.Name] = true
+			}
+		case *ast.CompositeLit:
+			if !isKeyedByValue(n.Type) {
+				for _, elt := range n.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok {
+							skip[key] = true
+						}
+					}
+				}
+			}
+		case *ast.LabeledStmt:
+			skip[n.Label] = true
+		case *ast.BranchStmt:
+			if n.Label != nil {
+				skip[n.Label] = true
+			}
+		}
+		return true
+	})
+
+	var edits []textEdit
+	ast.Inspect(file, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || skip[ident] {
+			return true
+		}
+		if to, ok := renames[ident.Name]; ok {
+			edits = append(edits, textEdit{fset.Position(ident.Pos()).Offset, fset.Position(ident.End()).Offset, to})
+		}
+		return true
+	})
+	n := len(edits)
+	for _, decl := range file.Decls {
+		var doc *ast.CommentGroup
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				doc = d.Doc
+			}
+		case *ast.GenDecl:
+			doc = d.Doc
+			if len(d.Specs) == 1 && doc == nil {
+				if ts, ok := d.Specs[0].(*ast.TypeSpec); ok {
+					doc = ts.Doc
+				}
+			}
+		}
+		keys := DeclKeys(decl)
+		if doc == nil || len(keys) != 1 {
+			continue
+		}
+		to, ok := renames[keys[0]]
+		first := doc.List[0]
+		if !ok || !strings.HasPrefix(first.Text, "// "+keys[0]+" ") && first.Text != "// "+keys[0] {
+			continue
+		}
+		start := fset.Position(first.Pos()).Offset + len("// ")
+		edits = append(edits, textEdit{start, start + len(keys[0]), to})
+	}
+	if len(edits) == 0 {
+		return src, 0, nil
+	}
+	out, err := applyTextEdits(src, edits)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, n, nil
+}
+
+// RenameQualified 在导入了结构体所在包的其他包文件中改写 pkg.Name 形式的引用。
+// importPath 为结构体所在包的导入路径，pkgName 为它的包名，文件没有导入该包时不修改
+func RenameQualified(filename string, src []byte, importPath, pkgName string, renames map[string]string) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析文件 %s 失败: %v", filename, err)
+	}
+	local := ""
+	for _, imp := range file.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path == importPath {
+			local = pkgName
+			if imp.Name != nil {
+				local = imp.Name.Name
+			}
+		}
+	}
+	if local == "" || local == "_" {
+		return src, 0, nil
+	}
+	if local == "." {
+		return RenameIdents(filename, src, renames)
+	}
+
+	var edits []textEdit
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == local {
+			if to, ok := renames[sel.Sel.Name]; ok {
+				edits = append(edits, textEdit{fset.Position(sel.Sel.Pos()).Offset, fset.Position(sel.Sel.End()).Offset, to})
+			}
+		}
+		return true
+	})
+	if len(edits) == 0 {
+		return src, 0, nil
+	}
+	out, err := applyTextEdits(src, edits)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, len(edits), nil
+}
+
+// isKeyedByValue 判断复合字面量的键是否为表达式（map、数组和切片），其他类型的键是字段名
+func isKeyedByValue(typ ast.Expr) bool {
+	switch typ.(type) {
+	case *ast.MapType, *ast.ArrayType:
+		return true
+	}
+	return false
+}
+
+// textEdit 表示一次按偏移量的文本替换
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// applyTextEdits 从后往前替换源码中的文本并格式化
+func applyTextEdits(src []byte, edits []textEdit) ([]byte, error) {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := string(src)
+	for _, e := range edits {
+		out = out[:e.start] + e.text + out[e.end:]
+	}
+	return format.Source([]byte(out))
+}
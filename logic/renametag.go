@@ -0,0 +1,221 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TagRename 记录一次标签名称修改
+type TagRename struct {
+	Struct string
+	// Old 修改前的名称
+	Old string
+	RenameTag
+}
+
+// String 返回形如 User.Name json: userName -> user_name 的描述
+func (r TagRename) String() string {
+	return fmt.Sprintf("%s.%s %s: %s -> %s", r.Struct, r.Field, r.Key, r.Old, r.Name)
+}
+
+// validate 检查标签改名配置
+func (r RenameTag) validate() error {
+	if r.Field == "" || r.Key == "" || r.Name == "" {
+		return fmt.Errorf("rename_tags 必须设置 field、key 和 name")
+	}
+	if strings.ContainsAny(r.Name, `," `) || r.Name == "-" {
+		return fmt.Errorf("字段 %s 的 %s 标签名称 %q 无效", r.Field, r.Key, r.Name)
+	}
+	for _, dir := range r.Literals {
+		if filepath.IsAbs(dir) || !filepath.IsLocal(dir) {
+			return fmt.Errorf("字段 %s 的 literals 目录 %q 必须是处理目录下的相对路径", r.Field, dir)
+		}
+	}
+	return nil
+}
+
+// RenameTags 在格式化后的源码中修改已有字段标签里的名称：结构体名 -> 改名列表。
+// 只替换标签值中逗号之前的名称，选项（如 omitempty）保持不变；名称已经是新值时跳过
+func RenameTags(src []byte, renames map[string][]RenameTag) ([]byte, []TagRename, error) {
+	if len(renames) == 0 {
+		return src, nil, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	var done []TagRename
+	var walkErr error
+	WalkStructs(file, func(name string, structType *ast.StructType) {
+		// 同一字段的多个改名合并为一次编辑
+		tags := make(map[*ast.Field][]TagPair)
+		var fields []*ast.Field
+		for _, rn := range renames[name] {
+			if walkErr != nil {
+				return
+			}
+			field := findField(structType, rn.Field)
+			if field == nil {
+				walkErr = fmt.Errorf("结构体 %s 中没有字段 %s", name, rn.Field)
+				return
+			}
+			if field.Tag == nil {
+				walkErr = fmt.Errorf("字段 %s.%s 没有 %s 标签", name, rn.Field, rn.Key)
+				return
+			}
+			pairs, ok := tags[field]
+			if !ok {
+				tag, err := strconv.Unquote(field.Tag.Value)
+				if err != nil {
+					walkErr = fmt.Errorf("字段 %s.%s 的标签无效: %v", name, rn.Field, err)
+					return
+				}
+				if pairs, err = ParseTag(tag); err != nil {
+					walkErr = fmt.Errorf("字段 %s.%s: %v", name, rn.Field, err)
+					return
+				}
+				tags[field] = pairs
+				fields = append(fields, field)
+			}
+			found := false
+			for i, p := range pairs {
+				if p.Key != rn.Key {
+					continue
+				}
+				found = true
+				old, opts, _ := strings.Cut(p.Value, ",")
+				if old == rn.Name {
+					break
+				}
+				pairs[i].Value = rn.Name
+				if opts != "" {
+					pairs[i].Value += "," + opts
+				}
+				done = append(done, TagRename{Struct: name, Old: old, RenameTag: rn})
+			}
+			if !found {
+				walkErr = fmt.Errorf("字段 %s.%s 没有 %s 标签", name, rn.Field, rn.Key)
+				return
+			}
+		}
+		for _, field := range fields {
+			text := FormatTag(tags[field])
+			if strings.Contains(text, "`") {
+				text = strconv.Quote(text)
+			} else {
+				text = "`" + text + "`"
+			}
+			if text != field.Tag.Value {
+				edits = append(edits, edit{
+					start: fset.Position(field.Tag.Pos()).Offset,
+					end:   fset.Position(field.Tag.End()).Offset,
+					text:  text,
+				})
+			}
+		}
+	})
+	if walkErr != nil {
+		return nil, nil, walkErr
+	}
+	if len(edits) == 0 {
+		return src, nil, nil
+	}
+
+	// 从后往前修改，避免偏移量失效
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := string(src)
+	for _, e := range edits {
+		out = out[:e.start] + e.text + out[e.end:]
+	}
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		return nil, nil, err
+	}
+	return formatted, done, nil
+}
+
+// findField 返回结构体中声明了指定名称的字段
+func findField(structType *ast.StructType, name string) *ast.Field {
+	for _, field := range structType.Fields.List {
+		for _, ident := range field.Names {
+			if ident.Name == name {
+				return field
+			}
+		}
+	}
+	return nil
+}
+
+// RenameLiterals 把源码中值等于 old 的字符串字面量改为 new，保留原来的引号形式，返回改写的处数。
+// 结构体标签和导入路径不会被改写
+func RenameLiterals(filename string, src []byte, old, new string) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析文件 %s 失败: %v", filename, err)
+	}
+
+	skip := make(map[*ast.BasicLit]bool)
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ImportSpec:
+			return false
+		case *ast.Field:
+			if n.Tag != nil {
+				skip[n.Tag] = true
+			}
+		case *ast.BasicLit:
+			if n.Kind != token.STRING || skip[n] {
+				return true
+			}
+			value, err := strconv.Unquote(n.Value)
+			if err != nil || value != old {
+				return true
+			}
+			text := strconv.Quote(new)
+			if strings.HasPrefix(n.Value, "`") {
+				text = "`" + new + "`"
+			}
+			edits = append(edits, edit{
+				start: fset.Position(n.Pos()).Offset,
+				end:   fset.Position(n.End()).Offset,
+				text:  text,
+			})
+		}
+		return true
+	})
+	if len(edits) == 0 {
+		return src, 0, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := string(src)
+	for _, e := range edits {
+		out = out[:e.start] + e.text + out[e.end:]
+	}
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		return nil, 0, err
+	}
+	return formatted, len(edits), nil
+}
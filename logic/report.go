@@ -0,0 +1,129 @@
+package logic
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// StructReport 是单个结构体的统计信息
+type StructReport struct {
+	File     string   `json:"file"`
+	Name     string   `json:"name"`
+	Rules    []string `json:"rules"`
+	Fields   int      `json:"fields"`
+	Embedded int      `json:"embedded"`
+	Tagged   int      `json:"tagged"`
+	// MissingTags 结构体中用到的各标签键，以及缺少该键的字段
+	MissingTags map[string][]string `json:"missing_tags,omitempty"`
+	// Size 在 gc/amd64 上的大小（字节），类型无法解析时为 -1
+	Size int64 `json:"size"`
+}
+
+// BuildReport 根据导出的结构体状态统计字段数、标签覆盖情况，size 为结构体大小
+func BuildReport(info *StructInfo, size int64) StructReport {
+	report := StructReport{File: info.File, Name: info.Name, Rules: info.Rules, Fields: len(info.Fields), Size: size}
+	keys := make(map[string]bool)
+	for _, field := range info.Fields {
+		if field.Embedded {
+			report.Embedded++
+		}
+		if field.Tag != "" {
+			report.Tagged++
+		}
+		for _, pair := range field.Tags {
+			keys[pair.Key] = true
+		}
+	}
+	for key := range keys {
+		for _, field := range info.Fields {
+			// 未导出的字段不参与序列化，不统计缺失的标签
+			if field.Embedded || !token.IsExported(field.Name) {
+				continue
+			}
+			if !hasTagKey(field.Tags, key) {
+				if report.MissingTags == nil {
+					report.MissingTags = make(map[string][]string)
+				}
+				report.MissingTags[key] = append(report.MissingTags[key], field.Name)
+			}
+		}
+	}
+	return report
+}
+
+// hasTagKey 判断标签中是否有指定的键
+func hasTagKey(pairs []TagPair, key string) bool {
+	for _, pair := range pairs {
+		if pair.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// StructSize 对文件所在的包做类型检查，返回结构体在 gc/amd64 上的大小，无法解析时返回 -1
+func StructSize(filename string, src []byte, name, goVersion string) int64 {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return -1
+	}
+	pkg, err := checkPackage(fset, filename, file, goVersion)
+	if err != nil {
+		return -1
+	}
+	obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok || !validType(obj.Type().Underlying()) {
+		return -1
+	}
+	return types.SizesFor("gc", "amd64").Sizeof(obj.Type())
+}
+
+// validType 判断类型中是否含有无法解析的部分，此时计算出的大小没有意义
+func validType(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Basic:
+		return t.Kind() != types.Invalid
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !validType(t.Field(i).Type().Underlying()) {
+				return false
+			}
+		}
+	case *types.Array:
+		return validType(t.Elem().Underlying())
+	}
+	return true
+}
+
+// WriteReport 以表格输出结构体统计，缺少的标签键逐行列出
+func WriteReport(w io.Writer, reports []StructReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tSTRUCT\tFIELDS\tEMBEDDED\tTAGGED\tSIZE\tMISSING TAGS")
+	for _, r := range reports {
+		size := "?"
+		if r.Size >= 0 {
+			size = fmt.Sprint(r.Size)
+		}
+		keys := make([]string, 0, len(r.MissingTags))
+		for key := range r.MissingTags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var missing []string
+		for _, key := range keys {
+			missing = append(missing, fmt.Sprintf("%s(%s)", key, strings.Join(r.MissingTags[key], ",")))
+		}
+		if len(missing) == 0 {
+			missing = []string{"-"}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d/%d\t%s\t%s\n", r.File, r.Name, r.Fields, r.Embedded, r.Tagged, r.Fields, size, strings.Join(missing, " "))
+	}
+	return tw.Flush()
+}
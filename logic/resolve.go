@@ -0,0 +1,177 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxResolveDepth 解析别名链的最大深度，防止异常代码导致死循环
+const maxResolveDepth = 16
+
+// ResolveStruct 解析规则中的结构体名：类型是别名（type User = base.User）或基于其他类型定义
+// （type User Base）时，沿类型表达式找到实际的结构体声明。
+// 声明在同一文件中时返回该结构体的名称，以便直接编辑它；声明在其他文件或包中时返回错误，
+// 说明应当把规则指向哪个文件。文件中没有该类型或它本身就是结构体时原样返回 name
+func ResolveStruct(fset *token.FileSet, filename string, file *ast.File, name, goVersion string) (string, error) {
+	spec := findTypeSpec(file, name)
+	if spec == nil {
+		return name, nil
+	}
+	if _, ok := spec.Type.(*ast.StructType); ok {
+		return name, nil
+	}
+
+	// 先在当前文件内沿标识符解析，不需要类型检查
+	cur := spec
+	for i := 0; i < maxResolveDepth; i++ {
+		ident, ok := cur.Type.(*ast.Ident)
+		if !ok {
+			break
+		}
+		next := findTypeSpec(file, ident.Name)
+		if next == nil {
+			break
+		}
+		if _, ok := next.Type.(*ast.StructType); ok {
+			return next.Name.Name, nil
+		}
+		cur = next
+	}
+
+	// 借助 go/types 找到其他文件或包中的声明
+	pos, target, err := resolveTypeDecl(fset, filename, file, name, goVersion)
+	if err != nil {
+		return "", fmt.Errorf("类型 %s 不是结构体，%v", name, err)
+	}
+	if pos.Filename == filename {
+		return target, nil
+	}
+	return "", fmt.Errorf("类型 %s 不是结构体，它指向 %s:%d 中的结构体 %s，请将规则的 file 设置为该文件", name, pos.Filename, pos.Line, target)
+}
+
+// resolveTypeDecl 对文件所在的包做类型检查，沿类型表达式逐级查找，返回最终结构体声明的位置和名称
+func resolveTypeDecl(fset *token.FileSet, filename string, file *ast.File, name, goVersion string) (token.Position, string, error) {
+	pkg, err := checkPackage(fset, filename, file, goVersion)
+	if err != nil {
+		return token.Position{}, "", err
+	}
+
+	obj, _ := pkg.Scope().Lookup(name).(*types.TypeName)
+	for i := 0; obj != nil && i < maxResolveDepth; i++ {
+		pos := fset.Position(obj.Pos())
+		declFile := file
+		if pos.Filename != filename {
+			declFile, err = parser.ParseFile(fset, pos.Filename, nil, parser.SkipObjectResolution)
+			if err != nil {
+				return token.Position{}, "", fmt.Errorf("解析 %s 失败: %v", pos.Filename, err)
+			}
+		}
+		spec := findTypeSpec(declFile, obj.Name())
+		if spec == nil {
+			return token.Position{}, "", fmt.Errorf("在 %s 中没有找到 %s 的声明", pos.Filename, obj.Name())
+		}
+		if _, ok := spec.Type.(*ast.StructType); ok {
+			return pos, obj.Name(), nil
+		}
+
+		switch expr := spec.Type.(type) {
+		case *ast.Ident:
+			obj, _ = obj.Pkg().Scope().Lookup(expr.Name).(*types.TypeName)
+		case *ast.SelectorExpr:
+			x, ok := expr.X.(*ast.Ident)
+			imported := importedPackage(obj.Pkg(), declFile, x)
+			if !ok || imported == nil {
+				return token.Position{}, "", fmt.Errorf("无法解析 %s 引用的包 %s", obj.Name(), types.ExprString(expr.X))
+			}
+			obj, _ = imported.Scope().Lookup(expr.Sel.Name).(*types.TypeName)
+		default:
+			return token.Position{}, "", fmt.Errorf("无法解析 %s 的类型表达式 %s", obj.Name(), types.ExprString(spec.Type))
+		}
+	}
+	return token.Position{}, "", fmt.Errorf("无法解析 %s 指向的结构体声明", name)
+}
+
+// findTypeSpec 在文件中查找指定名称的类型声明
+func findTypeSpec(file *ast.File, name string) *ast.TypeSpec {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+				return ts
+			}
+		}
+	}
+	return nil
+}
+
+// checkPackage 对文件所在目录中同一个包的文件做类型检查，
+// 目标文件使用传入的语法树（可能包含本次运行中的修改），类型错误被忽略；
+// goVersion 不为空时按该语言版本检查
+func checkPackage(fset *token.FileSet, filename string, file *ast.File, goVersion string) (*types.Package, error) {
+	files := []*ast.File{file}
+	dir := filepath.Dir(filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取目录 %s 失败: %v", dir, err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || path == filepath.Clean(filename) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil || f.Name.Name != file.Name.Name {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	conf := types.Config{
+		Importer:  importer.ForCompiler(fset, "source", nil),
+		Error:     func(error) {},
+		GoVersion: goVersion,
+	}
+	pkg, _ := conf.Check(file.Name.Name, fset, files, nil)
+	if pkg == nil {
+		return nil, fmt.Errorf("类型检查 %s 失败", dir)
+	}
+	return pkg, nil
+}
+
+// importedPackage 返回文件中以 x 为包名引用的导入包
+func importedPackage(pkg *types.Package, file *ast.File, x *ast.Ident) *types.Package {
+	if pkg == nil || x == nil {
+		return nil
+	}
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		for _, p := range pkg.Imports() {
+			if p.Path() != path {
+				continue
+			}
+			name := p.Name()
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			if name == x.Name {
+				return p
+			}
+		}
+	}
+	return nil
+}
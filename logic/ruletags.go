@@ -0,0 +1,33 @@
+package logic
+
+import "strings"
+
+// SplitTags 把逗号分隔的标签列表拆开，忽略空项
+func SplitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// MatchTags 返回带有 tags 的规则是否被选中：only 非空时规则至少要带有其中一个标签，
+// 带有 skip 中任一标签的规则不选，skip 优先于 only
+func MatchTags(tags, only, skip []string) bool {
+	has := func(list []string) bool {
+		for _, want := range list {
+			for _, tag := range tags {
+				if tag == want {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if has(skip) {
+		return false
+	}
+	return len(only) == 0 || has(only)
+}
@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// ScaffoldTarget 生成初始配置时扫描到的一个目标文件及其中的结构体
+type ScaffoldTarget struct {
+	File    string
+	Structs []string
+}
+
+// scaffoldHeader 初始配置开头的说明，列出常用的全局设置
+const scaffoldHeader = `# astauto 配置文件，由 astauto init 生成
+# 每条 [[rules]] 对应一个目标 Go 文件，[[rules.structs]] 列出要修改的结构体，
+# [[rules.structs.fields]] 列出要补齐的字段。完整的配置项见 logic/schema.cue
+
+# 配置格式的版本
+version = %d
+
+# 目标代码要兼容的最低 Go 版本，影响 any、泛型等写法
+# go_version = "1.21"
+
+# 可为空字段的表示方式：pointer、sql 或 option
+# nullable = "pointer"
+
+# 所有规则共用的导入
+# [[imports]]
+#   path = "time"
+`
+
+// scaffoldExample 没有扫描目标文件时给出的示例规则
+const scaffoldExample = `
+# [[rules]]
+#   id = "user"
+#   file = "model/user.go"
+#
+#   [[rules.structs]]
+#     name = "User"
+#     # 结构体不存在时创建
+#     create = true
+#     # 按 db 标签生成 UserColumns 和各字段的列名常量
+#     columns = "db"
+#
+#     [[rules.structs.fields]]
+#       name = "CreatedAt"
+#       type = "time.Time"
+#       tags = { json = "created_at", db = "created_at" }
+#       description = "创建时间"
+`
+
+// ScaffoldConfig 生成带注释的初始 TOML 配置：没有目标文件时给出示例规则，
+// 否则为每个目标文件生成一条规则，列出其中的结构体，字段留给用户填写
+func ScaffoldConfig(targets []ScaffoldTarget) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, scaffoldHeader, ConfigVersion)
+	if len(targets) == 0 {
+		sb.WriteString(scaffoldExample)
+		return sb.String()
+	}
+	for _, target := range targets {
+		fmt.Fprintf(&sb, "\n[[rules]]\n  file = %s\n", strconv.Quote(target.File))
+		for _, name := range target.Structs {
+			fmt.Fprintf(&sb, "\n  [[rules.structs]]\n    name = %s\n", strconv.Quote(name))
+			sb.WriteString("\n    # [[rules.structs.fields]]\n    #   name = \"\"\n    #   type = \"\"\n    #   tags = { json = \"\" }\n")
+		}
+	}
+	return sb.String()
+}
+
+// StructNames 按声明顺序返回源码中所有顶层具名结构体的名称
+func StructNames(src []byte) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("解析源码失败: %v", err)
+	}
+	var names []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if _, ok := ts.Type.(*ast.StructType); ok && ts.Assign == 0 {
+				names = append(names, ts.Name.Name)
+			}
+		}
+	}
+	return names, nil
+}
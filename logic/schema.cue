@@ -0,0 +1,213 @@
+// astauto 配置的 schema，ParseCUE 用它校验 CUE 配置。
+// 定义是封闭的，拼错的键（如 feilds）会被报告为错误，而不是被静默忽略。
+// 新增配置项时需要同步修改本文件。
+package astauto
+
+#Config: {
+	version?:    int & >=1
+	changelog?:  string
+	dictionary?: string
+	rules?: [...#Rule]
+	exclude?: [...string]
+	imports?: [...#Import]
+	models?: [...#Model]
+	registry?: [...#Registry]
+	presets?: [string]: #Preset
+	nullable?:    #Nullable
+	option_type?: #Preset
+	snippets?: [string]: #Snippet
+	hook?:          #Hook
+	go_version?:    string
+	state_dir?:     string
+	disable_state?: bool
+	lint?:          #Lint
+	header?:        #Header
+	provenance?:    #Provenance
+	deprecation?:   #Deprecation
+	codegen?:       #Codegen
+	extends?: string
+	include?: [...string]
+	vars?: [string]: string
+	fieldsets?: [string]: [...(#Field | string)]
+	secrets?: [string]: #Secret
+}
+
+// #Tags 字段标签，可以写原始字符串或键值表
+#Tags: string | {[string]: string}
+
+#Nullable: "pointer" | "sql" | "option"
+
+#Header: {
+	text?:  string
+	scope?: "touched" | "matched"
+}
+
+#Codegen: {
+	getter_prefix?: string
+	setter?:        "set" | "with" | "none"
+	receiver?:      "pointer" | "value"
+}
+
+#Provenance: {
+	rules?:       bool
+	config_hash?: bool
+	scope?:       "touched" | "matched"
+}
+
+#Deprecation: {
+	require?:    bool
+	grace_days?: int & >=0
+	journal?:    string
+}
+
+#Hook: {
+	command?: string
+	fix?:     bool
+	args?: [...string]
+}
+
+#Rule: {
+	id?: string
+	depends_on?: [...string]
+	requires?: [...string]
+	enabled?: bool
+	tags?: [...string]
+	when?:          string
+	file?:          string
+	file_regex?: string
+	files?: [...string]
+	package?:       string
+	include_tests?: bool
+	exclude?: [...string]
+	build_tags?: [...string]
+	imports?: [...#Import]
+	structs?: [...#Struct]
+	tag_format?:          "align"
+	apply_snippet?:       #SnippetRef | [...#SnippetRef]
+	go_version?:          string
+	nullable?:            #Nullable
+	normalize_any?:       "any" | "interface{}"
+	normalize_any_scope?: "structs" | "file"
+	merge_imports?:       bool
+	import_conflict?:     "error" | "keep" | "rewrite"
+	on_syntax_error?:     "fail" | "imports"
+	preserve_format?:     bool
+	managed?:             bool
+}
+
+#Import: {
+	path:   string
+	alias?: string
+}
+
+#Struct: {
+	name:         string
+	description?: string
+	create?:      bool
+	constructor?: bool
+	pattern?:     "soft-delete" | "version-lock" | "pagination"
+	pattern_scope?: bool
+	pattern_file?:  string
+	when?:        string
+	anchor?:      string
+	block?:       string
+	sort_by_tag?: string
+	tag_defaults?: #Tags
+	fields?: [...(#Field | string)]
+	remove?: [...#RemoveField]
+	remove_methods?: [...string]
+	fieldsets?: [...string]
+	receiver?: string
+	map_methods?: string
+	columns?:     string
+	accessors?:   bool
+	redacted?:    bool
+	sensitive?: [...string]
+	rename_tags?: [...#RenameTag]
+	deprecate?: [...#Deprecate]
+	widen?: [...#Widen]
+	rename_to?: string
+}
+
+#RemoveField: {
+	name:         string
+	audit?:       "fail" | "list" | "rewrite"
+	scope?:       "package" | "module"
+	replacement?: string
+}
+
+#RenameTag: {
+	field: string
+	key:   string
+	name:  string
+	literals?: [...string]
+}
+
+#Deprecate: {
+	name:    string
+	message: string
+	tags?: [...string]
+}
+
+#Widen: {
+	name: string
+	type: "int16" | "int32" | "int64" | "int" | "uint16" | "uint32" | "uint64" | "uint" | "float32" | "float64"
+}
+
+#Field: {
+	name:         string
+	type?:        string
+	tags?:        #Tags
+	description?: string
+	example?:     string
+	default?:     string
+	types?: [string]: string
+	nullable?:    bool
+}
+
+#Model: {
+	file:      string
+	package?:  string
+	template?: string
+	imports?: [...#Import]
+	entities?: [...#Struct]
+}
+
+#Registry: {
+	url:      string
+	subject:  string
+	version?: int & >=0
+	auth?:    string
+	file:     string
+	package?: string
+	struct?:  string
+	tags?: [...string]
+}
+
+#Preset: {
+	type: string
+	imports?: [...#Import]
+	tags?: #Tags
+}
+
+#Snippet: {
+	params?: [...string]
+	imports?: [...#Import]
+	code: string
+}
+
+#SnippetRef: {
+	name: string
+	args?: [string]: string | number | bool
+}
+
+#Lint: {
+	checks?: [...("json-case" | "gorm-column" | "db-tag")]
+	json_case?: "snake" | "camel"
+}
+
+#Secret: {
+	env?:  string
+	file?: string
+	command?: [...string]
+}
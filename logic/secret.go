@@ -0,0 +1,90 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Secret 描述一个敏感值（DSN、令牌等）的来源，配置文件中只保存引用而不保存明文。
+// Env、File、Command 三者必须且只能设置一个
+type Secret struct {
+	// Env 从环境变量读取
+	Env string `json:"env" toml:"env"`
+	// File 从文件读取，去掉首尾空白，适用于挂载的密钥文件
+	File string `json:"file" toml:"file"`
+	// Command 执行外部命令并读取标准输出，用于对接密钥管理工具，
+	// 例如 ["vault", "kv", "get", "-field=dsn", "secret/db"]
+	Command []string `json:"command" toml:"command"`
+}
+
+// Resolve 读取密钥的值
+func (s Secret) Resolve() (string, error) {
+	set := 0
+	for _, ok := range []bool{s.Env != "", s.File != "", len(s.Command) > 0} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", fmt.Errorf("密钥必须且只能设置 env、file、command 中的一个")
+	}
+
+	switch {
+	case s.Env != "":
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("环境变量 %s 未设置", s.Env)
+		}
+		return value, nil
+	case s.File != "":
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", fmt.Errorf("读取密钥文件失败: %v", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		cmd := exec.Command(s.Command[0], s.Command[1:]...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			// 不输出命令的标准输出，避免泄露密钥
+			return "", fmt.Errorf("执行密钥命令 %s 失败: %v %s", s.Command[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+}
+
+// ResolveSecretRef 解析配置值中的密钥引用：
+//
+//	secret:名称  引用 [secrets] 中定义的密钥
+//	env:NAME     读取环境变量
+//	file:路径    读取文件内容
+//
+// 不带前缀的值原样返回
+func ResolveSecretRef(ref string, secrets map[string]Secret) (string, error) {
+	prefix, rest, ok := strings.Cut(ref, ":")
+	if !ok {
+		return ref, nil
+	}
+	switch prefix {
+	case "secret":
+		secret, ok := secrets[rest]
+		if !ok {
+			return "", fmt.Errorf("密钥 %s 未定义", rest)
+		}
+		value, err := secret.Resolve()
+		if err != nil {
+			return "", fmt.Errorf("读取密钥 %s 失败: %v", rest, err)
+		}
+		return value, nil
+	case "env":
+		return Secret{Env: rest}.Resolve()
+	case "file":
+		return Secret{File: rest}.Resolve()
+	}
+	return ref, nil
+}
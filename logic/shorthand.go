@@ -0,0 +1,92 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// presetPlaceholder 解析字段简写时代替类型预设引用中 @ 的前缀，使 []@uuid 这样的写法可以按 Go 语法解析
+const presetPlaceholder = "astautoPreset_"
+
+// ParseFieldShorthand 按 Go 结构体字段声明的写法解析字段，如 Email string `json:"email"` // 邮箱，
+// 行尾注释作为字段说明，类型中可以引用类型预设（如 ID @uuid）
+func ParseFieldShorthand(s string) (Field, error) {
+	decl := presetRef.ReplaceAllString(s, presetPlaceholder+"$1")
+	src := "package p\n\ntype _ struct {\n" + decl + "\n}\n"
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		return Field{}, fmt.Errorf("字段简写 %q 的语法无效", s)
+	}
+	structType := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	if len(structType.Fields.List) != 1 || len(structType.Fields.List[0].Names) != 1 {
+		return Field{}, fmt.Errorf("字段简写 %q 必须是单个带名称和类型的字段", s)
+	}
+	f := structType.Fields.List[0]
+	typ := strings.ReplaceAll(types.ExprString(f.Type), presetPlaceholder, "@")
+	field := Field{Name: f.Names[0].Name, Type: typ}
+	if f.Tag != nil {
+		tag, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			return Field{}, fmt.Errorf("字段简写 %q 的标签无效: %v", s, err)
+		}
+		field.Tags = FieldTags(tag)
+	}
+	if f.Comment != nil {
+		field.Description = strings.TrimSpace(f.Comment.Text())
+	}
+	return field, nil
+}
+
+// UnmarshalTOML 实现 toml.Unmarshaler，兼容表与字段简写两种写法：
+// fields = ["Email string `json:\"email\"`", "Age *int"]
+func (f *Field) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		field, err := ParseFieldShorthand(v)
+		if err != nil {
+			return err
+		}
+		*f = field
+	case map[string]interface{}:
+		// 表的各个键与 JSON 名称一致，借助 JSON 解码，tags 的两种写法由 FieldTags 处理
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		type plain Field
+		var field plain
+		if err := json.Unmarshal(raw, &field); err != nil {
+			return fmt.Errorf("解析字段失败: %v", err)
+		}
+		*f = Field(field)
+	default:
+		return fmt.Errorf("字段必须是表或字段简写字符串")
+	}
+	return nil
+}
+
+// UnmarshalJSON 兼容 JSON 和 YAML 配置中的对象与字段简写两种写法
+func (f *Field) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		field, err := ParseFieldShorthand(s)
+		if err != nil {
+			return err
+		}
+		*f = field
+		return nil
+	}
+	type plain Field
+	var field plain
+	if err := json.Unmarshal(data, &field); err != nil {
+		return err
+	}
+	*f = Field(field)
+	return nil
+}
@@ -0,0 +1,218 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+	"text/template"
+)
+
+// Snippet 表示一段可复用的代码片段（方法、常量块等），代码为 text/template 模板
+type Snippet struct {
+	// Params 片段需要的参数名，引用时必须全部提供
+	Params []string `json:"params" toml:"params"`
+	// Imports 片段代码依赖的导入
+	Imports []Import `json:"imports" toml:"imports"`
+	// Code 片段代码，使用 {{.参数名}} 引用参数
+	Code string `json:"code" toml:"code"`
+}
+
+// SnippetRef 表示规则对代码片段的一次引用
+type SnippetRef struct {
+	Name string            `json:"name" toml:"name"`
+	Args map[string]string `json:"args" toml:"args"`
+}
+
+// SnippetRefs 代码片段引用列表，TOML 中既可以写单个内联表也可以写数组
+type SnippetRefs []SnippetRef
+
+// UnmarshalTOML 实现 toml.Unmarshaler，兼容 apply_snippet = {...} 与 [[apply_snippet]] 两种写法
+func (r *SnippetRefs) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		ref, err := snippetRefFromMap(v)
+		if err != nil {
+			return err
+		}
+		*r = SnippetRefs{ref}
+	case []map[string]interface{}:
+		for _, m := range v {
+			ref, err := snippetRefFromMap(m)
+			if err != nil {
+				return err
+			}
+			*r = append(*r, ref)
+		}
+	case []interface{}:
+		for _, item := range v {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("apply_snippet 的元素必须是表")
+			}
+			ref, err := snippetRefFromMap(m)
+			if err != nil {
+				return err
+			}
+			*r = append(*r, ref)
+		}
+	default:
+		return fmt.Errorf("apply_snippet 必须是表或表数组")
+	}
+	return nil
+}
+
+// UnmarshalJSON 兼容 JSON 和 YAML 配置中的对象与数组两种写法
+func (r *SnippetRefs) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return r.UnmarshalTOML(v)
+}
+
+// snippetRefFromMap 从 TOML 表构造片段引用
+func snippetRefFromMap(m map[string]interface{}) (SnippetRef, error) {
+	var ref SnippetRef
+	name, ok := m["name"].(string)
+	if !ok || name == "" {
+		return ref, fmt.Errorf("apply_snippet 缺少 name")
+	}
+	ref.Name = name
+	if args, ok := m["args"].(map[string]interface{}); ok {
+		ref.Args = make(map[string]string, len(args))
+		for k, v := range args {
+			ref.Args[k] = fmt.Sprint(v)
+		}
+	}
+	return ref, nil
+}
+
+// Render 使用参数渲染代码片段
+func (s Snippet) Render(name string, args map[string]string) (string, error) {
+	for _, param := range s.Params {
+		if _, ok := args[param]; !ok {
+			return "", fmt.Errorf("代码片段 %s 缺少参数 %s", name, param)
+		}
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(s.Code)
+	if err != nil {
+		return "", fmt.Errorf("解析代码片段 %s 失败: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, args); err != nil {
+		return "", fmt.Errorf("渲染代码片段 %s 失败: %v", name, err)
+	}
+	return buf.String(), nil
+}
+
+// AppendDecls 将代码中的声明追加到源码末尾，已存在同名声明的跳过，
+// 返回新的源码和实际追加的声明名称
+func AppendDecls(src []byte, code string) ([]byte, []string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析源码失败: %v", err)
+	}
+	existing := make(map[string]bool)
+	for _, decl := range file.Decls {
+		for _, key := range DeclKeys(decl) {
+			existing[key] = true
+		}
+	}
+
+	// 片段只包含声明，补上包名后解析
+	const header = "package snippet\n"
+	snippetSrc := header + code
+	snippetFile, err := parser.ParseFile(fset, "", snippetSrc, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析代码片段失败: %v", err)
+	}
+	base := fset.File(snippetFile.Pos()).Base()
+
+	var sb strings.Builder
+	var added []string
+	for _, decl := range snippetFile.Decls {
+		keys := DeclKeys(decl)
+		exists := false
+		for _, key := range keys {
+			if existing[key] {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		// 连同文档注释一起截取声明的源码
+		start := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		sb.WriteString("\n")
+		sb.WriteString(snippetSrc[int(start)-base : int(decl.End())-base])
+		sb.WriteString("\n")
+		added = append(added, keys...)
+	}
+	if len(added) == 0 {
+		return src, nil, nil
+	}
+
+	out, err := format.Source(append(bytes.TrimRight(src, "\n"), []byte("\n"+sb.String())...))
+	if err != nil {
+		return nil, nil, fmt.Errorf("格式化代码片段失败: %v", err)
+	}
+	return out, added, nil
+}
+
+// DeclKeys 返回声明的标识，方法为 "接收者类型.方法名"，其余为声明的名称
+func DeclKeys(decl ast.Decl) []string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			return []string{receiverTypeName(d.Recv.List[0].Type) + "." + d.Name.Name}
+		}
+		return []string{d.Name.Name}
+	case *ast.GenDecl:
+		var keys []string
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				keys = append(keys, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					keys = append(keys, name.Name)
+				}
+			}
+		}
+		return keys
+	}
+	return nil
+}
+
+// receiverTypeName 返回接收者的类型名，去掉指针和类型参数
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TagOrder 从标签值中取出排序用的数字：取逗号分隔的第一个整数，
+// 兼容 order:"3" 和 protobuf:"varint,1,opt,name=id" 两种写法
+func TagOrder(tag, key string) (int, bool) {
+	value, ok := reflect.StructTag(tag).Lookup(key)
+	if !ok {
+		return 0, false
+	}
+	for _, part := range strings.Split(value, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// SortFieldsByTag 在格式化后的源码中按标签数值对结构体字段排序：结构体名 -> 标签键。
+// 没有该标签的字段保持原有相对顺序排在最后；字段连同文档注释和行尾注释一起移动，
+// 结构体内有不属于任何字段的注释时返回错误，避免注释被丢弃
+func SortFieldsByTag(src []byte, sorts map[string]string) ([]byte, error) {
+	if len(sorts) == 0 {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+	tokFile := fset.File(file.Pos())
+
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	var replacements []replacement
+	var sortErr error
+	WalkStructs(file, func(name string, structType *ast.StructType) {
+		key, ok := sorts[name]
+		if !ok || sortErr != nil || len(structType.Fields.List) < 2 {
+			return
+		}
+
+		type chunk struct {
+			text  string
+			order int
+			has   bool
+		}
+		var chunks []chunk
+		attached := make(map[*ast.CommentGroup]bool)
+		for _, field := range structType.Fields.List {
+			start := field.Pos()
+			if field.Doc != nil {
+				start = field.Doc.Pos()
+				attached[field.Doc] = true
+			}
+			end := field.End()
+			if field.Comment != nil {
+				end = field.Comment.End()
+				attached[field.Comment] = true
+			}
+			startOffset := tokFile.Offset(tokFile.LineStart(tokFile.Line(start)))
+			c := chunk{text: string(src[startOffset:tokFile.Offset(end)])}
+			if field.Tag != nil {
+				tag, _ := strconv.Unquote(field.Tag.Value)
+				c.order, c.has = TagOrder(tag, key)
+			}
+			chunks = append(chunks, c)
+		}
+		for _, group := range file.Comments {
+			if group.Pos() > structType.Fields.Opening && group.End() < structType.Fields.Closing && !attached[group] {
+				sortErr = fmt.Errorf("结构体 %s 中有不属于字段的注释，无法排序", name)
+				return
+			}
+		}
+
+		sorted := make([]chunk, len(chunks))
+		copy(sorted, chunks)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].has != sorted[j].has {
+				return sorted[i].has
+			}
+			return sorted[i].has && sorted[i].order < sorted[j].order
+		})
+		same := true
+		for i := range chunks {
+			if chunks[i].text != sorted[i].text {
+				same = false
+				break
+			}
+		}
+		if same {
+			return
+		}
+
+		// 用排好序的字段替换左右花括号之间的全部内容
+		var sb strings.Builder
+		sb.WriteString("\n")
+		for _, c := range sorted {
+			sb.WriteString(strings.TrimLeft(c.text, " \t"))
+			sb.WriteString("\n")
+		}
+		replacements = append(replacements, replacement{
+			start: tokFile.Offset(structType.Fields.Opening) + 1,
+			end:   tokFile.Offset(structType.Fields.Closing),
+			text:  sb.String(),
+		})
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+	if len(replacements) == 0 {
+		return src, nil
+	}
+
+	// 从后往前替换，避免偏移量失效
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start > replacements[j].start })
+	out := string(src)
+	for _, r := range replacements {
+		out = out[:r.start] + r.text + out[r.end:]
+	}
+	return format.Source([]byte(out))
+}
@@ -0,0 +1,140 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// starlarkMaxSteps 配置脚本最多执行的步数，避免死循环卡住整个运行
+const starlarkMaxSteps = 10000000
+
+// ParseStarlark 从Starlark脚本解析配置：脚本可以在全局变量 config 中写出与 JSON 配置结构相同的字典，
+// 也可以调用内置函数 rule(file = ..., structs = [...]) 逐条生成规则，生成的规则追加到 config 的规则之后。
+// 例如按模型名列表循环生成规则：
+//
+//	for name in ["User", "Order"]:
+//	    rule(file = "model/%s.go" % name.lower(), structs = [{"name": name, "fields": ["ID int64"]}])
+func ParseStarlark(filename string) (*Config, error) {
+	return parseConfigFile(filename, decodeStarlark)
+}
+
+// decodeStarlark 执行Starlark配置脚本，不展开和检查
+func decodeStarlark(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开Starlark文件: %v", err)
+	}
+
+	var rules []interface{}
+	rule := starlark.NewBuiltin("rule", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("%s: 只接受关键字参数", b.Name())
+		}
+		r := make(map[string]interface{}, len(kwargs))
+		for _, kv := range kwargs {
+			value, err := starlarkValue(kv[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: 参数 %s: %v", b.Name(), kv[0], err)
+			}
+			r[string(kv[0].(starlark.String))] = value
+		}
+		rules = append(rules, r)
+		return starlark.None, nil
+	})
+	thread := &starlark.Thread{
+		Name:  filename,
+		Print: func(_ *starlark.Thread, msg string) { log.Printf("%s: %s", filename, msg) },
+	}
+	thread.SetMaxExecutionSteps(starlarkMaxSteps)
+	// 配置脚本通常在顶层循环生成规则，允许顶层的 for、if 和 while，死循环由步数限制兜底
+	opts := &syntax.FileOptions{TopLevelControl: true, GlobalReassign: true, While: true, Set: true}
+	globals, err := starlark.ExecFileOptions(opts, thread, filename, data, starlark.StringDict{"rule": rule})
+	if err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return nil, fmt.Errorf("执行Starlark文件失败: %s", evalErr.Backtrace())
+		}
+		return nil, fmt.Errorf("执行Starlark文件失败: %v", err)
+	}
+
+	doc := map[string]interface{}{}
+	if value, ok := globals["config"]; ok {
+		v, err := starlarkValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("Starlark文件中的 config: %v", err)
+		}
+		if doc, ok = v.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("Starlark文件中的 config 必须是字典")
+		}
+	}
+	if len(rules) > 0 {
+		existing, _ := doc["rules"].([]interface{})
+		doc["rules"] = append(existing, rules...)
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("解析Starlark文件失败: %v", err)
+	}
+	var config Config
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("解析Starlark文件失败: %v", err)
+	}
+	return &config, nil
+}
+
+// starlarkValue 把 Starlark 的值转换为可以编码为 JSON 的通用值，字典的键必须是字符串
+func starlarkValue(v starlark.Value) (interface{}, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.Int:
+		n, ok := v.Int64()
+		if !ok {
+			return nil, fmt.Errorf("整数 %s 超出范围", v)
+		}
+		return n, nil
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.String:
+		return string(v), nil
+	case *starlark.List:
+		return starlarkList(v)
+	case starlark.Tuple:
+		return starlarkList(v)
+	case *starlark.Dict:
+		m := make(map[string]interface{}, v.Len())
+		for _, item := range v.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("字典的键 %s 不是字符串", item[0])
+			}
+			value, err := starlarkValue(item[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			m[string(key)] = value
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("不支持 %s 类型的值", v.Type())
+}
+
+// starlarkList 转换 Starlark 的列表和元组
+func starlarkList(v starlark.Indexable) ([]interface{}, error) {
+	list := make([]interface{}, v.Len())
+	for i := range list {
+		item, err := starlarkValue(v.Index(i))
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 项: %v", i+1, err)
+		}
+		list[i] = item
+	}
+	return list, nil
+}
@@ -0,0 +1,41 @@
+package logic
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DefaultStateDir 运行状态目录的默认位置，相对于模块根目录
+const DefaultStateDir = ".astauto"
+
+// StateDir 返回存放运行锁等运行状态的目录：dir 为绝对路径时原样使用；
+// 为相对路径时相对于处理目录所在模块的根目录，为空时使用 DefaultStateDir
+func StateDir(root, dir string) string {
+	if dir == "" {
+		dir = DefaultStateDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(ModuleRoot(root), dir)
+}
+
+// ModuleRoot 返回 path 所在的模块根目录，即包含 go.mod 的最近上级目录；找不到时返回 path 所在的目录
+func ModuleRoot(path string) string {
+	dir := path
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return dir
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/version"
+	"sort"
+	"strconv"
+)
+
+// stdlibPackage 较新的标准库包的版本信息
+type stdlibPackage struct {
+	// Since 引入该包的 Go 版本
+	Since string
+	// Fallback 目标版本早于 Since 时使用的替代导入路径，包名相同且接口兼容，为空表示没有替代
+	Fallback string
+}
+
+// stdlibPackages 按导入路径记录较新的标准库包，目标版本较旧时改用替代包或报错
+var stdlibPackages = map[string]stdlibPackage{
+	"net/netip":    {Since: "go1.18"},
+	"crypto/ecdh":  {Since: "go1.20"},
+	"slices":       {Since: "go1.21", Fallback: "golang.org/x/exp/slices"},
+	"maps":         {Since: "go1.21", Fallback: "golang.org/x/exp/maps"},
+	"cmp":          {Since: "go1.21"},
+	"log/slog":     {Since: "go1.21", Fallback: "golang.org/x/exp/slog"},
+	"math/rand/v2": {Since: "go1.22"},
+	"iter":         {Since: "go1.23"},
+	"unique":       {Since: "go1.23"},
+	"structs":      {Since: "go1.23"},
+	"weak":         {Since: "go1.24"},
+}
+
+// stdlibNames 字段类型中常见的标准库包名对应的导入路径，按从新到旧排列，推断导入时取目标版本可用的第一个
+var stdlibNames = map[string][]string{
+	"atomic":  {"sync/atomic"},
+	"big":     {"math/big"},
+	"bytes":   {"bytes"},
+	"context": {"context"},
+	"fs":      {"io/fs"},
+	"http":    {"net/http"},
+	"io":      {"io"},
+	"json":    {"encoding/json"},
+	"log":     {"log"},
+	"maps":    {"maps"},
+	"net":     {"net"},
+	"netip":   {"net/netip"},
+	"os":      {"os"},
+	"rand":    {"math/rand/v2", "math/rand"},
+	"regexp":  {"regexp"},
+	"slog":    {"log/slog"},
+	"sql":     {"database/sql"},
+	"strings": {"strings"},
+	"sync":    {"sync"},
+	"time":    {"time"},
+	"unique":  {"unique"},
+	"url":     {"net/url"},
+}
+
+// stdlibPath 返回标准库包在目标版本下使用的导入路径：包比目标版本新时返回替代路径，
+// 没有替代时 ok 为 false
+func stdlibPath(path, goVersion string) (string, bool) {
+	pkg, known := stdlibPackages[path]
+	if !known || goVersion == "" || version.Compare(goVersion, pkg.Since) >= 0 {
+		return path, true
+	}
+	return pkg.Fallback, pkg.Fallback != ""
+}
+
+// GateImports 按目标 Go 版本调整导入：比目标版本新的标准库包（如 go1.21 的 slices）改用兼容的替代包
+// （golang.org/x/exp/slices），没有替代时返回错误
+func GateImports(imports []Import, goVersion string) ([]Import, error) {
+	gated := make([]Import, 0, len(imports))
+	for _, imp := range imports {
+		path, ok := stdlibPath(imp.Path, goVersion)
+		if !ok {
+			return nil, fmt.Errorf("包 %s 需要 %s，目标版本为 %s", imp.Path, stdlibPackages[imp.Path].Since, goVersion)
+		}
+		imp.Path = path
+		gated = append(gated, imp)
+	}
+	return gated, nil
+}
+
+// InferImports 推断字段类型中引用但规则和文件都没有导入的标准库包（如 time.Time 需要的 time），
+// 按目标 Go 版本选择可用的导入路径，无法推断的包名留给编译报告
+func InferImports(structs []Struct, imports []Import, file *ast.File, goVersion string) []Import {
+	known := make(map[string]bool)
+	for _, imp := range imports {
+		known[importName(imp.Alias, imp.Path)] = true
+	}
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		alias := ""
+		if imp.Name != nil {
+			alias = imp.Name.Name
+		}
+		known[importName(alias, path)] = true
+	}
+
+	inferred := make(map[string]string)
+	for _, st := range structs {
+		for _, field := range st.Fields {
+			expr, err := parser.ParseExpr(field.Type)
+			if err != nil {
+				continue
+			}
+			ast.Inspect(expr, func(n ast.Node) bool {
+				sel, ok := n.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				if x, ok := sel.X.(*ast.Ident); ok && !known[x.Name] {
+					for _, path := range stdlibNames[x.Name] {
+						if path, ok := stdlibPath(path, goVersion); ok {
+							inferred[x.Name] = path
+							break
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	var names []string
+	for name := range inferred {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []Import
+	for _, name := range names {
+		result = append(result, Import{Path: inferred[name]})
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package logic
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// StrictConfig 为 true 时 TOML 配置中没有对应字段的键（通常是拼写错误，如 tag 写成了 tags）会报错，
+// 为 false 时按 TOML 解码的默认行为忽略
+var StrictConfig = true
+
+// UnknownKey 表示配置中没有对应字段的键
+type UnknownKey struct {
+	Key string
+	// Lines 键在文件中出现的行号，找不到时为空
+	Lines []int
+}
+
+// String 返回形如 第 3、9 行: rules.structs.tag 的说明
+func (k UnknownKey) String() string {
+	if len(k.Lines) == 0 {
+		return k.Key
+	}
+	lines := make([]string, len(k.Lines))
+	for i, line := range k.Lines {
+		lines[i] = fmt.Sprint(line)
+	}
+	return fmt.Sprintf("第 %s 行: %s", strings.Join(lines, "、"), k.Key)
+}
+
+// unknownKeys 按 TOML 元数据中未解码的键查找它们在文件中的行号，同一个键只报告一次
+func unknownKeys(data []byte, md toml.MetaData) []UnknownKey {
+	var keys []UnknownKey
+	seen := make(map[string]bool)
+	for _, key := range md.Undecoded() {
+		name := key.String()
+		if seen[name] || customDecoded(reflect.TypeOf(Config{}), key) {
+			continue
+		}
+		// 未知的表下面的键不再单独报告
+		if parent := len(key) - 1; parent > 0 && seen[toml.Key(key[:parent]).String()] {
+			seen[name] = true
+			continue
+		}
+		seen[name] = true
+		keys = append(keys, UnknownKey{Key: name, Lines: keyLines(data, key)})
+	}
+	return keys
+}
+
+// customDecoded 判断键是否位于自行实现 toml.Unmarshaler 的值（如 FieldTags 的键值表写法）之内，
+// 这些值的子键由类型自己解码，不会被 TOML 元数据标记为已解码。自行解码的结构体（如 Field）
+// 继续按 toml 标签检查子键，没有对应字段的键仍然报告
+func customDecoded(t reflect.Type, key toml.Key) bool {
+	unmarshaler := reflect.TypeOf((*toml.Unmarshaler)(nil)).Elem()
+	custom := false
+	for _, part := range key {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+			continue
+		case reflect.Struct:
+			if reflect.PointerTo(t).Implements(unmarshaler) {
+				custom = true
+			}
+		default:
+			return false
+		}
+		field, ok := tomlField(t, part)
+		if !ok {
+			return false
+		}
+		t = field.Type
+		if reflect.PointerTo(t).Implements(unmarshaler) {
+			if t.Kind() != reflect.Struct {
+				return true
+			}
+			custom = true
+		}
+	}
+	return custom
+}
+
+// tomlField 按 toml 标签查找结构体字段
+func tomlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.Split(field.Tag.Get("toml"), ",")[0] == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// tomlHeader 匹配 [table] 和 [[array]] 表头
+var tomlHeader = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*(#.*)?$`)
+
+// keyLines 返回键在 TOML 文本中出现的行号：键是表时匹配表头，否则匹配所在表中的赋值行，
+// 写在内联表中找不到所在表时退化为按键名匹配
+func keyLines(data []byte, key toml.Key) []int {
+	last := key[len(key)-1]
+	parent := toml.Key(key[:len(key)-1]).String()
+	assign := regexp.MustCompile(`^\s*("` + regexp.QuoteMeta(last) + `"|` + regexp.QuoteMeta(last) + `)\s*=`)
+	inline := regexp.MustCompile(`(^|[\s{,])("` + regexp.QuoteMeta(last) + `"|` + regexp.QuoteMeta(last) + `)\s*=`)
+
+	var lines, loose []int
+	table := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		if m := tomlHeader.FindStringSubmatch(text); m != nil {
+			table = normalizeTableName(m[1])
+			if table == key.String() {
+				lines = append(lines, n)
+			}
+			continue
+		}
+		if table == parent && assign.MatchString(text) {
+			lines = append(lines, n)
+		} else if inline.MatchString(text) {
+			loose = append(loose, n)
+		}
+	}
+	if len(lines) == 0 {
+		return loose
+	}
+	return lines
+}
+
+// normalizeTableName 去掉表名各段两侧的空白和引号
+func normalizeTableName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
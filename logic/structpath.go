@@ -0,0 +1,85 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// 结构体路径：规则中的结构体名除了类型名，也可以是指向嵌在其他类型里的匿名结构体的路径。
+// 路径以类型名开头，.Field 进入该字段的类型，[] 进入切片、数组的元素或 map 的值，指针自动解引用，例如
+//
+//	Users[]          type Users []struct{...} 的元素
+//	Config.Server    Config 中 Server 字段的内联结构体
+//	Index[].Tags[]   type Index map[string]struct{ Tags []struct{...} } 中最内层的结构体
+
+// IsStructPath 判断结构体名是否为路径而不是单纯的类型名
+func IsStructPath(name string) bool {
+	return strings.ContainsAny(name, ".[")
+}
+
+// ValidateStructPath 检查结构体路径的语法
+func ValidateStructPath(path string) error {
+	rest := path
+	i := strings.IndexAny(rest, ".[")
+	if i < 0 {
+		i = len(rest)
+	}
+	if !token.IsIdentifier(rest[:i]) {
+		return fmt.Errorf("结构体路径 %q 必须以类型名开头", path)
+	}
+	rest = rest[i:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "[]"):
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			j := strings.IndexAny(rest, ".[")
+			if j < 0 {
+				j = len(rest)
+			}
+			if !token.IsIdentifier(rest[:j]) {
+				return fmt.Errorf("结构体路径 %q 中的字段名 %q 无效", path, rest[:j])
+			}
+			rest = rest[j:]
+		default:
+			return fmt.Errorf("结构体路径 %q 的语法无效，只支持 .字段 和 []", path)
+		}
+	}
+	return nil
+}
+
+// WalkStructs 遍历文件中的全部结构体类型，包括嵌在其他类型中的匿名结构体，
+// 对每个结构体以它的路径（具名类型为类型名）调用 fn
+func WalkStructs(file *ast.File, fn func(path string, st *ast.StructType)) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok {
+			walkStructType(spec.Name.Name, spec.Type, fn)
+			return false
+		}
+		return true
+	})
+}
+
+// walkStructType 沿类型表达式查找结构体，path 为表达式对应的路径
+func walkStructType(path string, expr ast.Expr, fn func(string, *ast.StructType)) {
+	switch t := expr.(type) {
+	case *ast.ParenExpr:
+		walkStructType(path, t.X, fn)
+	case *ast.StarExpr:
+		walkStructType(path, t.X, fn)
+	case *ast.ArrayType:
+		walkStructType(path+"[]", t.Elt, fn)
+	case *ast.MapType:
+		walkStructType(path+"[]", t.Value, fn)
+	case *ast.StructType:
+		fn(path, t)
+		for _, field := range t.Fields.List {
+			for _, name := range field.Names {
+				walkStructType(path+"."+name.Name, field.Type, fn)
+			}
+		}
+	}
+}
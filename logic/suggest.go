@@ -0,0 +1,40 @@
+package logic
+
+import "strings"
+
+// Suggestion 表示代码评审中的一条修改建议：用 Replacement 替换 StartLine 到 EndLine（含）的整行内容，
+// Original 为被替换的原文。评审建议无法表示纯插入，插入会并入相邻的一行；
+// 原文件为空（新建的文件）时 StartLine 为 1、EndLine 为 0
+type Suggestion struct {
+	Path        string `json:"path"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+}
+
+// Suggestions 把 original 改为 modified 的文本编辑转为按整行替换的评审建议
+func Suggestions(path string, original, modified []byte) []Suggestion {
+	lines := splitLines(original)
+	var suggestions []Suggestion
+	for _, e := range ComputeEdits(original, modified) {
+		s := Suggestion{Path: path, StartLine: e.Line, EndLine: e.EndLine, Replacement: e.NewText}
+		if e.EndLine < e.Line {
+			switch {
+			case e.Line > 1:
+				// 插入到上一行之后
+				s.StartLine, s.EndLine = e.Line-1, e.Line-1
+				s.Replacement = lines[e.Line-2] + e.NewText
+			case len(lines) > 0:
+				// 插入到文件开头时并入第一行
+				s.EndLine = 1
+				s.Replacement = e.NewText + lines[0]
+			}
+		}
+		if s.EndLine >= s.StartLine {
+			s.Original = strings.Join(lines[s.StartLine-1:s.EndLine], "")
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions
+}
@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Summary 汇总一次执行的结果，在执行结束时输出
+type Summary struct {
+	Rules          int
+	Skipped        int
+	Modified       int
+	Unchanged      int
+	FieldsAdded    int
+	FieldsSkipped  int
+	FieldsRemoved  int
+	MethodsRemoved int
+	TagsRenamed    int
+	Deprecated     int
+	Widened        int
+	StructsRenamed int
+	Imports        int
+	Decls          int
+	Missing        int
+	Errors         int
+	// Filtered 被 -only 和 -skip 排除的规则名称
+	Filtered []string
+	// Disabled 配置中被禁用的规则名称
+	Disabled []string
+}
+
+// WriteSummary 以两列表格输出执行汇总，数量右对齐在前，
+// 避免中文名称的显示宽度打乱对齐
+func WriteSummary(w io.Writer, s Summary) error {
+	if _, err := fmt.Fprintln(w, "执行汇总:"); err != nil {
+		return err
+	}
+	rows := []struct {
+		name  string
+		value int
+	}{
+		{"执行规则", s.Rules},
+		{"跳过规则", s.Skipped},
+		{"修改文件", s.Modified},
+		{"未变文件", s.Unchanged},
+		{"新增字段", s.FieldsAdded},
+		{"已存在字段", s.FieldsSkipped},
+		{"删除字段", s.FieldsRemoved},
+		{"删除方法", s.MethodsRemoved},
+		{"修改标签", s.TagsRenamed},
+		{"废弃字段", s.Deprecated},
+		{"放宽类型", s.Widened},
+		{"改名结构体", s.StructsRenamed},
+		{"新增导入", s.Imports},
+		{"新增声明", s.Decls},
+		{"缺失结构体", s.Missing},
+		{"错误", s.Errors},
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(w, "%6d  %s\n", row.value, row.name); err != nil {
+			return err
+		}
+	}
+	if len(s.Disabled) > 0 {
+		if _, err := fmt.Fprintf(w, "%6d  已禁用的规则: %s\n", len(s.Disabled), strings.Join(s.Disabled, ", ")); err != nil {
+			return err
+		}
+	}
+	if len(s.Filtered) > 0 {
+		if _, err := fmt.Fprintf(w, "%6d  按标签排除的规则: %s\n", len(s.Filtered), strings.Join(s.Filtered, ", ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
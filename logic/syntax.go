@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// 目标文件有语法错误时的处理方式
+const (
+	// SyntaxErrorFail 报告语法错误并中止规则（默认）
+	SyntaxErrorFail = "fail"
+	// SyntaxErrorImports 在不完整的语法树上只添加导入，跳过结构体和代码片段
+	SyntaxErrorImports = "imports"
+)
+
+// maxSyntaxErrors 错误信息中最多列出的语法错误条数
+const maxSyntaxErrors = 10
+
+// SyntaxError 文件的语法错误，逐条列出每个错误的位置，可以用 errors.As 取出 scanner.ErrorList
+type SyntaxError struct {
+	List scanner.ErrorList
+}
+
+// NewSyntaxError 把解析错误包装为 SyntaxError，不是 scanner.ErrorList 时原样返回
+func NewSyntaxError(err error) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+	// AllErrors 模式下同一行常有多条连带错误，只保留每行的第一条
+	list.RemoveMultiples()
+	return &SyntaxError{List: list}
+}
+
+func (e *SyntaxError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "文件有 %d 处语法错误:", len(e.List))
+	for i, err := range e.List {
+		if i == maxSyntaxErrors {
+			fmt.Fprintf(&b, "\n\t……其余 %d 处省略", len(e.List)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n\t%s", err)
+	}
+	return b.String()
+}
+
+// Short 返回单行的错误摘要，只包含第一处错误
+func (e *SyntaxError) Short() string {
+	if len(e.List) == 1 {
+		return e.List[0].Error()
+	}
+	return fmt.Sprintf("%s（共 %d 处语法错误）", e.List[0], len(e.List))
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.List
+}
+
+// Pos 返回第一处语法错误的位置
+func (e *SyntaxError) Pos() token.Position {
+	return e.List[0].Pos
+}
+
+// importsBeforeError 判断不完整语法树中的导入声明是否都完整地位于第一处语法错误之前，
+// 只有这样按语法树的位置编辑导入才是安全的
+func importsBeforeError(fset *token.FileSet, file *ast.File, list scanner.ErrorList) bool {
+	if file == nil || file.Name == nil || len(list) == 0 {
+		return false
+	}
+	first := list[0].Pos.Offset
+	for _, e := range list[1:] {
+		if e.Pos.Offset < first {
+			first = e.Pos.Offset
+		}
+	}
+	if fset.Position(file.Name.End()).Offset > first {
+		return false
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if fset.Position(gen.End()).Offset > first {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TagPair 表示结构体标签中的一个 key:"value" 键值对
+type TagPair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// String 返回键值对的标签写法
+func (p TagPair) String() string {
+	return p.Key + ":" + strconv.Quote(p.Value)
+}
+
+// ParseTag 按 reflect.StructTag 的规则解析标签（不含反引号），保留键的顺序
+func ParseTag(tag string) ([]TagPair, error) {
+	var pairs []TagPair
+	for tag != "" {
+		// 跳过前导空格
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// 键为非控制字符且不含空格、引号和冒号
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, fmt.Errorf("标签 %q 的语法无效", tag)
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// 值为带引号的字符串
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, fmt.Errorf("标签 %q 的值没有结束", key)
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, fmt.Errorf("标签 %q 的值无效: %v", key, err)
+		}
+		tag = tag[i+1:]
+		pairs = append(pairs, TagPair{Key: key, Value: value})
+	}
+	return pairs, nil
+}
+
+// FormatTag 将键值对拼接为标签字符串（不含反引号）
+func FormatTag(pairs []TagPair) string {
+	parts := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		parts = append(parts, p.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// FieldTags 配置中字段的标签（不含反引号）。TOML 中既可以写原始字符串，
+// 也可以写键值表 tags = { json = "user_id,omitempty", gorm = "column:user_id" }，
+// 键值表按键名排序后序列化为规范的标签字符串，值中的引号无需转义
+type FieldTags string
+
+// UnmarshalTOML 实现 toml.Unmarshaler，兼容字符串与键值表两种写法
+func (t *FieldTags) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		*t = FieldTags(v)
+	case map[string]interface{}:
+		tags, err := tagsFromMap(v)
+		if err != nil {
+			return err
+		}
+		*t = tags
+	default:
+		return fmt.Errorf("tags 必须是字符串或键值表")
+	}
+	return nil
+}
+
+// UnmarshalJSON 兼容 JSON 配置中的字符串与对象两种写法
+func (t *FieldTags) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return t.UnmarshalTOML(v)
+}
+
+// tagsFromMap 将键值表序列化为按键名排序的标签字符串
+func tagsFromMap(m map[string]interface{}) (FieldTags, error) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]TagPair, 0, len(keys))
+	for _, key := range keys {
+		value, ok := m[key].(string)
+		if !ok {
+			return "", fmt.Errorf("标签 %s 的值必须是字符串", key)
+		}
+		if key == "" || strings.ContainsAny(key, " :\"`") {
+			return "", fmt.Errorf("标签键 %q 无效", key)
+		}
+		pairs = append(pairs, TagPair{Key: key, Value: value})
+	}
+	return FieldTags(FormatTag(pairs)), nil
+}
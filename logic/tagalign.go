@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AlignTags 在格式化后的源码中将指定结构体的标签按键分列对齐。
+// 同一字段块（不被空行隔开的连续字段）中的标签按键出现次数、再按首次出现的顺序排列，
+// 每个键值对补齐到该列的最大宽度，缺少的键留空，使多键标签纵向对齐
+func AlignTags(src []byte, structs map[string]bool) ([]byte, error) {
+	if len(structs) == 0 {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+
+	var replacements []tagReplacement
+	WalkStructs(file, func(name string, structType *ast.StructType) {
+		if !structs[name] {
+			return
+		}
+
+		// 按空行将字段分块
+		var blocks [][]*ast.Field
+		lastLine := 0
+		for _, field := range structType.Fields.List {
+			start := field.Pos()
+			if field.Doc != nil {
+				start = field.Doc.Pos()
+			}
+			if len(blocks) == 0 || fset.Position(start).Line > lastLine+1 {
+				blocks = append(blocks, nil)
+			}
+			blocks[len(blocks)-1] = append(blocks[len(blocks)-1], field)
+			lastLine = fset.Position(field.End()).Line
+		}
+
+		for _, block := range blocks {
+			replacements = append(replacements, alignBlock(fset, block)...)
+		}
+	})
+	if len(replacements) == 0 {
+		return src, nil
+	}
+
+	// 从后往前替换，避免偏移量失效
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start > replacements[j].start })
+	out := string(src)
+	for _, r := range replacements {
+		out = out[:r.start] + r.text + out[r.end:]
+	}
+	return format.Source([]byte(out))
+}
+
+// tagReplacement 标签字面量的替换内容
+type tagReplacement struct {
+	start, end int
+	text       string
+}
+
+// alignBlock 计算一个字段块中各标签对齐后的文本，无法解析的标签保持不变
+func alignBlock(fset *token.FileSet, block []*ast.Field) []tagReplacement {
+	var keys []string
+	width := make(map[string]int)
+	count := make(map[string]int)
+	parsed := make(map[*ast.Field][]TagPair)
+	for _, field := range block {
+		if field.Tag == nil || !strings.HasPrefix(field.Tag.Value, "`") {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		pairs, err := ParseTag(tag)
+		if err != nil || len(pairs) == 0 {
+			continue
+		}
+		parsed[field] = pairs
+		for _, p := range pairs {
+			if _, ok := width[p.Key]; !ok {
+				keys = append(keys, p.Key)
+			}
+			count[p.Key]++
+			if w := len(p.String()); w > width[p.Key] {
+				width[p.Key] = w
+			}
+		}
+	}
+	if len(parsed) < 2 {
+		return nil
+	}
+	// 出现次数多的键排在前面，减少空列
+	sort.SliceStable(keys, func(i, j int) bool { return count[keys[i]] > count[keys[j]] })
+
+	var replacements []tagReplacement
+	for _, field := range block {
+		pairs, ok := parsed[field]
+		if !ok {
+			continue
+		}
+		byKey := make(map[string]TagPair, len(pairs))
+		for _, p := range pairs {
+			byKey[p.Key] = p
+		}
+		var sb strings.Builder
+		for i, key := range keys {
+			cell := ""
+			if p, ok := byKey[key]; ok {
+				cell = p.String()
+			}
+			sb.WriteString(cell)
+			if i < len(keys)-1 {
+				sb.WriteString(strings.Repeat(" ", width[key]-len(cell)+1))
+			}
+		}
+		text := "`" + strings.TrimRight(sb.String(), " ") + "`"
+		if text == field.Tag.Value {
+			continue
+		}
+		replacements = append(replacements, tagReplacement{
+			start: fset.Position(field.Tag.Pos()).Offset,
+			end:   fset.Position(field.Tag.End()).Offset,
+			text:  text,
+		})
+	}
+	return replacements
+}
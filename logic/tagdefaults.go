@@ -0,0 +1,107 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// TagNames 标签默认值模板可用的字段名变体
+type TagNames struct {
+	// Name 字段名，如 UserID
+	Name string
+	// Snake 蛇形命名，如 user_id
+	Snake string
+	// Camel 小驼峰命名，如 userID
+	Camel string
+	// Nullable 字段是否可空，例如 json = "{{.Snake}}{{if .Nullable}},omitempty{{end}}"
+	Nullable bool
+}
+
+// ApplyTagDefaults 把结构体的 TagDefaults 合并到每个字段的标签中。
+// 默认值是 text/template 模板，数据为 TagNames，例如 bson = "{{.Snake}}"；
+// 字段自己写了的键优先，其余默认键按 TagDefaults 的顺序追加在字段标签之后
+func ApplyTagDefaults(st *Struct) error {
+	if st.TagDefaults == "" {
+		return nil
+	}
+	defaults, err := ParseTag(string(st.TagDefaults))
+	if err != nil {
+		return fmt.Errorf("结构体 %s 的 tag_defaults 无效: %v", st.Name, err)
+	}
+	tmpls := make([]*template.Template, len(defaults))
+	for i, pair := range defaults {
+		tmpls[i], err = template.New(pair.Key).Option("missingkey=error").Parse(pair.Value)
+		if err != nil {
+			return fmt.Errorf("结构体 %s 标签 %s 的默认值模板无效: %v", st.Name, pair.Key, err)
+		}
+	}
+
+	for i := range st.Fields {
+		field := &st.Fields[i]
+		pairs, err := ParseTag(string(field.Tags))
+		if err != nil {
+			return fmt.Errorf("字段 %s.%s 的标签无效: %v", st.Name, field.Name, err)
+		}
+		names := TagNames{Name: field.Name, Snake: SnakeCase(field.Name), Camel: CamelCase(field.Name), Nullable: field.Nullable}
+		for j, pair := range defaults {
+			if hasTagPair(pairs, pair.Key) {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := tmpls[j].Execute(&buf, names); err != nil {
+				return fmt.Errorf("渲染字段 %s.%s 的 %s 标签失败: %v", st.Name, field.Name, pair.Key, err)
+			}
+			pairs = append(pairs, TagPair{Key: pair.Key, Value: buf.String()})
+		}
+		field.Tags = FieldTags(FormatTag(pairs))
+	}
+	return nil
+}
+
+// hasTagPair 判断键值对中是否已有指定的键
+func hasTagPair(pairs []TagPair, key string) bool {
+	for _, p := range pairs {
+		if p.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SnakeCase 将 Go 标识符转换为蛇形命名，连续的大写缩写视为一个单词：UserID -> user_id，HTTPServer -> http_server
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// CamelCase 将 Go 标识符转换为小驼峰命名，开头的大写缩写整体转小写：UserID -> userID，HTTPServer -> httpServer
+func CamelCase(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	// 缩写后面紧跟小写字母时，缩写的最后一个字母属于下一个单词
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,190 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// ParseType 解析配置中的类型字符串，返回不带位置信息的类型表达式，
+// 以便插入到其他文件的语法树中。支持 Go 的全部类型写法，
+// 包括通道方向（<-chan T、chan<- T）、可变参数函数（func(...T)）和多级指针（**T）
+func ParseType(typ string) (ast.Expr, error) {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return nil, fmt.Errorf("无效的类型 %q: %v", typ, err)
+	}
+	if err := checkType(expr); err != nil {
+		return nil, fmt.Errorf("无效的类型 %q: %v", typ, err)
+	}
+	clearPos(reflect.ValueOf(expr))
+	return expr, nil
+}
+
+// checkType 检查表达式是否为类型：ParseExpr 也接受 1+2、f() 这样的值表达式，
+// 可变参数 ...T 只能出现在函数类型的最后一个参数
+func checkType(expr ast.Expr) error {
+	switch t := expr.(type) {
+	case *ast.Ident, *ast.StructType, *ast.InterfaceType:
+		return nil
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return fmt.Errorf("%s 不是类型", types.ExprString(t))
+		}
+		return nil
+	case *ast.ParenExpr:
+		return checkType(t.X)
+	case *ast.StarExpr:
+		return checkType(t.X)
+	case *ast.ArrayType:
+		if _, ok := t.Len.(*ast.Ellipsis); ok {
+			return fmt.Errorf("数组长度不能使用 ...")
+		}
+		return checkType(t.Elt)
+	case *ast.MapType:
+		if err := checkType(t.Key); err != nil {
+			return err
+		}
+		return checkType(t.Value)
+	case *ast.ChanType:
+		return checkType(t.Value)
+	case *ast.FuncType:
+		if err := checkParams(t.Params, true); err != nil {
+			return err
+		}
+		return checkParams(t.Results, false)
+	case *ast.IndexExpr:
+		if err := checkType(t.X); err != nil {
+			return err
+		}
+		return checkType(t.Index)
+	case *ast.IndexListExpr:
+		if err := checkType(t.X); err != nil {
+			return err
+		}
+		for _, index := range t.Indices {
+			if err := checkType(index); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *ast.Ellipsis:
+		return fmt.Errorf("...%s 只能用作函数的最后一个参数", types.ExprString(t.Elt))
+	}
+	return fmt.Errorf("%s 不是类型", types.ExprString(expr))
+}
+
+// checkParams 检查函数类型的参数或返回值列表，variadic 表示最后一个参数允许为 ...T
+func checkParams(list *ast.FieldList, variadic bool) error {
+	if list == nil {
+		return nil
+	}
+	for i, field := range list.List {
+		if ellipsis, ok := field.Type.(*ast.Ellipsis); ok && variadic && i == len(list.List)-1 {
+			if err := checkType(ellipsis.Elt); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := checkType(field.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearPos 递归清除语法树节点中的位置信息
+func clearPos(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			clearPos(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			clearPos(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if f.Type() == reflect.TypeOf(token.NoPos) {
+				if f.CanSet() {
+					f.SetInt(0)
+				}
+				continue
+			}
+			// 不进入标识符的对象信息，避免循环引用
+			if f.Type() == reflect.TypeOf((*ast.Object)(nil)) {
+				continue
+			}
+			clearPos(f)
+		}
+	}
+}
+
+// versionSuffix 匹配导入路径末尾的主版本号，如 /v2
+var versionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// ImportBaseName 根据导入路径推断包的默认名称：取最后一段，跳过 /v2 这样的版本后缀
+func ImportBaseName(importPath string) string {
+	base := path.Base(importPath)
+	if versionSuffix.MatchString(base) {
+		base = path.Base(path.Dir(importPath))
+	}
+	return base
+}
+
+// ImportAliases 返回文件中以别名导入的包：默认包名 -> 别名。
+// 默认包名同时被其他导入直接使用时不做映射，避免改写到错误的包
+func ImportAliases(file *ast.File) map[string]string {
+	used := make(map[string]bool)
+	aliases := make(map[string]string)
+	for _, imp := range file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		base := ImportBaseName(importPath)
+		if imp.Name == nil {
+			used[base] = true
+			continue
+		}
+		switch imp.Name.Name {
+		case "_", ".":
+			continue
+		}
+		used[imp.Name.Name] = true
+		if imp.Name.Name != base {
+			aliases[base] = imp.Name.Name
+		}
+	}
+	for base := range aliases {
+		if used[base] {
+			delete(aliases, base)
+		}
+	}
+	return aliases
+}
+
+// QualifyType 将类型表达式中的包限定符替换为文件中使用的别名，例如 pb.User -> apipb.User
+func QualifyType(expr ast.Expr, aliases map[string]string) {
+	if len(aliases) == 0 {
+		return
+	}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				if alias, ok := aliases[ident.Name]; ok {
+					ident.Name = alias
+				}
+			}
+		}
+		return true
+	})
+}
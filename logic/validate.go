@@ -0,0 +1,29 @@
+package logic
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+)
+
+// ValidateField 检查字段配置的语法：名称是有效的标识符，类型（包括各构建标签下的类型）
+// 是有效的 Go 类型表达式，标签符合 reflect.StructTag 的约定
+func ValidateField(field Field) error {
+	if !token.IsIdentifier(field.Name) {
+		return fmt.Errorf("字段名 %q 不是有效的标识符", field.Name)
+	}
+	if field.Type != "" {
+		if _, err := parser.ParseExpr(field.Type); err != nil {
+			return fmt.Errorf("字段 %s 的类型 %q 无效: %v", field.Name, field.Type, err)
+		}
+	}
+	for tag, typ := range field.Types {
+		if _, err := parser.ParseExpr(typ); err != nil {
+			return fmt.Errorf("字段 %s 在构建标签 %s 下的类型 %q 无效: %v", field.Name, tag, typ, err)
+		}
+	}
+	if _, err := ParseTag(string(field.Tags)); err != nil {
+		return fmt.Errorf("字段 %s 的标签无效: %v", field.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package logic
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VariantFile 返回规则文件在构建标签下的变体文件名，如 size.go 与 wasm 得到 size_wasm.go
+func VariantFile(file, tag string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "_" + tag + ext
+}
+
+// VariantSkeleton 生成变体文件的骨架：带 //go:build 约束和包声明，包名取自同目录下的其他 Go 文件，
+// 目录中没有 Go 文件时使用目录名
+func VariantSkeleton(filename, tag string) ([]byte, error) {
+	dir := filepath.Dir(filename)
+	pkg := filepath.Base(dir)
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.PackageClauseOnly)
+		if err == nil {
+			pkg = file.Name.Name
+			break
+		}
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("无法确定变体文件 %s 的包名", filename)
+	}
+	return []byte(fmt.Sprintf("//go:build %s\n\npackage %s\n", tag, pkg)), nil
+}
+
+// expandVariants 把字段按构建标签设置了不同类型（types）的规则展开为每个标签一条规则，
+// 分别作用于 VariantFile 命名的变体文件，使各平台的文件一起更新。
+// 这些字段都设置了 type 时保留原规则，作为其他平台的默认实现；
+// 依赖原规则 ID 的规则改为依赖全部变体
+func expandVariants(rules []*Rule) ([]*Rule, error) {
+	var out []*Rule
+	renamed := make(map[string][]string)
+	for _, rule := range rules {
+		tagSet := make(map[string]bool)
+		keepBase := true
+		for _, st := range rule.Structs {
+			for _, field := range st.Fields {
+				for tag := range field.Types {
+					if !token.IsIdentifier(tag) {
+						return nil, fmt.Errorf("规则 %s 字段 %s.%s 的 types 中构建标签 %q 无效", rule.Name(), st.Name, field.Name, tag)
+					}
+					tagSet[tag] = true
+				}
+				if len(field.Types) > 0 && field.Type == "" {
+					keepBase = false
+				}
+			}
+		}
+		if len(tagSet) == 0 {
+			out = append(out, rule)
+			continue
+		}
+
+		tags := make([]string, 0, len(tagSet))
+		for tag := range tagSet {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		for _, st := range rule.Structs {
+			for _, field := range st.Fields {
+				if field.Type != "" {
+					continue
+				}
+				for _, tag := range tags {
+					if _, ok := field.Types[tag]; !ok {
+						return nil, fmt.Errorf("规则 %s 字段 %s.%s 没有设置 type，也没有设置构建标签 %s 下的类型", rule.Name(), st.Name, field.Name, tag)
+					}
+				}
+			}
+		}
+		var ids []string
+		if keepBase {
+			out = append(out, variantRule(rule, ""))
+			ids = append(ids, rule.ID)
+		}
+		for _, tag := range tags {
+			variant := variantRule(rule, tag)
+			out = append(out, variant)
+			ids = append(ids, variant.ID)
+		}
+		if rule.ID != "" {
+			renamed[rule.ID] = ids
+		}
+	}
+
+	rewrite := func(refs []string) []string {
+		var deps []string
+		for _, dep := range refs {
+			if ids, ok := renamed[dep]; ok {
+				deps = append(deps, ids...)
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+		return deps
+	}
+	for _, rule := range out {
+		rule.DependsOn = rewrite(rule.DependsOn)
+		rule.Requires = rewrite(rule.Requires)
+	}
+	return out, nil
+}
+
+// variantRule 复制规则并按构建标签选择字段类型，tag 为空时使用默认的 type
+func variantRule(rule *Rule, tag string) *Rule {
+	variant := *rule
+	variant.Imports = append([]Import(nil), rule.Imports...)
+	variant.Structs = make([]Struct, len(rule.Structs))
+	if tag != "" {
+		variant.File = VariantFile(rule.File, tag)
+		variant.Variant = tag
+		if rule.ID != "" {
+			variant.ID = rule.ID + "@" + tag
+		}
+	}
+	for i, st := range rule.Structs {
+		st.Fields = append([]Field(nil), st.Fields...)
+		for j := range st.Fields {
+			if typ, ok := st.Fields[j].Types[tag]; ok {
+				st.Fields[j].Type = typ
+			}
+			st.Fields[j].Types = nil
+		}
+		variant.Structs[i] = st
+	}
+	return &variant
+}
@@ -0,0 +1,199 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// VerifyContents 在写回之前检查修改后的全部文件：每个文件都能格式化，
+// 所在包的类型检查不出现修改前没有的错误。contents 为文件的新内容，
+// originals 为磁盘上的原始内容，新建的文件不在 originals 中
+func VerifyContents(contents, originals map[string][]byte, goVersion string) error {
+	dirs := make(map[string]bool)
+	for filename, src := range contents {
+		if _, err := format.Source(src); err != nil {
+			return fmt.Errorf("文件 %s 无法格式化: %v", filename, err)
+		}
+		dirs[filepath.Dir(filename)] = true
+	}
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted)
+
+	for _, dir := range sorted {
+		before, err := packageErrors(dir, originals, true, goVersion)
+		if err != nil {
+			return err
+		}
+		after, err := packageErrors(dir, contents, false, goVersion)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		for _, e := range before {
+			seen[e.Msg] = true
+		}
+		for _, e := range after {
+			if !seen[e.Msg] {
+				return fmt.Errorf("修改后类型检查失败: %v", e)
+			}
+		}
+	}
+	return nil
+}
+
+// packageErrors 对目录中的包做类型检查，返回类型错误。overlay 中的文件内容优先于磁盘，导入的模块内的包也是如此，
+// original 为 true 时 overlay 中没有的新文件按不存在处理
+func packageErrors(dir string, overlay map[string][]byte, original bool, goVersion string) ([]types.Error, error) {
+	fset := token.NewFileSet()
+	files, err := parsePackageDir(fset, dir, overlay, original)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+
+	var errs []types.Error
+	conf := types.Config{
+		Importer:  newOverlayImporter(fset, overlay, original, goVersion),
+		Error:     func(err error) { errs = append(errs, err.(types.Error)) },
+		GoVersion: goVersion,
+	}
+	conf.Check(files[0].Name.Name, fset, files, nil)
+	return errs, nil
+}
+
+// overlayImporter 从源码导入包：模块内在 overlay 中有文件的包按 overlay 中的新内容做类型检查，
+// 使跨包的修改（如结构体改名后改写的引用）与被引用的包一起校验；其他包交给 source 导入器
+type overlayImporter struct {
+	fset      *token.FileSet
+	overlay   map[string][]byte
+	original  bool
+	goVersion string
+	fallback  types.ImporterFrom
+	// dirs overlay 中文件所在目录的绝对路径 -> overlay 中使用的目录名
+	dirs     map[string]string
+	packages map[string]*types.Package
+}
+
+// newOverlayImporter 创建按 overlay 导入模块内包的导入器
+func newOverlayImporter(fset *token.FileSet, overlay map[string][]byte, original bool, goVersion string) *overlayImporter {
+	imp := &overlayImporter{
+		fset:      fset,
+		overlay:   overlay,
+		original:  original,
+		goVersion: goVersion,
+		fallback:  importer.ForCompiler(fset, "source", nil).(types.ImporterFrom),
+		dirs:      make(map[string]string),
+		packages:  make(map[string]*types.Package),
+	}
+	for filename := range overlay {
+		dir := filepath.Dir(filename)
+		if abs, err := filepath.Abs(dir); err == nil {
+			imp.dirs[abs] = dir
+		}
+	}
+	return imp
+}
+
+// Import 实现 types.Importer
+func (imp *overlayImporter) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, ".", 0)
+}
+
+// ImportFrom 实现 types.ImporterFrom，被导入包中的类型错误不影响导入
+func (imp *overlayImporter) ImportFrom(path, srcDir string, mode types.ImportMode) (*types.Package, error) {
+	if pkg, ok := imp.packages[path]; ok {
+		return pkg, nil
+	}
+	dir, ok := imp.moduleDir(path, srcDir)
+	if !ok {
+		return imp.fallback.ImportFrom(path, srcDir, mode)
+	}
+	files, err := parsePackageDir(imp.fset, dir, imp.overlay, imp.original)
+	if err != nil {
+		return nil, err
+	}
+	conf := types.Config{Importer: imp, Error: func(error) {}, GoVersion: imp.goVersion}
+	pkg, _ := conf.Check(path, imp.fset, files, nil)
+	imp.packages[path] = pkg
+	return pkg, nil
+}
+
+// moduleDir 返回与 srcDir 同一模块中导入路径为 path 的包在 overlay 中的目录，包不在模块中或 overlay 中没有它的文件时返回 false
+func (imp *overlayImporter) moduleDir(path, srcDir string) (string, bool) {
+	modRoot := ModuleRoot(srcDir)
+	data, err := os.ReadFile(filepath.Join(modRoot, "go.mod"))
+	if err != nil {
+		return "", false
+	}
+	module := modfile.ModulePath(data)
+	rel, ok := strings.CutPrefix(path, module)
+	if module == "" || !ok || rel != "" && !strings.HasPrefix(rel, "/") {
+		return "", false
+	}
+	abs, err := filepath.Abs(filepath.Join(modRoot, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", false
+	}
+	dir, ok := imp.dirs[abs]
+	return dir, ok
+}
+
+// parsePackageDir 解析目录中包的非测试文件，overlay 中的文件内容优先于磁盘，
+// original 为 true 时 overlay 中没有的新文件按不存在处理。无法解析的文件和包名不同的文件被跳过
+func parsePackageDir(fset *token.FileSet, dir string, overlay map[string][]byte, original bool) ([]*ast.File, error) {
+	names := make(map[string]bool)
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取目录 %s 失败: %v", dir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names[filepath.Join(dir, entry.Name())] = true
+		}
+	}
+	if !original {
+		for filename := range overlay {
+			if filepath.Dir(filename) == dir {
+				names[filename] = true
+			}
+		}
+	}
+
+	// 按文件名排序后解析，包名以第一个文件为准
+	var sorted []string
+	for filename := range names {
+		if strings.HasSuffix(filename, ".go") && !strings.HasSuffix(filename, "_test.go") {
+			sorted = append(sorted, filename)
+		}
+	}
+	sort.Strings(sorted)
+	var files []*ast.File
+	for _, filename := range sorted {
+		var src interface{}
+		if data, ok := overlay[filename]; ok {
+			src = data
+		}
+		f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		if len(files) > 0 && f.Name.Name != files[0].Name.Name {
+			continue
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
@@ -0,0 +1,14 @@
+package logic
+
+import (
+	"log"
+	"os"
+)
+
+// warnings 警告的输出，不受 -v 控制：没有 -v 时逐项日志会被丢弃，警告仍然写到标准错误
+var warnings = log.New(os.Stderr, "", log.LstdFlags)
+
+// Warnf 输出一条警告，例如规则没有匹配的文件被跳过、修改后仍需人工检查的引用
+func Warnf(format string, args ...interface{}) {
+	warnings.Printf("警告: "+format, args...)
+}
@@ -0,0 +1,558 @@
+package logic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+)
+
+// WidenField 结构体表示把已有字段的数值类型放宽为更宽的类型（如 int32 -> int64），
+// 只允许不会丢失取值的放宽。结构体的方法和构造函数中赋给该字段的类型转换、
+// getter 的返回类型和 setter 的参数类型一起改写，包中其他可能截断或无法编译的引用会被列出
+type WidenField struct {
+	Name string `json:"name" toml:"name"`
+	Type string `json:"type" toml:"type"`
+}
+
+// validate 检查放宽字段类型的配置
+func (w WidenField) validate() error {
+	if w.Name == "" || w.Type == "" {
+		return fmt.Errorf("widen 必须设置 name 和 type")
+	}
+	if _, ok := numericTypes[w.Type]; !ok {
+		return fmt.Errorf("字段 %s 放宽后的类型 %s 不是数值类型", w.Name, w.Type)
+	}
+	return nil
+}
+
+// numericType 数值类型的类别和位数，位数为 0 表示与平台相关的 int 和 uint
+type numericType struct {
+	kind byte // i 有符号整数，u 无符号整数，f 浮点数
+	bits int
+}
+
+// numericTypes 可以放宽的数值类型
+var numericTypes = map[string]numericType{
+	"int8": {'i', 8}, "int16": {'i', 16}, "int32": {'i', 32}, "rune": {'i', 32}, "int64": {'i', 64}, "int": {'i', 0},
+	"uint8": {'u', 8}, "byte": {'u', 8}, "uint16": {'u', 16}, "uint32": {'u', 32}, "uint64": {'u', 64}, "uint": {'u', 0},
+	"float32": {'f', 32}, "float64": {'f', 64},
+}
+
+// floatMantissa 浮点类型能精确表示的整数位数
+var floatMantissa = map[int]int{32: 24, 64: 53}
+
+// CanHold 判断 to 类型能否无损表示 from 类型的所有取值。int 和 uint 作为来源按 64 位、
+// 作为目标按 32 位计算，在任何平台上都成立
+func CanHold(from, to string) bool {
+	f, ok := numericTypes[from]
+	if !ok {
+		return false
+	}
+	t, ok := numericTypes[to]
+	if !ok {
+		return false
+	}
+	fromBits, toBits := f.bits, t.bits
+	if fromBits == 0 {
+		fromBits = 64
+	}
+	if toBits == 0 {
+		toBits = 32
+	}
+	switch {
+	case t.kind == 'f' && f.kind == 'f':
+		return fromBits <= toBits
+	case t.kind == 'f':
+		return fromBits <= floatMantissa[toBits]
+	case f.kind == 'f':
+		return false
+	case f.kind == t.kind:
+		return fromBits <= toBits
+	case f.kind == 'u' && t.kind == 'i':
+		return fromBits < toBits
+	}
+	return false
+}
+
+// FieldWidening 记录一个被放宽类型的字段
+type FieldWidening struct {
+	Struct string
+	Field  string
+	From   string
+	To     string
+}
+
+// String 返回 字段: 旧类型 -> 新类型 形式的描述
+func (w FieldWidening) String() string {
+	return fmt.Sprintf("%s: %s -> %s", w.Field, w.From, w.To)
+}
+
+// WidenFields 在格式化后的源码中放宽字段的类型：结构体名 -> 放宽列表。已经是目标类型的字段不做修改，
+// 不是数值类型或不能无损放宽时报错。顶层结构体的方法和构造函数中，赋给该字段的 旧类型(x) 转换、
+// 直接返回该字段的 getter 的返回类型和直接赋值给该字段的 setter 的参数类型改为新类型；
+// 构造函数和复合字面量中赋给该字段的其他值（如旧类型的参数）包上新类型的转换
+func WidenFields(src []byte, widens map[string][]WidenField) ([]byte, []FieldWidening, error) {
+	if len(widens) == 0 {
+		return src, nil, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析生成的源码失败: %v", err)
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	replace := func(node ast.Node, text string) {
+		edits = append(edits, edit{fset.Position(node.Pos()).Offset, fset.Position(node.End()).Offset, text})
+	}
+	var done []FieldWidening
+	var walkErr error
+	WalkStructs(file, func(name string, structType *ast.StructType) {
+		for _, w := range widens[name] {
+			if walkErr != nil {
+				return
+			}
+			field := findField(structType, w.Name)
+			if field == nil {
+				walkErr = WithCode(CodeMatch, fmt.Errorf("结构体 %s 中没有字段 %s", name, w.Name))
+				return
+			}
+			ident, ok := field.Type.(*ast.Ident)
+			if !ok || numericTypes[ident.Name] == (numericType{}) {
+				walkErr = fmt.Errorf("字段 %s.%s 的类型 %s 不是数值类型，无法放宽", name, w.Name, types.ExprString(field.Type))
+				return
+			}
+			if ident.Name == w.Type {
+				continue
+			}
+			if !CanHold(ident.Name, w.Type) {
+				walkErr = fmt.Errorf("字段 %s.%s 的类型 %s 不能无损放宽为 %s", name, w.Name, ident.Name, w.Type)
+				return
+			}
+			if len(field.Names) > 1 {
+				walkErr = fmt.Errorf("字段 %s.%s 与其他字段声明在同一行，无法单独放宽", name, w.Name)
+				return
+			}
+			replace(ident, w.Type)
+			idents, values := widenGenerated(file, name, w.Name, ident.Name, w.Type)
+			for _, node := range idents {
+				replace(node, w.Type)
+			}
+			for _, value := range values {
+				start, end := fset.Position(value.Pos()).Offset, fset.Position(value.End()).Offset
+				replace(value, w.Type+"("+string(src[start:end])+")")
+			}
+			done = append(done, FieldWidening{Struct: name, Field: w.Name, From: ident.Name, To: w.Type})
+		}
+	})
+	if walkErr != nil {
+		return nil, nil, walkErr
+	}
+	if len(edits) == 0 {
+		return src, nil, nil
+	}
+
+	// 从后往前修改，避免偏移量失效
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := string(src)
+	for _, e := range edits {
+		out = out[:e.start] + e.text + out[e.end:]
+	}
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		return nil, nil, err
+	}
+	return formatted, done, nil
+}
+
+// widenGenerated 返回结构体的方法和构造函数中需要改为新类型的旧类型标识符：
+// 赋给字段的类型转换、直接返回字段的 getter 的返回类型、直接赋值给字段的 setter 的参数类型；
+// 以及需要包上新类型转换的值：复合字面量和构造函数的赋值中赋给字段、不是字面量也不是转换的值
+func widenGenerated(file *ast.File, structName, fieldName, from, to string) ([]*ast.Ident, []ast.Expr) {
+	var idents []*ast.Ident
+	var values []ast.Expr
+	// needsConversion 判断赋给字段的值是否需要包上新类型的转换，常量字面量和已经转换为新类型的值不需要
+	needsConversion := func(expr ast.Expr) bool {
+		switch e := expr.(type) {
+		case *ast.BasicLit:
+			return false
+		case *ast.UnaryExpr:
+			_, ok := e.X.(*ast.BasicLit)
+			return !ok
+		case *ast.CallExpr:
+			fun, ok := e.Fun.(*ast.Ident)
+			return !ok || fun.Name != to || len(e.Args) != 1
+		}
+		return true
+	}
+	// conversion 返回 from(x) 形式的转换中的类型标识符
+	conversion := func(expr ast.Expr) *ast.Ident {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return nil
+		}
+		if fun, ok := call.Fun.(*ast.Ident); ok && fun.Name == from {
+			return fun
+		}
+		return nil
+	}
+	isField := func(expr ast.Expr, recv string) bool {
+		sel, ok := expr.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != fieldName {
+			return false
+		}
+		x, ok := sel.X.(*ast.Ident)
+		return ok && (recv == "" || x.Name == recv)
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		recv := ""
+		if fn.Recv != nil && len(fn.Recv.List) == 1 {
+			if receiverTypeName(fn.Recv.List[0].Type) != structName {
+				continue
+			}
+			if len(fn.Recv.List[0].Names) == 0 {
+				continue
+			}
+			recv = fn.Recv.List[0].Names[0].Name
+		} else if fn.Name.Name != ConstructorName(structName) {
+			continue
+		}
+
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				for i, lhs := range n.Lhs {
+					if i < len(n.Rhs) && len(n.Lhs) == len(n.Rhs) && isField(lhs, recv) {
+						if ident := conversion(n.Rhs[i]); ident != nil {
+							idents = append(idents, ident)
+						} else if recv == "" && needsConversion(n.Rhs[i]) {
+							values = append(values, n.Rhs[i])
+						}
+					}
+				}
+			case *ast.CompositeLit:
+				if typeName(n.Type) != structName {
+					return true
+				}
+				for _, elt := range n.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok && key.Name == fieldName {
+							if ident := conversion(kv.Value); ident != nil {
+								idents = append(idents, ident)
+							} else if needsConversion(kv.Value) {
+								values = append(values, kv.Value)
+							}
+						}
+					}
+				}
+			}
+			return true
+		})
+		if recv == "" {
+			continue
+		}
+
+		// getter：唯一的返回值为旧类型，函数体只有 return recv.Field
+		if results := fn.Type.Results; results != nil && len(results.List) == 1 && len(fn.Body.List) == 1 {
+			if ret, ok := fn.Body.List[0].(*ast.ReturnStmt); ok && len(ret.Results) == 1 && isField(ret.Results[0], recv) {
+				if ident, ok := results.List[0].Type.(*ast.Ident); ok && ident.Name == from {
+					idents = append(idents, ident)
+				}
+			}
+		}
+		// setter：唯一的参数为旧类型，函数体中有 recv.Field = 参数
+		if params := fn.Type.Params.List; len(params) == 1 && len(params[0].Names) == 1 {
+			ident, ok := params[0].Type.(*ast.Ident)
+			if !ok || ident.Name != from {
+				continue
+			}
+			param := params[0].Names[0].Name
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				assign, ok := n.(*ast.AssignStmt)
+				if ok && len(assign.Lhs) == 1 && len(assign.Rhs) == 1 && isField(assign.Lhs[0], recv) {
+					if value, ok := assign.Rhs[0].(*ast.Ident); ok && value.Name == param {
+						idents = append(idents, ident)
+						return false
+					}
+				}
+				return true
+			})
+		}
+	}
+	return idents, values
+}
+
+// WidenRisk 表示放宽字段类型后需要人工检查的一处引用
+type WidenRisk struct {
+	File   string
+	Line   int
+	Col    int
+	Expr   string
+	Reason string
+}
+
+// String 返回 文件:行:列 形式的位置、引用源码和原因
+func (r WidenRisk) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s（%s）", r.File, r.Line, r.Col, r.Expr, r.Reason)
+}
+
+// WidenRisks 对放宽前的包做类型检查，找出字段放宽为 to 之后有风险的引用：
+// 转换为装不下新类型的数值类型（原本无损，之后会截断），以及在要求旧类型的上下文中使用
+// （赋值、传参、返回、与旧类型的值运算，放宽后无法编译，加上转换又会截断）。
+// overlay 中的文件内容优先于磁盘；结构体所在文件 filename 中该结构体的方法和构造函数已由 WidenFields 改写，不再列出
+func WidenRisks(filename string, overlay map[string][]byte, w FieldWidening, goVersion string) ([]WidenRisk, error) {
+	fset := token.NewFileSet()
+	files, err := parsePackageDir(fset, filepath.Dir(filename), overlay, false)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{
+		Importer:  importer.ForCompiler(fset, "source", nil),
+		Error:     func(error) {},
+		GoVersion: goVersion,
+	}
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, info)
+	if pkg == nil {
+		return nil, nil
+	}
+	obj, ok := pkg.Scope().Lookup(w.Struct).(*types.TypeName)
+	if !ok {
+		return nil, nil
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil
+	}
+	var target *types.Var
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Name() == w.Field {
+			target = st.Field(i)
+		}
+	}
+	if target == nil {
+		return nil, nil
+	}
+
+	var risks []WidenRisk
+	for _, file := range files {
+		name := fset.Position(file.Pos()).Filename
+		var stack []ast.Node
+		ast.Inspect(file, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			stack = append(stack, n)
+			if fn, ok := n.(*ast.FuncDecl); ok && name == filename && isWidenedFunc(fn, w.Struct) {
+				stack = stack[:len(stack)-1]
+				return false
+			}
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok || info.Uses[sel.Sel] != target {
+				return true
+			}
+			if reason := widenRisk(info, stack, w); reason != "" {
+				pos := fset.Position(sel.Pos())
+				risks = append(risks, WidenRisk{File: name, Line: pos.Line, Col: pos.Column, Expr: types.ExprString(sel), Reason: reason})
+			}
+			return true
+		})
+	}
+	return risks, nil
+}
+
+// WidenErrors 分别对放宽前（before）和放宽后（after）的包做类型检查，返回放宽后新出现的类型错误，
+// 例如包中其他文件把字段赋给旧类型的变量或作为旧类型的参数传递。
+// filename 为结构体所在的文件，overlay 中其他文件的内容优先于磁盘
+func WidenErrors(filename string, overlay map[string][]byte, before, after []byte, goVersion string) ([]WidenRisk, error) {
+	withFile := func(src []byte) map[string][]byte {
+		files := make(map[string][]byte, len(overlay)+1)
+		for name, data := range overlay {
+			files[name] = data
+		}
+		files[filename] = src
+		return files
+	}
+	errs, err := packageErrors(filepath.Dir(filename), withFile(before), false, goVersion)
+	if err != nil {
+		return nil, err
+	}
+	// 按文件和信息计数，放宽前已有的错误不再列出
+	existing := make(map[string]int)
+	for _, e := range errs {
+		existing[e.Fset.Position(e.Pos).Filename+"\x00"+e.Msg]++
+	}
+	if errs, err = packageErrors(filepath.Dir(filename), withFile(after), false, goVersion); err != nil {
+		return nil, err
+	}
+	var risks []WidenRisk
+	for _, e := range errs {
+		pos := e.Fset.Position(e.Pos)
+		if key := pos.Filename + "\x00" + e.Msg; existing[key] > 0 {
+			existing[key]--
+			continue
+		}
+		risks = append(risks, WidenRisk{File: pos.Filename, Line: pos.Line, Col: pos.Column, Expr: e.Msg, Reason: "放宽类型后无法编译"})
+	}
+	return risks, nil
+}
+
+// isWidenedFunc 判断函数是否为 WidenFields 会改写的结构体方法或构造函数
+func isWidenedFunc(fn *ast.FuncDecl, structName string) bool {
+	if fn.Recv != nil && len(fn.Recv.List) == 1 {
+		return receiverTypeName(fn.Recv.List[0].Type) == structName
+	}
+	return fn.Name.Name == ConstructorName(structName)
+}
+
+// widenRisk 按字段引用所在的上下文判断放宽后的风险，没有风险时返回空字符串。
+// stack 为从文件到该选择器表达式的节点路径
+func widenRisk(info *types.Info, stack []ast.Node, w FieldWidening) string {
+	var expr ast.Expr = stack[len(stack)-1].(ast.Expr)
+	i := len(stack) - 2
+	for i >= 0 {
+		if paren, ok := stack[i].(*ast.ParenExpr); ok {
+			expr = paren
+			i--
+			continue
+		}
+		break
+	}
+	if i < 0 {
+		return ""
+	}
+
+	// expected 在要求某个数值类型的上下文中使用时给出原因
+	expected := func(t types.Type, context string) string {
+		if t == nil {
+			return ""
+		}
+		basic, ok := t.Underlying().(*types.Basic)
+		if !ok || basic.Info()&types.IsUntyped != 0 || CanHold(w.To, basic.Name()) {
+			return ""
+		}
+		return fmt.Sprintf("%s要求 %s，放宽为 %s 后需要转换并可能截断", context, types.TypeString(t, nil), w.To)
+	}
+
+	switch parent := stack[i].(type) {
+	case *ast.CallExpr:
+		if tv, ok := info.Types[parent.Fun]; ok && tv.IsType() {
+			basic, ok := tv.Type.Underlying().(*types.Basic)
+			if ok && CanHold(w.From, basic.Name()) && !CanHold(w.To, basic.Name()) {
+				return fmt.Sprintf("转换为 %s，放宽为 %s 后可能截断", types.TypeString(tv.Type, nil), w.To)
+			}
+			return ""
+		}
+		sig, ok := info.Types[parent.Fun].Type.(*types.Signature)
+		if !ok {
+			return ""
+		}
+		for j, arg := range parent.Args {
+			if arg != expr {
+				continue
+			}
+			params := sig.Params()
+			switch {
+			case sig.Variadic() && j >= params.Len()-1:
+				if slice, ok := params.At(params.Len() - 1).Type().(*types.Slice); ok && parent.Ellipsis == token.NoPos {
+					return expected(slice.Elem(), "参数")
+				}
+			case j < params.Len():
+				return expected(params.At(j).Type(), "参数")
+			}
+		}
+	case *ast.AssignStmt:
+		if parent.Tok == token.DEFINE || len(parent.Lhs) != len(parent.Rhs) {
+			return ""
+		}
+		for j := range parent.Rhs {
+			if parent.Rhs[j] == expr {
+				return expected(info.TypeOf(parent.Lhs[j]), "赋值目标")
+			}
+			if parent.Lhs[j] == expr {
+				rhs := info.Types[parent.Rhs[j]]
+				if rhs.Value == nil && rhs.Type != nil && !types.Identical(rhs.Type.Underlying(), types.Typ[basicKind(w.To)]) {
+					return fmt.Sprintf("赋值的值类型为 %s，放宽为 %s 后需要转换", types.TypeString(rhs.Type, nil), w.To)
+				}
+			}
+		}
+	case *ast.ValueSpec:
+		if parent.Type != nil {
+			return expected(info.TypeOf(parent.Type), "变量")
+		}
+	case *ast.ReturnStmt:
+		for j := i - 1; j >= 0; j-- {
+			var results *ast.FieldList
+			switch fn := stack[j].(type) {
+			case *ast.FuncDecl:
+				results = fn.Type.Results
+			case *ast.FuncLit:
+				results = fn.Type.Results
+			default:
+				continue
+			}
+			var resultTypes []ast.Expr
+			if results != nil {
+				for _, field := range results.List {
+					for n := max(len(field.Names), 1); n > 0; n-- {
+						resultTypes = append(resultTypes, field.Type)
+					}
+				}
+			}
+			for k, result := range parent.Results {
+				if result == expr && k < len(resultTypes) {
+					return expected(info.TypeOf(resultTypes[k]), "返回值")
+				}
+			}
+			return ""
+		}
+	case *ast.BinaryExpr:
+		if parent.Op == token.SHL || parent.Op == token.SHR {
+			return ""
+		}
+		other := parent.X
+		if other == expr {
+			other = parent.Y
+		}
+		if tv := info.Types[other]; tv.Value == nil {
+			return expected(tv.Type, "运算的另一侧")
+		}
+	}
+	return ""
+}
+
+// basicKind 返回数值类型名对应的 types.BasicKind
+func basicKind(name string) types.BasicKind {
+	for kind, t := range types.Typ {
+		if t != nil && t.Name() == name {
+			return types.BasicKind(kind)
+		}
+	}
+	switch name {
+	case "byte":
+		return types.Uint8
+	case "rune":
+		return types.Int32
+	}
+	return types.Invalid
+}
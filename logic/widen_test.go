@@ -0,0 +1,273 @@
+package logic
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCanHold(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"int32", "int64", true},
+		{"int64", "int32", false},
+		{"int8", "int", true},
+		{"int64", "int", false},
+		{"int", "int64", true},
+		{"uint32", "int64", true},
+		{"uint64", "int64", false},
+		{"int32", "uint64", false},
+		{"byte", "uint16", true},
+		{"rune", "int32", true},
+		{"int32", "float64", true},
+		{"int64", "float64", false},
+		{"int16", "float32", true},
+		{"int32", "float32", false},
+		{"float32", "float64", true},
+		{"float64", "int64", false},
+		{"string", "int64", false},
+		{"int32", "string", false},
+	}
+	for _, tt := range tests {
+		if got := CanHold(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanHold(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestWidenFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		widens  map[string][]WidenField
+		want    string
+		widened []FieldWidening
+		err     string
+	}{
+		{
+			name: "constructor getter and setter",
+			src: `package m
+
+type User struct {
+	ID int32
+}
+
+func NewUser(id int32) *User {
+	return &User{ID: id}
+}
+
+func (u *User) Reset(n int) {
+	u.ID = int32(n)
+}
+
+func (u *User) GetID() int32 {
+	return u.ID
+}
+
+func (u *User) SetID(id int32) {
+	u.ID = id
+}
+`,
+			widens: map[string][]WidenField{"User": {{Name: "ID", Type: "int64"}}},
+			want: `package m
+
+type User struct {
+	ID int64
+}
+
+func NewUser(id int32) *User {
+	return &User{ID: int64(id)}
+}
+
+func (u *User) Reset(n int) {
+	u.ID = int64(n)
+}
+
+func (u *User) GetID() int64 {
+	return u.ID
+}
+
+func (u *User) SetID(id int64) {
+	u.ID = id
+}
+`,
+			widened: []FieldWidening{{Struct: "User", Field: "ID", From: "int32", To: "int64"}},
+		},
+		{
+			name: "literal values stay unconverted",
+			src: `package m
+
+type User struct {
+	ID int32
+}
+
+func NewUser() *User {
+	return &User{ID: 1}
+}
+`,
+			widens: map[string][]WidenField{"User": {{Name: "ID", Type: "int64"}}},
+			want: `package m
+
+type User struct {
+	ID int64
+}
+
+func NewUser() *User {
+	return &User{ID: 1}
+}
+`,
+			widened: []FieldWidening{{Struct: "User", Field: "ID", From: "int32", To: "int64"}},
+		},
+		{
+			name: "already the target type",
+			src: `package m
+
+type User struct {
+	ID int64
+}
+`,
+			widens: map[string][]WidenField{"User": {{Name: "ID", Type: "int64"}}},
+			want: `package m
+
+type User struct {
+	ID int64
+}
+`,
+		},
+		{
+			name: "narrowing is rejected",
+			src: `package m
+
+type User struct {
+	ID int64
+}
+`,
+			widens: map[string][]WidenField{"User": {{Name: "ID", Type: "int32"}}},
+			err:    "int32",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, widened, err := WidenFields([]byte(tt.src), tt.widens)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want an error mentioning %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if len(widened) != len(tt.widened) {
+				t.Fatalf("widened = %v, want %v", widened, tt.widened)
+			}
+			for i := range widened {
+				if widened[i] != tt.widened[i] {
+					t.Errorf("widened[%d] = %v, want %v", i, widened[i], tt.widened[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWidenRisks(t *testing.T) {
+	user := `package m
+
+type User struct {
+	ID int32
+}
+
+func NewUser(id int32) *User {
+	return &User{ID: int32(id)}
+}
+`
+	tests := []struct {
+		name  string
+		other string
+		lines []int
+	}{
+		{
+			name: "assignment to old type",
+			other: `package m
+
+func id(u *User) int32 {
+	var n int32
+	n = u.ID
+	return n
+}
+`,
+			lines: []int{5},
+		},
+		{
+			name: "argument and return",
+			other: `package m
+
+func take(n int32) {}
+
+func id(u *User) int32 {
+	take(u.ID)
+	return u.ID
+}
+`,
+			lines: []int{6, 7},
+		},
+		{
+			name: "narrowing conversion",
+			other: `package m
+
+func id(u *User) int {
+	return int(u.ID)
+}
+`,
+			lines: []int{4},
+		},
+		{
+			name: "safe uses",
+			other: `package m
+
+func id(u *User) int64 {
+	n := u.ID
+	_ = n
+	return int64(u.ID) + 1
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{"go.mod": "module m\n\ngo 1.21\n", "user.go": user, "other.go": tt.other}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			w := FieldWidening{Struct: "User", Field: "ID", From: "int32", To: "int64"}
+			risks, err := WidenRisks(filepath.Join(dir, "user.go"), nil, w, "go1.21")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var lines []int
+			for _, risk := range risks {
+				if filepath.Base(risk.File) != "other.go" {
+					t.Errorf("unexpected risk outside other.go: %s", risk)
+				}
+				lines = append(lines, risk.Line)
+			}
+			if len(lines) != len(tt.lines) {
+				t.Fatalf("risks = %v, want lines %v", risks, tt.lines)
+			}
+			for i := range lines {
+				if lines[i] != tt.lines[i] {
+					t.Errorf("risk %d on line %d, want %d", i, lines[i], tt.lines[i])
+				}
+			}
+		})
+	}
+}
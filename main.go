@@ -1,28 +1,58 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"flag"
 
 	"github.com/afantree/astauto/logic"
-	"golang.org/x/tools/go/ast/astutil"
 )
 
 var rootPath = flag.String("path", "./", "path to the directory or file to process")
-var configPath = flag.String("conf", "./config.toml", "path to the config file")
+var configPath = flag.String("conf", "./config.toml", "path to the config file (TOML, or JSON, YAML, CUE, HCL or a Starlark script by the .json, .yaml/.yml, .cue, .hcl or .star extension), a directory whose config files are merged in name order, or an http(s) URL of a config file without extends, include, secrets or registry (http requires -conf-sha256)")
+var configChecksum = flag.String("conf-sha256", "", "expected sha256 of a remote -conf, required for http URLs; the run fails when the downloaded config differs")
+var lockTimeout = flag.Duration("lock-timeout", 30*time.Second, "how long to wait for another astauto process holding the run lock in the state directory")
+var strictConfig = flag.Bool("strict-config", true, "reject keys in a TOML config that match no setting, reporting them with their line numbers; -strict-config=false ignores them")
+var verbose = flag.Bool("v", false, "print the parsed config and per-item logs instead of only the final summary (warnings are always printed)")
+var includeGenerated = flag.Bool("include-generated", false, "when rules find their files by glob, package or struct name, also edit files with a \"Code generated ... DO NOT EDIT.\" header; they are skipped by default")
+var outputFormat = flag.String("output", "text", "output format of the check, lint and validate (text, sarif or github), report and diff-config (text or json) commands")
 
 // Usage is a replacement usage function for the flags package.
 func Usage() {
 	fmt.Fprintf(os.Stderr, "Usage of astauto:\n")
-	fmt.Fprintf(os.Stderr, "\tastauto -path directory\n")
+	fmt.Fprintf(os.Stderr, "\tastauto -path directory [-format-package] [-clean] [-v]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto -path directory [-only tag,...] [-skip tag,...]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto -path directory -suggestions\n")
+	fmt.Fprintf(os.Stderr, "\tastauto -archive module.zip [-archive-out out.zip] [-path directory]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto -hermetic -path directory -conf config.toml -out directory\n")
+	fmt.Fprintf(os.Stderr, "\tastauto check -path directory [-output text|sarif|github] [-staged-only]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto check -module example.com/dep[@version] [-path directory]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto hook install [-force]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto serve [-listen :8080]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto plan -path directory [-staged-only]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto export -path directory [-format json]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto report -path directory [-output text|json]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto lint -path directory [-output text|sarif|github] [-staged-only]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto diff-config [-output text|json] old.toml new.toml\n")
+	fmt.Fprintf(os.Stderr, "\tastauto validate -path directory [-output text|sarif|github]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto add-field -path directory -file model.go -struct User -name Email -type string [-tags 'json:\"email\"']\n")
+	fmt.Fprintf(os.Stderr, "\tastauto migrate-config [config.toml ...]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto init [-conf config.toml] [-path directory] [-force] [file.go ...]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto convert -from config.toml -to config.yaml [-overwrite]\n")
+	fmt.Fprintf(os.Stderr, "\tastauto batch -repos repos.txt [-batch-dir directory] [-patches directory] [-path directory]\n")
 	fmt.Fprintf(os.Stderr, "Flags:\n")
 	flag.PrintDefaults()
 }
@@ -31,28 +61,323 @@ func main() {
 	flag.Usage = Usage
 	flag.Parse()
 
-	// 从TOML文件解析配置
-	config, err := logic.ParseTOML(*configPath)
+	// 解析子命令，子命令之后的参数继续按全局标志解析
+	command := flag.Arg(0)
+	if command != "" {
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	logic.StrictConfig = *strictConfig
+	logic.ConfigChecksum = *configChecksum
+	logic.IncludeGenerated = *includeGenerated
+	if *modulePath != "" {
+		if err := useModule(command); err != nil {
+			log.Printf("%v", err)
+			os.Exit(1)
+		}
+	}
+	switch command {
+	case "":
+	case "check":
+		os.Exit(runCheck())
+	case "hook":
+		os.Exit(runHook(flag.Args()))
+	case "serve":
+		os.Exit(runServe())
+	case "plan":
+		os.Exit(runPlan())
+	case "export":
+		os.Exit(runExport())
+	case "report":
+		os.Exit(runReport())
+	case "lint":
+		os.Exit(runLint())
+	case "diff-config":
+		os.Exit(runDiffConfig(flag.Args()))
+	case "validate":
+		os.Exit(runValidate())
+	case "add-field":
+		os.Exit(runAddField())
+	case "migrate-config":
+		os.Exit(runMigrateConfig(flag.Args()))
+	case "init":
+		os.Exit(runInit(flag.Args()))
+	case "convert":
+		os.Exit(runConvert())
+	case "batch":
+		os.Exit(runBatch())
+	default:
+		log.Printf("未知的子命令: %s", command)
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if *hermetic {
+		if err := checkHermetic(); err != nil {
+			log.Printf("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	// 解析配置文件，按扩展名识别 TOML、JSON、YAML、CUE 或 HCL
+	config, err := logic.ParseConfig(*configPath)
 	if err != nil {
-		log.Printf("从TOML解析失败，检查根目录下面的配置")
+		log.Printf("解析配置失败，检查根目录下面的配置: %v", err)
 		os.Exit(1)
 	}
+	if *printEffectiveConfig {
+		os.Exit(writeEffectiveConfig(config))
+	}
+
+	if *archivePath != "" {
+		os.Exit(runArchive(config))
+	}
+	os.Exit(run(config))
+}
+
+// quietLogs 没有 -v 时丢弃逐项日志（添加的字段、写回的文件等），返回恢复日志输出的函数。
+// 警告由 logic.Warnf 输出，不受影响
+func quietLogs() func() {
+	if *verbose {
+		return func() {}
+	}
+	log.SetOutput(io.Discard)
+	return func() { log.SetOutput(os.Stderr) }
+}
+
+// run 执行配置中的规则并输出执行汇总，返回进程退出码：文件不存在时为 2，其他错误为 1
+func run(config *logic.Config) int {
+	// 打印解析的配置，逐项日志只在 -v 时输出，默认以执行汇总作为结果
+	if *verbose {
+		printConfig(config)
+	}
+	restore := quietLogs()
+	results, err := applyConfig(config)
+	restore()
+	// 输出文本编辑或评审建议时标准输出只留给 JSON
+	summaryOut := os.Stdout
+	if *printEdits || *printSuggestions {
+		summaryOut = os.Stderr
+	}
+	summary := summarize(results, err)
+	summary.Filtered = filteredRules(config)
+	summary.Disabled = config.Disabled
+	if err := logic.WriteSummary(summaryOut, summary); err != nil {
+		log.Printf("输出执行汇总失败: %v", err)
+	}
+	if err != nil {
+		log.Printf("%v", err)
+		if errors.Is(err, os.ErrNotExist) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// summarize 根据规则执行结果生成执行汇总
+func summarize(results []*ruleResult, err error) logic.Summary {
+	var s logic.Summary
+	files := make(map[string]bool)
+	for _, result := range results {
+		s.Rules++
+		if result.Skipped {
+			s.Skipped++
+			continue
+		}
+		if result.Modified() {
+			files[result.Filename] = true
+		} else if _, ok := files[result.Filename]; !ok {
+			files[result.Filename] = false
+		}
+		for _, edit := range result.Others {
+			files[edit.Filename] = true
+		}
+		for _, change := range result.Changes {
+			s.FieldsAdded += len(change.Added)
+			s.FieldsRemoved += len(change.Removed)
+			s.MethodsRemoved += len(change.RemovedMethods)
+			s.TagsRenamed += len(change.Renamed)
+			s.Deprecated += len(change.Deprecated)
+			s.Widened += len(change.Widened)
+			if change.RenamedTo != "" {
+				s.StructsRenamed++
+			}
+		}
+		s.FieldsSkipped += len(result.Existing)
+		s.Imports += len(result.Imports)
+		s.Decls += len(result.Decls)
+		s.Missing += len(result.Missing)
+	}
+	for _, modified := range files {
+		if modified {
+			s.Modified++
+		} else {
+			s.Unchanged++
+		}
+	}
+	if err != nil {
+		s.Errors++
+	}
+	return s
+}
 
-	// 打印解析的配置
-	printConfig(config)
+// loadConfig 解析 -conf 指定的配置，并按 -path 把规则中 file 的通配模式和 package 展开为匹配的文件
+func loadConfig() (*logic.Config, error) {
+	config, err := logic.ParseConfig(*configPath)
+	if err != nil {
+		return nil, err
+	}
+	return logic.ExpandTargets(config, *rootPath)
+}
 
+// loadSyncedConfig 与 loadConfig 相同，但先下载 registry 主题生成结构体，用于需要执行全部规则的 check
+func loadSyncedConfig() (*logic.Config, error) {
+	config, err := logic.ParseConfig(*configPath)
+	if err != nil {
+		return nil, err
+	}
+	if config, err = logic.SyncRegistry(config); err != nil {
+		return nil, err
+	}
+	return logic.ExpandTargets(config, *rootPath)
+}
+
+// applyConfig 依次执行配置中的规则并写回文件，最后记录变更日志，返回各规则的执行结果
+func applyConfig(config *logic.Config) ([]*ruleResult, error) {
+	// 下载 registry 主题生成结构体，只有实际执行规则时才访问网络
+	config, err := logic.SyncRegistry(config)
+	if err != nil {
+		return nil, err
+	}
+	// 规则中 file 的通配模式和 package 按本次的 -path 展开，同一配置可以在 batch 中对多个仓库执行
+	if config, err = logic.ExpandTargets(config, *rootPath); err != nil {
+		return nil, err
+	}
+	selected, err := ruleFilter()
+	if err != nil {
+		return nil, fmt.Errorf("获取暂存文件失败: %v", err)
+	}
+
+	// 持有运行锁期间读取和写回文件，避免与其他 astauto 进程交错写入
+	if dir, ok := stateDir(config); ok {
+		lock, err := logic.AcquireLock(dir, *lockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Release()
+	} else {
+		log.Printf("已禁用运行状态目录，不使用运行锁")
+	}
+
+	// contents 保存各文件在本次运行中的最新内容，originals 保存它们在磁盘上的原始内容，
+	// 所有规则执行完并通过安全阈值检查后才统一写回
+	contents := make(map[string][]byte)
+	originals := make(map[string][]byte)
+
+	// 删除带标记的内容，随后由规则重新生成
+	if *cleanManaged {
+		if err := cleanFiles(config, selected, contents, originals); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []*ruleResult
+	var entries []logic.ChangelogEntry
+	failed := 0
+	date := time.Now().Format("2006-01-02")
+	processed := make(map[string]bool)
 	for _, rule := range config.Rules {
+		if !selected(rule) {
+			continue
+		}
 		// 处理Go文件修改
-		if err := modifyGoFile(rule); err != nil {
-			log.Fatalf("修改Go文件失败: %v", err)
+		start := time.Now()
+		result, err := applyRule(config, rule, contents)
+		if err != nil {
+			metrics.ObserveError(errorType(err))
+			if *keepGoing && isSyntaxError(err) {
+				logic.Warnf("规则 %s 执行失败，继续处理其他规则: %v", rule.Name(), err)
+				failed++
+				continue
+			}
+			return results, fmt.Errorf("修改Go文件失败: %w", err)
+		}
+		recordResult(result, contents, originals)
+		metrics.ObserveRule(time.Since(start), result.Skipped)
+		if !result.Skipped {
+			processed[result.Filename] = true
+		}
+		results = append(results, result)
+		for _, change := range result.Changes {
+			entries = append(entries, logic.ChangelogEntry{Date: date, Rule: rule.Name(), StructChange: change})
 		}
 	}
+	metrics.ObserveProcessed(len(processed))
+
+	// 格式化修改过的包，格式化后的文件同样计入修改量并一起写回
+	if *formatPackage {
+		if _, err := formatPackages(results, originals, contents); err != nil {
+			return results, fmt.Errorf("格式化包失败: %v", err)
+		}
+	}
+
+	// 超过安全阈值时在写回之前中止
+	if err := checkLimits(originals, contents); err != nil {
+		return results, logic.WithCode(logic.CodeLimit, err)
+	}
+	if *printEdits {
+		return results, writeEdits(originals, contents)
+	}
+	if *printSuggestions {
+		return results, writeSuggestions(originals, contents)
+	}
+	if *hermetic {
+		return results, writeOutputs(results, contents)
+	}
+	write := writeFiles
+	if *atomicRun || renamesStructs(config) {
+		write = func(originals, contents map[string][]byte) error {
+			return writeFilesAtomic(config, originals, contents)
+		}
+	}
+	if err := write(originals, contents); err != nil {
+		metrics.ObserveError(errorType(err))
+		return results, fmt.Errorf("修改Go文件失败: %w", err)
+	}
+	metrics.ObserveFiles(len(changedFiles(originals, contents)))
+	if err := recordLifecycle(config, results); err != nil {
+		return results, err
+	}
+
+	// 记录变更日志
+	if config.Changelog != "" {
+		if err := logic.AppendChangelog(filepath.Join(*rootPath, config.Changelog), entries); err != nil {
+			return results, fmt.Errorf("写入变更日志失败: %v", err)
+		}
+	}
+	// 按写回后的结构体重写数据字典
+	if config.Dictionary != "" {
+		structs, err := exportStructs(config)
+		if err != nil {
+			return results, fmt.Errorf("生成数据字典失败: %v", err)
+		}
+		if err := logic.WriteDictionary(filepath.Join(*rootPath, config.Dictionary), logic.BuildDictionary(structs, config)); err != nil {
+			return results, err
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d 条规则因目标文件有语法错误未执行", failed)
+	}
+	return results, nil
 }
 
 // printConfig 打印配置信息
 func printConfig(config *logic.Config) {
 	fmt.Println("解析的配置:")
 	for _, rule := range config.Rules {
+		if rule.ID != "" {
+			fmt.Printf("规则: %s\n", rule.ID)
+		}
 		fmt.Printf("文件: %s\n", rule.File)
 		fmt.Println("导入:")
 		for _, imp := range rule.Imports {
@@ -69,140 +394,912 @@ func printConfig(config *logic.Config) {
 	}
 }
 
-// modifyGoFile 根据配置修改Go文件
-func modifyGoFile(rule *logic.Rule) error {
+// ruleResult 记录一条规则在内存中执行的结果
+type ruleResult struct {
+	Rule     *logic.Rule
+	Filename string
+	Before   []byte
+	After    []byte
+	// Skipped 规则条件不满足而被跳过
+	Skipped bool
+	Changes []logic.StructChange
+	Imports []string
+	Decls   []string
+	// Missing 规则中在文件里找不到的结构体
+	Missing []string
+	// Renamed 修改了名称的字段标签
+	Renamed []logic.TagRename
+	// Others 改写字段引用时修改的其他文件
+	Others []*fileEdit
+	// Existing 配置中已存在于结构体的字段，类型不一致时记为冲突
+	Existing []logic.PlanOp
+	// Clashes 规则中的结构体里编码后名称相同的字段
+	Clashes []logic.NameClash
+	// Regenerated 按字段重新生成而替换的声明
+	Regenerated []string
+	// Header 规则给文件添加了文件头
+	Header bool
+	// Footer 规则添加或更新了文件末尾的来源注释
+	Footer bool
+	// Partial 目标文件有语法错误，规则只添加了导入
+	Partial *logic.SyntaxError
+	// Deprecating 规则中标记为废弃且文件中存在的字段，写回后记入运行状态目录的废弃记录
+	Deprecating []logic.FieldDeprecation
+}
+
+// fileEdit 记录规则对目标文件之外的文件所做的修改
+type fileEdit struct {
+	Filename string
+	Before   []byte
+	After    []byte
+	// Reason 修改的原因，显示在 plan 中
+	Reason string
+}
+
+// Modified 返回规则执行后文件内容是否发生变化
+func (r *ruleResult) Modified() bool {
+	return !r.Skipped && (!bytes.Equal(r.Before, r.After) || len(r.Others) > 0)
+}
+
+// recordResult 将规则执行结果记入内存中的文件内容，并保留文件在磁盘上的原始内容
+func recordResult(result *ruleResult, contents, originals map[string][]byte) {
+	if !result.Modified() {
+		log.Printf("文件 %s 无需修改\n", result.Rule.File)
+		return
+	}
+	if _, ok := originals[result.Filename]; !ok {
+		originals[result.Filename] = result.Before
+	}
+	contents[result.Filename] = result.After
+	for _, edit := range result.Others {
+		if _, ok := originals[edit.Filename]; !ok {
+			originals[edit.Filename] = edit.Before
+		}
+		contents[edit.Filename] = edit.After
+		log.Printf("文件 %s 中的字段引用已改写\n", edit.Filename)
+	}
+}
+
+// writeFiles 写回内容发生变化的文件，-staged-only 时重新暂存
+func writeFiles(originals, contents map[string][]byte) error {
+	for _, filename := range changedFiles(originals, contents) {
+		if err := os.WriteFile(filename, contents[filename], 0644); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+		log.Printf("文件 %s 已成功修改并保存\n", filename)
+		if *stagedOnly {
+			if err := stageFile(filename); err != nil {
+				return fmt.Errorf("重新暂存文件失败: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// readSource 读取文件内容，优先使用本次运行中已修改过的内容
+func readSource(filename string, contents map[string][]byte) ([]byte, error) {
+	if src, ok := contents[filename]; ok {
+		return src, nil
+	}
+	src, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("文件 %s 不存在: %w", filename, os.ErrNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return src, nil
+}
+
+// applyRule 在内存中对文件执行规则，不写回磁盘。
+// contents 保存本次运行中已修改过的文件内容，优先于磁盘上的文件读取
+func applyRule(config *logic.Config, rule *logic.Rule, contents map[string][]byte) (*ruleResult, error) {
 	var filename = filepath.Join(*rootPath, rule.File)
-	// 检查文件是否存在
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		log.Printf("文件 %s 不存在", filename)
-		os.Exit(2)
+	result := &ruleResult{Rule: rule, Filename: filename}
+
+	// 读取文件内容，模型文件不存在时按清单生成骨架
+	src, err := readSource(filename, contents)
+	created := false
+	if errors.Is(err, os.ErrNotExist) && rule.Model != nil {
+		if src, err = rule.Model.Skeleton(); err == nil {
+			created = true
+			log.Printf("按模型清单创建文件 %s", rule.File)
+		}
+	} else if errors.Is(err, os.ErrNotExist) && rule.Variant != "" {
+		if src, err = logic.VariantSkeleton(filename, rule.Variant); err == nil {
+			created = true
+			log.Printf("创建构建标签 %s 的变体文件 %s", rule.Variant, rule.File)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.After = src
+	if !created {
+		result.Before = src
 	}
 
+	// 检查文件编码，非 UTF-8 文件无法无损改写
+	bom, src, err := logic.SplitBOM(src)
+	if err != nil {
+		return nil, fmt.Errorf("文件 %s 编码不受支持: %v", rule.File, err)
+	}
+	original := src
+
 	fset := token.NewFileSet()
 	// 解析Go源文件，保留注释
-	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.AllErrors)
 	if err != nil {
-		return fmt.Errorf("解析文件失败: %v", err)
+		err = logic.NewSyntaxError(err)
+		if rule.OnSyntaxError == logic.SyntaxErrorImports {
+			return applyImportsOnly(rule, result, bom, src, err)
+		}
+		return nil, fmt.Errorf("解析文件失败: %w", err)
+	}
+
+	// 检查规则的执行条件
+	fileEnv := logic.FileEnv(rule.File, file)
+	if rule.When != "" {
+		ok, err := logic.EvalBool(rule.When, fileEnv)
+		if err != nil {
+			return nil, fmt.Errorf("规则 %s 的条件求值失败: %v", rule.Name(), err)
+		}
+		if !ok {
+			log.Printf("规则 %s 的条件不满足，跳过", rule.Name())
+			result.Skipped = true
+			return result, nil
+		}
+	}
+
+	// 渲染代码片段，收集片段依赖的导入
+	imports := append([]logic.Import(nil), rule.Imports...)
+	var snippetCodes []string
+	for _, ref := range rule.ApplySnippets {
+		snippet := config.Snippets[ref.Name]
+		code, err := snippet.Render(ref.Name, ref.Args)
+		if err != nil {
+			return nil, err
+		}
+		snippetCodes = append(snippetCodes, code)
+		imports = append(imports, snippet.Imports...)
+	}
+	// 补上字段类型引用的标准库包，再按目标 Go 版本把较新的标准库包换成替代包
+	imports = append(imports, logic.InferImports(rule.Structs, imports, file, rule.GoVersion)...)
+	if imports, err = logic.GateImports(imports, rule.GoVersion); err != nil {
+		return nil, fmt.Errorf("导入不符合目标 Go 版本: %v", err)
 	}
 
-	// 添加导入
-	for _, imp := range rule.Imports {
-		path := imp.Path
-		if imp.Alias != "" {
-			// 使用别名导入
-			if !astutil.AddNamedImport(fset, file, imp.Alias, path) {
-				log.Printf("导入 %s 已经存在或不需要", path)
+	// 以文本方式添加导入以保留导入块中的注释，源码变化后重新解析
+	parsed := src
+	if rule.MergeImports {
+		var n int
+		if src, n, err = logic.MergeImports(src); err != nil {
+			return nil, fmt.Errorf("合并导入失败: %v", err)
+		}
+		if n > 0 {
+			log.Printf("已合并 %d 处导入声明", n)
+		}
+	}
+	var renames map[string]string
+	src, result.Imports, renames, err = logic.AddImports(src, imports, rule.ImportConflict)
+	if err != nil {
+		return nil, fmt.Errorf("添加导入失败: %w", err)
+	}
+	for _, path := range result.Imports {
+		log.Printf("添加导入: %s", path)
+	}
+	if !bytes.Equal(parsed, src) {
+		fset = token.NewFileSet()
+		if file, err = parser.ParseFile(fset, filename, src, parser.ParseComments); err != nil {
+			return nil, fmt.Errorf("解析文件失败: %w", err)
+		}
+	}
+
+	// 规则中的类型是别名或基于其他类型定义时，解析到实际的结构体声明
+	structs := make([]logic.Struct, len(rule.Structs))
+	for i, st := range rule.Structs {
+		name, err := logic.ResolveStruct(fset, filename, file, st.Name, rule.GoVersion)
+		if err != nil {
+			return nil, logic.WithCode(logic.CodeMatch, fmt.Errorf("规则 %s: %v", rule.Name(), err))
+		}
+		if name != st.Name {
+			log.Printf("类型 %s 解析为结构体 %s", st.Name, name)
+			st.Name = name
+		}
+		structs[i] = st
+	}
+
+	// 处理结构体，字段类型中的包名按导入别名改写
+	aliases := logic.ImportAliases(file)
+	for name, existing := range renames {
+		log.Printf("导入别名 %s 沿用文件中已有的名称 %s", name, existing)
+		aliases[name] = existing
+	}
+	var applyErr error
+	var lifecycle logic.Lifecycle
+	var removals []removal
+	removes := make(map[string][]string)
+	matched := make(map[string]bool)
+	docs := make(logic.FieldDocs)
+	inserts := make(logic.FieldInserts)
+	sorts := make(map[string]string)
+	renameTags := make(map[string][]logic.RenameTag)
+	deprecations := make(map[string][]logic.DeprecateField)
+	widens := make(map[string][]logic.WidenField)
+	var patterned []logic.Struct
+	var structRenames []logic.StructRename
+	blocks := make(logic.StructBlocks)
+	receivers := make(map[string]string)
+	methods := logic.MethodKeys(file)
+	removedMethods := make(map[string]bool)
+	// 查找匹配的结构体，规则中的名称可以是指向内联结构体的路径
+	logic.WalkStructs(file, func(path string, structType *ast.StructType) {
+		for _, st := range structs {
+			if applyErr != nil {
+				return
 			}
-			log.Printf("添加带别名的导入: %s as %s", path, imp.Alias)
-		} else {
-			// 普通导入
-			if !astutil.AddImport(fset, file, path) {
-				log.Printf("导入 %s 已经存在或不需要", path)
-			}
-			log.Printf("添加导入: %s", path)
-		}
-	}
-
-	// 处理结构体
-	astutil.Apply(file, nil, func(c *astutil.Cursor) bool {
-		n := c.Node()
-
-		// 检查节点是否为类型声明
-		if typeSpec, ok := n.(*ast.TypeSpec); ok {
-			// 查找匹配的结构体类型
-			for _, st := range rule.Structs {
-				if typeSpec.Name.Name == st.Name {
-					// 确认该类型是一个结构体
-					if structType, ok := typeSpec.Type.(*ast.StructType); ok {
-						for _, field := range st.Fields {
-							// 检查字段是否已存在
-							fieldExists := false
-							for _, existingField := range structType.Fields.List {
-								if len(existingField.Names) > 0 && existingField.Names[0].Name == field.Name {
-									fieldExists = true
-									log.Printf("字段 %s 已存在于结构体 %s 中，跳过添加\n", field.Name, st.Name)
-									break
-								}
-							}
+			if path == st.Name {
+				matched[st.Name] = true
+				// 检查结构体的执行条件
+				if st.When != "" {
+					ok, err := logic.EvalBool(st.When, logic.StructEnv(fileEnv, st.Name, structType))
+					if err != nil {
+						applyErr = fmt.Errorf("结构体 %s 的条件求值失败: %v", st.Name, err)
+						return
+					}
+					if !ok {
+						log.Printf("结构体 %s 的条件不满足，跳过", st.Name)
+						continue
+					}
+				}
+				if st.SortByTag != "" {
+					sorts[st.Name] = st.SortByTag
+				}
+				if st.Receiver != "" {
+					receivers[st.Name] = st.Receiver
+				}
+				if len(st.RenameTags) > 0 {
+					renameTags[st.Name] = append(renameTags[st.Name], st.RenameTags...)
+				}
+				if len(st.Deprecate) > 0 {
+					deprecations[st.Name] = append(deprecations[st.Name], st.Deprecate...)
+				}
+				for _, d := range st.Deprecate {
+					if logic.HasField(structType, d.Name) {
+						result.Deprecating = append(result.Deprecating, logic.FieldDeprecation{Struct: st.Name, Field: d.Name})
+					}
+				}
+				if len(st.Widen) > 0 {
+					widens[st.Name] = append(widens[st.Name], st.Widen...)
+				}
+				// 构造函数已存在时 AppendDecls 会跳过
+				if st.Constructor {
+					snippetCodes = append(snippetCodes, logic.ConstructorSource(st))
+				}
+				if st.Pattern != "" {
+					snippetCodes = append(snippetCodes, logic.PatternSource(st))
+					patterned = append(patterned, st)
+				}
+				if st.RenameTo != "" {
+					structRenames = append(structRenames, logic.StructRename{Struct: st.Name, To: st.RenameTo})
+				}
+				change := logic.StructChange{Struct: st.Name, Line: fset.Position(structType.Pos()).Line}
+				fields := st.Fields
+				if st.Block != "" {
+					// 托管块中的字段打印后整体替换，新增和删除的字段由 SyncBlocks 统计
+					block, err := structBlock(st, aliases, rule.GoVersion)
+					if err != nil {
+						applyErr = err
+						return
+					}
+					blocks.Add(st.Name, block)
+					fields = nil
+				}
+				for _, field := range fields {
+					// 检查字段是否已存在
+					fieldExists := containsString(change.Added, field.Name)
+					for _, existingField := range structType.Fields.List {
+						if len(existingField.Names) > 0 && existingField.Names[0].Name == field.Name {
+							fieldExists = true
+							log.Printf("字段 %s 已存在于结构体 %s 中，跳过添加\n", field.Name, st.Name)
+							result.Existing = append(result.Existing, existingOp(st.Name, field, existingField, aliases, rule.GoVersion))
+							break
+						}
+					}
 
-							// 如果字段不存在，则添加新字段
-							if !fieldExists {
-								// 创建新字段
-								newField := &ast.Field{
-									Names: []*ast.Ident{ast.NewIdent(field.Name)},
-								}
+					// 如果字段不存在，则添加新字段
+					if !fieldExists {
+						newField, err := buildField(field, aliases, rule.GoVersion)
+						if err != nil {
+							applyErr = err
+							return
+						}
 
-								// 设置字段类型
-								if field.Type[0] == '*' {
-									// 指针类型
-									parts := parseTypeParts(field.Type[1:])
-									if len(parts) == 2 {
-										newField.Type = &ast.StarExpr{
-											X: &ast.SelectorExpr{
-												X:   ast.NewIdent(parts[0]),
-												Sel: ast.NewIdent(parts[1]),
-											},
-										}
-									} else {
-										newField.Type = &ast.StarExpr{
-											X: ast.NewIdent(field.Type[1:]),
-										}
-									}
-								} else {
-									// 普通类型
-									parts := parseTypeParts(field.Type)
-									if len(parts) == 2 {
-										newField.Type = &ast.SelectorExpr{
-											X:   ast.NewIdent(parts[0]),
-											Sel: ast.NewIdent(parts[1]),
-										}
-									} else {
-										newField.Type = ast.NewIdent(field.Type)
-									}
-								}
+						// 登记字段文档注释，打印后再插入
+						docs.Add(st.Name, field.Name, field.DocLines())
 
-								// 设置字段标签
-								if field.Tags != "" {
-									newField.Tag = &ast.BasicLit{
-										Kind:  token.STRING,
-										Value: "`" + field.Tags + "`",
-									}
-								}
+						// 打印后插入到结构体末尾或锚点注释之后
+						line := logic.FieldSource(newField)
+						if rule.Managed {
+							line += " " + logic.ManagedComment(rule.Name())
+						}
+						inserts.Add(st.Name, st.Anchor, line)
+						change.Added = append(change.Added, field.Name)
+						log.Printf("成功添加字段 %s 到结构体 %s\n", field.Name, st.Name)
+					}
+				}
 
-								// 将新字段追加到结构体字段列表的末尾
-								structType.Fields.List = append(structType.Fields.List, newField)
-								log.Printf("成功添加字段 %s 到结构体 %s\n", field.Name, st.Name)
+				// 登记需要删除的字段，打印后删除，引用在生成源码后统一审计
+				for _, rm := range st.Remove {
+					if logic.HasField(structType, rm.Name) && !containsString(removes[st.Name], rm.Name) {
+						// 按废弃流程检查字段能否删除
+						if config.Deprecation.Enabled() {
+							if lifecycle == nil {
+								if lifecycle, applyErr = loadLifecycle(config); applyErr != nil {
+									return
+								}
+							}
+							if applyErr = lifecycle.CheckRemoval(config.Deprecation, rule.File, st.Name, rm.Name, time.Now()); applyErr != nil {
+								return
 							}
 						}
+						removes[st.Name] = append(removes[st.Name], rm.Name)
+						change.Removed = append(change.Removed, rm.Name)
+						removals = append(removals, removal{structName: st.Name, field: rm})
+						log.Printf("已从结构体 %s 删除字段 %s\n", st.Name, rm.Name)
+					}
+				}
+
+				// 登记需要删除的方法
+				for _, name := range st.RemoveMethods {
+					key := st.Name + "." + name
+					if methods[key] && !removedMethods[key] {
+						removedMethods[key] = true
+						change.RemovedMethods = append(change.RemovedMethods, name)
+						log.Printf("已删除结构体 %s 的方法 %s\n", st.Name, name)
 					}
 				}
+				if len(change.Added) > 0 || len(change.Removed) > 0 || len(change.RemovedMethods) > 0 {
+					result.Changes = append(result.Changes, change)
+				}
 			}
 		}
-		return true
 	})
+	if applyErr != nil {
+		return nil, applyErr
+	}
+	for _, st := range structs {
+		if matched[st.Name] {
+			continue
+		}
+		if st.Create {
+			// 创建结构体，随代码片段一起追加到文件末尾
+			code, err := structSource(st, aliases, rule.GoVersion)
+			if err != nil {
+				return nil, err
+			}
+			snippetCodes = append(snippetCodes, code)
+			if st.Constructor {
+				snippetCodes = append(snippetCodes, logic.ConstructorSource(st))
+			}
+			change := logic.StructChange{Struct: st.Name}
+			for _, field := range st.Fields {
+				change.Added = append(change.Added, field.Name)
+			}
+			result.Changes = append(result.Changes, change)
+			matched[st.Name] = true
+			continue
+		}
+		if st.RenameTo != "" && logic.DeclaresType(file, st.RenameTo) {
+			log.Printf("结构体 %s 已改名为 %s，配置中的 name 可以改为新名称", st.Name, st.RenameTo)
+			continue
+		}
+		log.Printf("文件 %s 中没有找到结构体 %s", rule.File, st.Name)
+		result.Missing = append(result.Missing, st.Name)
+	}
 
-	// 将修改后的 AST 写回文件
-	outputFile, err := os.Create(filename)
+	// 使用 go/format 格式化输出，确保代码符合 gofmt 规范
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, logic.WithCode(logic.CodeFormat, fmt.Errorf("格式化文件失败: %v", err))
+	}
+
+	// 删除字段
+	src, err = logic.RemoveFields(buf.Bytes(), removes)
 	if err != nil {
-		return fmt.Errorf("创建输出文件失败: %v", err)
+		return nil, fmt.Errorf("删除字段失败: %v", err)
 	}
-	defer outputFile.Close()
 
-	// 使用 go/format 格式化输出，确保代码符合 gofmt 规范
-	if err := format.Node(outputFile, fset, file); err != nil {
-		return fmt.Errorf("格式化并写入文件失败: %v", err)
+	// 删除方法
+	src, err = logic.RemoveMethods(src, removedMethods)
+	if err != nil {
+		return nil, fmt.Errorf("删除方法失败: %v", err)
+	}
+
+	// 统一方法接收者的名称
+	var receiverRenames []logic.ReceiverRename
+	src, receiverRenames, err = logic.NormalizeReceivers(src, receivers)
+	if err != nil {
+		return nil, fmt.Errorf("统一接收者名称失败: %v", err)
+	}
+	for _, rn := range receiverRenames {
+		log.Printf("方法 %s.%s 的接收者 %s 已改名为 %s", rn.Struct, rn.Method, rn.Old, rn.New)
+		change := structChange(result, rn.Struct)
+		change.Receivers = append(change.Receivers, fmt.Sprintf("%s: %s -> %s", rn.Method, rn.Old, rn.New))
+	}
+
+	// 修改已有字段的标签名称
+	src, result.Renamed, err = logic.RenameTags(src, renameTags)
+	if err != nil {
+		return nil, fmt.Errorf("修改标签失败: %v", err)
+	}
+	for _, rn := range result.Renamed {
+		log.Printf("已修改字段标签 %s", rn)
+		addRenamed(result, rn)
+	}
+
+	// 标记废弃字段
+	var deprecated []logic.FieldDeprecation
+	src, deprecated, err = logic.DeprecateFields(src, deprecations)
+	if err != nil {
+		return nil, fmt.Errorf("废弃字段失败: %w", err)
+	}
+	for _, d := range deprecated {
+		log.Printf("已将字段 %s.%s 标记为废弃", d.Struct, d.Field)
+		change := structChange(result, d.Struct)
+		change.Deprecated = append(change.Deprecated, d.Field)
+	}
+
+	// 放宽字段的数值类型，保留放宽前的内容用于检查放宽后新出现的编译错误
+	var widened []logic.FieldWidening
+	unwidened := src
+	src, widened, err = logic.WidenFields(src, widens)
+	if err != nil {
+		return nil, fmt.Errorf("放宽字段类型失败: %w", err)
+	}
+	for _, w := range widened {
+		log.Printf("已将字段 %s.%s 的类型从 %s 放宽为 %s", w.Struct, w.Field, w.From, w.To)
+		change := structChange(result, w.Struct)
+		change.Widened = append(change.Widened, w.String())
+	}
+
+	// 插入新字段
+	src, err = logic.InsertFields(src, inserts)
+	if err != nil {
+		return nil, fmt.Errorf("插入字段失败: %w", err)
+	}
+
+	// 插入新字段的文档注释
+	src, err = logic.InsertFieldDocs(src, docs)
+	if err != nil {
+		return nil, fmt.Errorf("插入字段注释失败: %v", err)
+	}
+
+	// 重新生成托管块
+	var blockChanges []logic.StructChange
+	src, blockChanges, err = logic.SyncBlocks(src, blocks)
+	if err != nil {
+		return nil, fmt.Errorf("同步托管块失败: %w", err)
+	}
+	for _, bc := range blockChanges {
+		log.Printf("已同步结构体 %s 的托管块，新增字段 %v，删除字段 %v", bc.Struct, bc.Added, bc.Removed)
+		change := structChange(result, bc.Struct)
+		change.Line = bc.Line
+		change.Added = append(change.Added, bc.Added...)
+		change.Removed = append(change.Removed, bc.Removed...)
+	}
+
+	// 按标签数值排序字段
+	src, err = logic.SortFieldsByTag(src, sorts)
+	if err != nil {
+		return nil, fmt.Errorf("排序字段失败: %v", err)
+	}
+
+	// 统一空接口的写法
+	if rule.NormalizeAny != "" {
+		var scope map[string]bool
+		if rule.NormalizeAnyScope != "file" {
+			scope = make(map[string]bool)
+			for _, st := range structs {
+				scope[st.Name] = matched[st.Name]
+			}
+		}
+		var n int
+		src, n, err = logic.NormalizeAny(src, rule.NormalizeAny, scope)
+		if err != nil {
+			return nil, fmt.Errorf("统一空接口写法失败: %v", err)
+		}
+		if n > 0 {
+			log.Printf("已将 %d 处空接口改写为 %s", n, rule.NormalizeAny)
+		}
+	}
+
+	// 对齐规则中各结构体的标签
+	if rule.TagFormat == "align" {
+		aligned := make(map[string]bool)
+		for _, st := range structs {
+			aligned[st.Name] = matched[st.Name]
+		}
+		src, err = logic.AlignTags(src, aligned)
+		if err != nil {
+			return nil, fmt.Errorf("对齐标签失败: %v", err)
+		}
+	}
+
+	// 追加代码片段中尚不存在的声明
+	for _, code := range snippetCodes {
+		if code, err = logic.GateCode(code, rule.GoVersion); err != nil {
+			return nil, fmt.Errorf("代码片段不符合目标 Go 版本: %v", err)
+		}
+		if rule.Managed {
+			if code, err = logic.MarkDecls(code, rule.Name()); err != nil {
+				return nil, err
+			}
+		}
+		var added []string
+		src, added, err = logic.AppendDecls(src, code)
+		if err != nil {
+			return nil, fmt.Errorf("插入代码片段失败: %v", err)
+		}
+		for _, name := range added {
+			log.Printf("成功插入声明 %s", name)
+		}
+		result.Decls = append(result.Decls, added...)
+	}
+
+	// 按结构体的模式改写已有的构造函数和方法
+	for _, st := range patterned {
+		var patched []string
+		if src, patched, err = logic.PatchPattern(src, st); err != nil {
+			return nil, fmt.Errorf("按模式 %s 改写结构体 %s 失败: %v", st.Pattern, st.Name, err)
+		}
+		for _, name := range patched {
+			log.Printf("已按模式 %s 更新 %s", st.Pattern, name)
+		}
+		result.Regenerated = append(result.Regenerated, patched...)
+	}
+
+	// 按结构体当前的字段重新生成 ToMap、FromMap、getter、setter 和列名声明
+	if err := syncGeneratedDecls(rule, result, &src); err != nil {
+		return nil, err
+	}
+
+	// 被删除的字段或方法是某个导入唯一的使用者时一起删除该导入，否则文件无法编译。
+	// 在插入字段和追加声明之后按最终的源码判断，新增的字段仍在使用的导入保留
+	if len(removes) > 0 || len(removedMethods) > 0 {
+		var pruned []string
+		if src, pruned, err = logic.PruneImports(buf.Bytes(), src); err != nil {
+			return nil, fmt.Errorf("删除未使用的导入失败: %v", err)
+		}
+		for _, path := range pruned {
+			log.Printf("删除不再使用的导入: %s", path)
+		}
+	}
+
+	// 审计被删除字段的引用
+	for _, rm := range removals {
+		src, err = auditRemoval(result, rm, src, contents)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 列出放宽类型后可能截断或无法编译的引用
+	if len(widened) > 0 {
+		if err := auditWidening(result, widened, original, unwidened, src, contents); err != nil {
+			return nil, err
+		}
+	}
+
+	// 改写允许的包中引用旧标签名称的字符串
+	for _, rn := range result.Renamed {
+		src, err = renameLiterals(result, rn, src, contents)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 检查规则中的结构体是否有 json 或 yaml 名称相同的字段
+	if result.Clashes, err = logic.DuplicateNames(src, matched); err != nil {
+		return nil, fmt.Errorf("检查字段名称失败: %v", err)
+	}
+	for _, clash := range result.Clashes {
+		logic.Warnf("%s，编码时这些字段会被忽略", clash)
+	}
+
+	// 结构体改名，连同派生的声明和其他文件中的引用
+	for _, rn := range structRenames {
+		if src, err = renameStruct(result, rn, src, contents); err != nil {
+			return nil, err
+		}
+	}
+
+	// 以文本编辑的形式应用修改，未被触及的代码保持原有的空白和对齐
+	if rule.PreserveFormat {
+		src = logic.PreserveFormat(original, src)
+	}
+
+	// 给修改过的文件（scope = "matched" 时为所有目标文件）补上文件头
+	if config.Header.Scope == logic.HeaderMatched || created || !bytes.Equal(original, src) {
+		if src, result.Header, err = logic.EnsureHeader(src, config.Header); err != nil {
+			return nil, fmt.Errorf("添加文件头失败: %v", err)
+		}
+		if result.Header {
+			log.Printf("已添加文件头")
+		}
+	}
+
+	// 在文件末尾记录维护它的规则和配置
+	if config.Provenance.Enabled() && (config.Provenance.Scope == logic.HeaderMatched || created || !bytes.Equal(original, src)) {
+		if src, result.Footer, err = ensureProvenance(config, rule, src); err != nil {
+			return nil, fmt.Errorf("添加来源注释失败: %v", err)
+		}
+		if result.Footer {
+			log.Printf("已更新来源注释")
+		}
+	}
+
+	// 保留原文件的 UTF-8 BOM
+	result.After = append(bom, src...)
+	return result, nil
+}
+
+// existingOp 比较已存在字段与配置中的类型，类型不同时记为冲突
+func existingOp(structName string, field logic.Field, existing *ast.Field, aliases map[string]string, goVersion string) logic.PlanOp {
+	op := logic.PlanOp{Action: logic.PlanSkipExists, Target: structName + "." + field.Name}
+	want, err := buildField(field, aliases, goVersion)
+	if err != nil {
+		return op
+	}
+	if got, typ := types.ExprString(existing.Type), types.ExprString(want.Type); got != typ {
+		op.Action = logic.PlanConflict
+		op.Detail = fmt.Sprintf("文件中类型为 %s，配置为 %s", got, typ)
+	}
+	return op
+}
+
+// removal 记录一次字段删除
+type removal struct {
+	structName string
+	field      logic.RemoveField
+}
+
+// auditRemoval 扫描被删除字段的引用，按配置报错、列出或改写，返回改写后的目标文件源码
+func auditRemoval(result *ruleResult, rm removal, src []byte, contents map[string][]byte) ([]byte, error) {
+	files, err := logic.AuditFiles(*rootPath, filepath.Dir(result.Filename), rm.field.Scope, result.Rule.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("查找需要审计的文件失败: %v", err)
+	}
+
+	var all []logic.FieldRef
+	for _, filename := range files {
+		fileSrc := src
+		other := filepath.Clean(filename) != filepath.Clean(result.Filename)
+		if other {
+			if fileSrc, err = readSource(filename, contents); err != nil {
+				return nil, err
+			}
+		}
+		refs, err := logic.FindFieldRefs(filename, fileSrc, rm.structName, rm.field.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		all = append(all, refs...)
+		if rm.field.Audit != "rewrite" {
+			continue
+		}
+
+		out, err := logic.RewriteFieldRefs(fileSrc, refs, rm.field.Replacement)
+		if err != nil {
+			return nil, fmt.Errorf("改写字段 %s.%s 的引用失败: %v", rm.structName, rm.field.Name, err)
+		}
+		if other {
+			result.Others = append(result.Others, &fileEdit{Filename: filename, Before: fileSrc, After: out, Reason: "改写被删除字段的引用"})
+		} else {
+			src = out
+		}
+		log.Printf("已改写 %s 中 %d 处对字段 %s 的引用", filename, len(refs), rm.field.Name)
+	}
+	if len(all) == 0 || rm.field.Audit == "rewrite" {
+		return src, nil
+	}
+
+	var lines []string
+	for _, ref := range all {
+		lines = append(lines, "  "+ref.String())
+	}
+	if rm.field.Audit == "list" {
+		logic.Warnf("被删除的字段 %s.%s 仍有 %d 处引用:\n%s", rm.structName, rm.field.Name, len(all), strings.Join(lines, "\n"))
+		return src, nil
 	}
+	return nil, fmt.Errorf("被删除的字段 %s.%s 仍有 %d 处引用:\n%s", rm.structName, rm.field.Name, len(all), strings.Join(lines, "\n"))
+}
 
-	log.Printf("文件 %s 已成功修改并保存\n", rule.File)
+// auditWidening 在放宽前的包中查找字段放宽类型后有风险的引用并输出警告，再对放宽后的包做类型检查，
+// 出现放宽前没有的编译错误（如其他函数中以旧类型的值初始化字段）时返回错误，规则失败。
+// original 为目标文件修改前的内容，unwidened 和 src 分别为目标文件放宽前和当前的内容
+func auditWidening(result *ruleResult, widened []logic.FieldWidening, original, unwidened, src []byte, contents map[string][]byte) error {
+	overlay := make(map[string][]byte, len(contents)+1)
+	for filename, src := range contents {
+		overlay[filename] = src
+	}
+	overlay[result.Filename] = original
+	for _, w := range widened {
+		risks, err := logic.WidenRisks(result.Filename, overlay, w, result.Rule.GoVersion)
+		if err != nil {
+			return fmt.Errorf("检查字段 %s.%s 的引用失败: %v", w.Struct, w.Field, err)
+		}
+		if len(risks) == 0 {
+			continue
+		}
+		lines := make([]string, len(risks))
+		for i, risk := range risks {
+			lines[i] = "  " + risk.String()
+		}
+		logic.Warnf("字段 %s.%s 放宽为 %s 后有 %d 处引用需要检查:\n%s", w.Struct, w.Field, w.To, len(risks), strings.Join(lines, "\n"))
+	}
+
+	risks, err := logic.WidenErrors(result.Filename, overlay, unwidened, src, result.Rule.GoVersion)
+	if err != nil {
+		return fmt.Errorf("检查放宽类型后的包失败: %v", err)
+	}
+	if len(risks) > 0 {
+		lines := make([]string, len(risks))
+		for i, risk := range risks {
+			lines[i] = "  " + risk.String()
+		}
+		return fmt.Errorf("放宽字段类型后包中有 %d 处无法编译，需要先修改这些引用:\n%s", len(risks), strings.Join(lines, "\n"))
+	}
 	return nil
 }
 
-// parseTypeParts 解析类型字符串，返回包名和类型名（如果有）
-func parseTypeParts(typeStr string) []string {
-	for i, char := range typeStr {
-		if char == '.' {
-			return []string{typeStr[:i], typeStr[i+1:]}
+// addRenamed 把标签改名记入对应结构体的变更
+func addRenamed(result *ruleResult, rn logic.TagRename) {
+	change := structChange(result, rn.Struct)
+	change.Renamed = append(change.Renamed, fmt.Sprintf("%s %s: %s -> %s", rn.Field, rn.Key, rn.Old, rn.Name))
+}
+
+// structChange 返回结构体在规则结果中的变更记录，没有时新建一条
+func structChange(result *ruleResult, name string) *logic.StructChange {
+	for i := range result.Changes {
+		if result.Changes[i].Struct == name {
+			return &result.Changes[i]
+		}
+	}
+	result.Changes = append(result.Changes, logic.StructChange{Struct: name})
+	return &result.Changes[len(result.Changes)-1]
+}
+
+// renameLiterals 在标签改名配置的 literals 包中把值为旧名称的字符串字面量改为新名称，返回改写后的目标文件源码
+func renameLiterals(result *ruleResult, rn logic.TagRename, src []byte, contents map[string][]byte) ([]byte, error) {
+	for _, dir := range rn.Literals {
+		files, err := logic.AuditFiles(*rootPath, filepath.Join(*rootPath, dir), "package", nil)
+		if err != nil {
+			return nil, fmt.Errorf("查找目录 %s 中的文件失败: %v", dir, err)
+		}
+		for _, filename := range files {
+			// 同一文件可能已被本规则的其他步骤修改过
+			var fileSrc []byte
+			edit := otherEdit(result, filename)
+			self := filepath.Clean(filename) == filepath.Clean(result.Filename)
+			switch {
+			case self:
+				fileSrc = src
+			case edit != nil:
+				fileSrc = edit.After
+			default:
+				if fileSrc, err = readSource(filename, contents); err != nil {
+					return nil, err
+				}
+			}
+			out, n, err := logic.RenameLiterals(filename, fileSrc, rn.Old, rn.Name)
+			if err != nil {
+				return nil, fmt.Errorf("改写字段 %s.%s 的 %s 名称失败: %v", rn.Struct, rn.Field, rn.Key, err)
+			}
+			if n == 0 {
+				continue
+			}
+			switch {
+			case self:
+				src = out
+			case edit != nil:
+				edit.After = out
+			default:
+				result.Others = append(result.Others, &fileEdit{Filename: filename, Before: fileSrc, After: out, Reason: "改写引用旧标签名称的字符串"})
+			}
+			log.Printf("已改写 %s 中 %d 处字符串 %q 为 %q", filename, n, rn.Old, rn.Name)
+		}
+	}
+	return src, nil
+}
+
+// otherEdit 返回规则对目标文件之外的某个文件已做的修改
+func otherEdit(result *ruleResult, filename string) *fileEdit {
+	for _, edit := range result.Others {
+		if filepath.Clean(edit.Filename) == filepath.Clean(filename) {
+			return edit
+		}
+	}
+	return nil
+}
+
+// buildField 根据配置创建字段节点，类型中的包名按文件中的导入别名改写
+func buildField(field logic.Field, aliases map[string]string, goVersion string) (*ast.Field, error) {
+	// 创建新字段
+	newField := &ast.Field{
+		Names: []*ast.Ident{ast.NewIdent(field.Name)},
+	}
+
+	// 设置字段类型
+	typ, err := logic.ParseType(field.Type)
+	if err != nil {
+		return nil, fmt.Errorf("字段 %s: %v", field.Name, err)
+	}
+	logic.QualifyType(typ, aliases)
+	if typ, err = logic.GateType(typ, goVersion); err != nil {
+		return nil, fmt.Errorf("字段 %s: %v", field.Name, err)
+	}
+	newField.Type = typ
+
+	// 设置字段标签
+	if tags := field.TagValue(); tags != "" {
+		newField.Tag = &ast.BasicLit{
+			Kind:  token.STRING,
+			Value: "`" + tags + "`",
+		}
+	}
+	return newField, nil
+}
+
+// structSource 生成新结构体声明的源码
+func structSource(st logic.Struct, aliases map[string]string, goVersion string) (string, error) {
+	var sb strings.Builder
+	for _, line := range st.DocLines() {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("type " + st.Name + " struct {\n")
+	block, err := structBlock(st, aliases, goVersion)
+	if err != nil {
+		return "", err
+	}
+	begin, end := logic.BlockComments(st.Block)
+	if st.Block != "" {
+		sb.WriteString("\t" + begin + "\n")
+	}
+	for _, line := range block.Lines {
+		sb.WriteString("\t" + line + "\n")
+	}
+	if st.Block != "" {
+		sb.WriteString("\t" + end + "\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// structBlock 按结构体配置生成字段的源码行，设置了 block 时即为托管块的内容
+func structBlock(st logic.Struct, aliases map[string]string, goVersion string) (logic.StructBlock, error) {
+	block := logic.StructBlock{Name: st.Block}
+	for _, field := range st.Fields {
+		newField, err := buildField(field, aliases, goVersion)
+		if err != nil {
+			return logic.StructBlock{}, err
+		}
+		block.Fields = append(block.Fields, field.Name)
+		block.Lines = append(block.Lines, field.DocLines()...)
+		block.Lines = append(block.Lines, logic.FieldSource(newField))
+	}
+	return block, nil
+}
+
+// containsString 判断字符串切片中是否包含指定值
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
 		}
 	}
-	return []string{typeStr}
+	return false
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// runMigrateConfig 执行 migrate-config 子命令：把给出的配置文件（默认为 -conf）改写为当前的配置版本，
+// 返回进程退出码
+func runMigrateConfig(files []string) int {
+	if len(files) == 0 {
+		files = []string{*configPath}
+	}
+	code := 0
+	for _, filename := range files {
+		version, err := logic.MigrateConfigFile(filename)
+		if err != nil {
+			log.Printf("迁移配置 %s 失败: %v", filename, err)
+			code = 1
+			continue
+		}
+		if version == logic.ConfigVersion {
+			log.Printf("配置 %s 已是版本 %d", filename, version)
+			continue
+		}
+		log.Printf("已把配置 %s 从版本 %d 迁移到版本 %d", filename, version, logic.ConfigVersion)
+	}
+	return code
+}
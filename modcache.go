@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var modulePath = flag.String("module", "", "with check, plan, lint, validate, export or report, analyze a dependency in the module cache read-only: module path (resolved from the go.mod under -path) or path@version; rule files are relative to the module root")
+
+// readOnlyCommands 可以对模块缓存中的依赖执行的只读子命令
+var readOnlyCommands = map[string]bool{"check": true, "plan": true, "lint": true, "validate": true, "export": true, "report": true}
+
+// useModule 把处理目录换成依赖在模块缓存中的目录，只允许只读的子命令，
+// 避免改写模块缓存中共享的只读文件
+func useModule(command string) error {
+	if !readOnlyCommands[command] {
+		return fmt.Errorf("-module 只能用于 check、plan、lint、validate、export 和 report 子命令")
+	}
+	dir, err := moduleDir(*rootPath, *modulePath)
+	if err != nil {
+		return err
+	}
+	*rootPath = dir
+	return nil
+}
+
+// moduleDir 返回模块在模块缓存中的目录。不带版本时按 dir 下 go.mod 的依赖解析，
+// 带版本时按需下载
+func moduleDir(dir, module string) (string, error) {
+	args := []string{"list", "-m", "-json", module}
+	if strings.Contains(module, "@") {
+		args = []string{"mod", "download", "-json", module}
+	}
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("查找模块 %s 失败: %v %s", module, err, strings.TrimSpace(stderr.String()))
+	}
+	var info struct {
+		Dir   string
+		Error interface{}
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return "", fmt.Errorf("查找模块 %s 失败: %v", module, err)
+	}
+	if info.Error != nil {
+		return "", fmt.Errorf("查找模块 %s 失败: %v", module, info.Error)
+	}
+	if info.Dir == "" {
+		return "", fmt.Errorf("模块 %s 不在模块缓存中，先执行 go mod download %s", module, module)
+	}
+	return info.Dir, nil
+}
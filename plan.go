@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// runPlan 执行 plan 子命令：在内存中执行所有规则，列出每条规则在其文件上将要执行的操作
+// （新增、删除、已存在跳过、类型冲突等），不写回任何文件，返回进程退出码
+func runPlan() int {
+	config, err := loadConfig()
+	if err != nil {
+		log.Printf("解析配置失败: %v", err)
+		return 1
+	}
+	selected, err := ruleFilter()
+	if err != nil {
+		log.Printf("获取暂存文件失败: %v", err)
+		return 1
+	}
+
+	var ops []logic.PlanOp
+	failed := false
+	contents := make(map[string][]byte)
+	restore := quietLogs()
+	for _, rule := range config.Rules {
+		if !selected(rule) {
+			continue
+		}
+		result, err := applyRule(config, rule, contents)
+		if err != nil {
+			failed = true
+			detail := err.Error()
+			if syntaxErr := syntaxError(err); syntaxErr != nil {
+				detail = syntaxErr.Short()
+			}
+			ops = append(ops, logic.PlanOp{Rule: rule.Name(), File: rule.File, Action: logic.PlanError, Detail: detail})
+			continue
+		}
+		ops = append(ops, planOps(result)...)
+		if result.Modified() {
+			contents[result.Filename] = result.After
+			for _, edit := range result.Others {
+				contents[edit.Filename] = edit.After
+			}
+		}
+	}
+
+	restore()
+
+	if err := logic.WritePlan(os.Stdout, ops); err != nil {
+		log.Printf("输出计划失败: %v", err)
+		return 1
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// planOps 将规则执行结果展开为计划中的操作
+func planOps(result *ruleResult) []logic.PlanOp {
+	name := result.Rule.Name()
+	file := result.Rule.File
+	if result.Skipped {
+		return []logic.PlanOp{{Rule: name, File: file, Action: logic.PlanSkipWhen, Target: "-", Detail: result.Rule.When}}
+	}
+
+	var ops []logic.PlanOp
+	op := func(action, target, detail string) {
+		ops = append(ops, logic.PlanOp{Rule: name, File: file, Action: action, Target: target, Detail: detail})
+	}
+	for _, path := range result.Imports {
+		op(logic.PlanImport, path, "")
+	}
+	for _, change := range result.Changes {
+		for _, field := range change.Added {
+			op(logic.PlanAdd, change.Struct+"."+field, "")
+		}
+		for _, field := range change.Removed {
+			op(logic.PlanRemove, change.Struct+"."+field, "")
+		}
+		for _, method := range change.RemovedMethods {
+			op(logic.PlanRemove, change.Struct+"."+method+"()", "")
+		}
+		for _, rn := range change.Receivers {
+			method, detail, _ := strings.Cut(rn, ": ")
+			op(logic.PlanRename, change.Struct+"."+method+"()", "接收者 "+detail)
+		}
+		for _, rn := range change.Renamed {
+			field, detail, _ := strings.Cut(rn, " ")
+			op(logic.PlanRename, change.Struct+"."+field, detail)
+		}
+		for _, field := range change.Deprecated {
+			op(logic.PlanDeprecate, change.Struct+"."+field, "")
+		}
+		for _, w := range change.Widened {
+			field, detail, _ := strings.Cut(w, ": ")
+			op(logic.PlanWiden, change.Struct+"."+field, detail)
+		}
+		if change.RenamedTo != "" {
+			op(logic.PlanRename, change.Struct, "-> "+change.RenamedTo)
+		}
+	}
+	for _, existing := range result.Existing {
+		op(existing.Action, existing.Target, existing.Detail)
+	}
+	if result.Header {
+		op(logic.PlanHeader, "-", "添加文件头")
+	}
+	if result.Footer {
+		op(logic.PlanHeader, "-", "更新来源注释")
+	}
+	for _, decl := range result.Decls {
+		op(logic.PlanDecl, decl, "")
+	}
+	for _, decl := range result.Regenerated {
+		op(logic.PlanDecl, decl, "按字段重新生成")
+	}
+	for _, clash := range result.Clashes {
+		op(logic.PlanConflict, clash.Struct+"."+strings.Join(clash.Fields, ","), fmt.Sprintf("%s 名称都是 %q", clash.Key, clash.Name))
+	}
+	for _, st := range result.Missing {
+		op(logic.PlanMissing, st, "文件中没有找到结构体")
+	}
+	for _, edit := range result.Others {
+		rel, err := filepath.Rel(*rootPath, edit.Filename)
+		if err != nil {
+			rel = edit.Filename
+		}
+		op(logic.PlanRewrite, rel, edit.Reason)
+	}
+	if len(ops) == 0 {
+		op(logic.PlanNoop, "-", "")
+	}
+	return ops
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var printEffectiveConfig = flag.Bool("print-config", false, "print the effective config as TOML, after extends, include, vars, fieldsets and presets are applied, and exit without running any rule")
+
+// writeEffectiveConfig 输出实际生效的配置
+func writeEffectiveConfig(config *logic.Config) int {
+	if err := logic.WriteConfig(os.Stdout, config); err != nil {
+		fmt.Fprintf(os.Stderr, "输出配置失败: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// ensureProvenance 按配置在文件末尾写入来源注释，列出以同一文件为目标的全部规则，
+// 没有 id 的规则以文件名为名称，重复的名称只列一次
+func ensureProvenance(config *logic.Config, rule *logic.Rule, src []byte) ([]byte, bool, error) {
+	var rules []string
+	seen := make(map[string]bool)
+	for _, r := range config.Rules {
+		if r.File == rule.File && !seen[r.Name()] {
+			seen[r.Name()] = true
+			rules = append(rules, r.Name())
+		}
+	}
+	var hash string
+	if config.Provenance.ConfigHash {
+		var err error
+		if hash, err = logic.ConfigHash(config); err != nil {
+			return nil, false, err
+		}
+	}
+	comment := config.Provenance.Comment(rules, provenanceConfig(), hash)
+	return logic.EnsureFooter(src, comment)
+}
+
+// provenanceConfig 返回来源注释中记录的配置位置，与执行时的工作目录和本机路径无关：
+// 远程配置去掉地址中的用户信息、查询参数和片段，本地配置记录相对 -path 的路径，不在 -path 下时只记录文件名
+func provenanceConfig() string {
+	if logic.IsRemoteConfig(*configPath) {
+		if u, err := url.Parse(*configPath); err == nil {
+			u.User, u.RawQuery, u.ForceQuery, u.Fragment = nil, "", false, ""
+			return u.String()
+		}
+	}
+	if abs, err := filepath.Abs(*configPath); err == nil {
+		if root, err := filepath.Abs(*rootPath); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return filepath.Base(*configPath)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// renamesStructs 判断配置中是否有结构体改名。改名会改写多个文件，只写入其中一部分会使代码无法编译，
+// 因此总是按 -atomic-run 校验后一起写回
+func renamesStructs(config *logic.Config) bool {
+	for _, rule := range config.Rules {
+		for _, st := range rule.Structs {
+			if st.RenameTo != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renameStruct 把目标文件中的结构体改名：在所在包中确定随之改名的派生声明，改写所在包的全部文件，
+// 再改写 -path 下导入了该包的文件中的引用。返回改写后的目标文件源码
+func renameStruct(result *ruleResult, rn logic.StructRename, src []byte, contents map[string][]byte) ([]byte, error) {
+	dir := filepath.Dir(result.Filename)
+	files, err := logic.AuditFiles(*rootPath, dir, "package", nil)
+	if err != nil {
+		return nil, fmt.Errorf("查找目录 %s 中的文件失败: %v", dir, err)
+	}
+
+	// 同一文件可能已被本规则的其他步骤修改过
+	self := func(filename string) bool {
+		return filepath.Clean(filename) == filepath.Clean(result.Filename)
+	}
+	read := func(filename string) ([]byte, error) {
+		if self(filename) {
+			return src, nil
+		}
+		if edit := otherEdit(result, filename); edit != nil {
+			return edit.After, nil
+		}
+		return readSource(filename, contents)
+	}
+	write := func(filename string, before, after []byte) {
+		switch edit := otherEdit(result, filename); {
+		case self(filename):
+			src = after
+		case edit != nil:
+			edit.After = after
+		default:
+			result.Others = append(result.Others, &fileEdit{Filename: filename, Before: before, After: after, Reason: "改写改名结构体的引用"})
+		}
+	}
+
+	// 所在包的文件（包括包内测试）按名称改写，外部测试包和其他包按导入改写
+	pkgName, err := packageNameOf(src)
+	if err != nil {
+		return nil, fmt.Errorf("解析文件 %s 失败: %v", result.Filename, err)
+	}
+	var inner []string
+	var parsed []*ast.File
+	for _, filename := range files {
+		fileSrc, err := read(filename)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filename, fileSrc, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("解析文件 %s 失败: %v", filename, err)
+		}
+		if file.Name.Name == pkgName {
+			inner = append(inner, filename)
+			parsed = append(parsed, file)
+		}
+	}
+	renames, err := logic.RenameDecls(parsed, rn)
+	if err != nil {
+		return nil, fmt.Errorf("结构体 %s 改名失败: %v", rn.Struct, err)
+	}
+
+	changed := make(map[string]bool)
+	for _, filename := range inner {
+		fileSrc, err := read(filename)
+		if err != nil {
+			return nil, err
+		}
+		out, _, err := logic.RenameIdents(filename, fileSrc, renames)
+		if err != nil {
+			return nil, fmt.Errorf("结构体 %s 改名失败: %v", rn.Struct, err)
+		}
+		if !bytes.Equal(out, fileSrc) {
+			write(filename, fileSrc, out)
+			changed[filename] = true
+		}
+	}
+
+	rel, err := filepath.Rel(*rootPath, dir)
+	if err != nil {
+		return nil, err
+	}
+	importPath, err := logic.PackageImportPath(*rootPath, filepath.ToSlash(rel))
+	if err != nil {
+		logic.Warnf("无法确定结构体 %s 所在包的导入路径，只改写了包内的引用: %v", rn.Struct, err)
+	} else {
+		all, err := logic.AuditFiles(*rootPath, *rootPath, "module", result.Rule.Exclude)
+		if err != nil {
+			return nil, fmt.Errorf("查找需要改写的文件失败: %v", err)
+		}
+		isInner := make(map[string]bool, len(inner))
+		for _, filename := range inner {
+			isInner[filepath.Clean(filename)] = true
+		}
+		for _, filename := range all {
+			if isInner[filepath.Clean(filename)] {
+				continue
+			}
+			fileSrc, err := read(filename)
+			if err != nil {
+				return nil, err
+			}
+			out, n, err := logic.RenameQualified(filename, fileSrc, importPath, pkgName, renames)
+			if err != nil {
+				return nil, fmt.Errorf("结构体 %s 改名失败: %v", rn.Struct, err)
+			}
+			if n > 0 {
+				write(filename, fileSrc, out)
+				changed[filename] = true
+			}
+		}
+	}
+
+	var derived []string
+	for name, to := range renames {
+		if name != rn.Struct {
+			derived = append(derived, name+" -> "+to)
+		}
+	}
+	sort.Strings(derived)
+	if len(derived) > 0 {
+		log.Printf("已将结构体 %s 改名为 %s，一起改名的声明: %s；改写了 %d 个文件", rn.Struct, rn.To, strings.Join(derived, ", "), len(changed))
+	} else {
+		log.Printf("已将结构体 %s 改名为 %s，改写了 %d 个文件", rn.Struct, rn.To, len(changed))
+	}
+	structChange(result, rn.Struct).RenamedTo = rn.To
+	return src, nil
+}
+
+// packageNameOf 返回源码的包名
+func packageNameOf(src []byte) (string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly)
+	if err != nil {
+		return "", err
+	}
+	return file.Name.Name, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// runReport 执行 report 子命令：列出配置中的结构体及其字段数、标签覆盖情况和大小估算，
+// 用于发现在多次自动添加字段后需要重构的模型，返回进程退出码
+func runReport() int {
+	config, err := loadConfig()
+	if err != nil {
+		log.Printf("解析配置失败: %v", err)
+		return 1
+	}
+	structs, err := exportStructs(config)
+	if err != nil {
+		log.Printf("读取结构体失败: %v", err)
+		return 1
+	}
+
+	reports := []logic.StructReport{}
+	for _, info := range structs {
+		filename := filepath.Join(*rootPath, info.File)
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			log.Printf("读取文件失败: %v", err)
+			return 1
+		}
+		_, src, _ = logic.SplitBOM(src)
+		reports = append(reports, logic.BuildReport(info, logic.StructSize(filename, src, info.Name, config.GoVersion)))
+	}
+
+	switch *outputFormat {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(reports)
+	case "text":
+		err = logic.WriteReport(os.Stdout, reports)
+	default:
+		log.Printf("不支持的输出格式: %s", *outputFormat)
+		return 1
+	}
+	if err != nil {
+		log.Printf("输出报告失败: %v", err)
+		return 1
+	}
+	return 0
+}
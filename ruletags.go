@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var onlyTags = flag.String("only", "", "comma-separated rule tags; only rules carrying at least one of them are applied")
+var skipTags = flag.String("skip", "", "comma-separated rule tags; rules carrying any of them are not applied, even when selected by -only")
+
+// tagSelected 返回规则是否被 -only 和 -skip 选中
+func tagSelected(rule *logic.Rule) bool {
+	return logic.MatchTags(rule.Tags, logic.SplitTags(*onlyTags), logic.SplitTags(*skipTags))
+}
+
+// filteredRules 返回被 -only 和 -skip 排除的规则名称
+func filteredRules(config *logic.Config) []string {
+	var names []string
+	for _, rule := range config.Rules {
+		if !tagSelected(rule) {
+			names = append(names, rule.Name())
+		}
+	}
+	return names
+}
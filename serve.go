@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var listenAddr = flag.String("listen", ":8080", "address the serve command listens on")
+
+// metrics 进程内的运行指标，serve 模式下通过 /metrics 导出
+var metrics = logic.NewMetrics()
+
+// errorType 返回错误的类型标签，用于按类型统计失败次数，与响应中的错误码一致
+func errorType(err error) string {
+	return logic.ErrorCode(err)
+}
+
+// errorResponse 返回失败响应的内容：原始的错误信息、错误码和按请求的 Accept-Language 选择语言的说明
+func errorResponse(r *http.Request, code string, err error) map[string]interface{} {
+	return map[string]interface{}{
+		"error":   err.Error(),
+		"code":    code,
+		"message": logic.ErrorMessage(code, logic.MatchLanguage(r.Header.Get("Accept-Language"))),
+	}
+}
+
+// runServe 执行 serve 子命令：以守护进程方式运行，按请求执行规则并导出指标
+//
+//	POST /apply   重新读取配置并执行所有规则
+//	POST /check   重新读取配置并检查，返回 JSON 格式的问题列表
+//	GET  /metrics Prometheus 指标
+//	GET  /healthz 健康检查
+//
+// 失败时响应中带有错误码（code）和按 Accept-Language 选择中文或英文的说明（message），
+// 问题列表中规则无法执行的问题也带有错误码
+func runServe() int {
+	// 同一时间只允许一次执行，避免并发写同一文件
+	var mu sync.Mutex
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apply", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+
+		config, err := logic.ParseConfig(*configPath)
+		if err != nil {
+			metrics.ObserveError(logic.CodeConfig)
+			writeJSON(w, http.StatusBadRequest, errorResponse(r, logic.CodeConfig, err))
+			return
+		}
+		restore := quietLogs()
+		results, err := applyConfig(config)
+		restore()
+		// 多条规则修改同一个文件时只列出一次
+		var modified []string
+		seen := make(map[string]bool)
+		for _, result := range results {
+			if result.Modified() && !seen[result.Rule.File] {
+				seen[result.Rule.File] = true
+				modified = append(modified, result.Rule.File)
+			}
+		}
+		if err != nil {
+			resp := errorResponse(r, errorType(err), err)
+			resp["modified"] = modified
+			writeJSON(w, http.StatusInternalServerError, resp)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"modified": modified})
+	})
+	mux.HandleFunc("/check", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+
+		findings := checkFindings()
+		status := http.StatusOK
+		if logic.Failed(findings) {
+			status = http.StatusConflict
+		}
+		// 附上问题中出现的错误码的说明
+		lang := logic.MatchLanguage(r.Header.Get("Accept-Language"))
+		messages := make(map[string]string)
+		for _, f := range findings {
+			if f.Code != "" {
+				messages[f.Code] = logic.ErrorMessage(f.Code, lang)
+			}
+		}
+		writeJSON(w, status, map[string]interface{}{"findings": findings, "messages": messages})
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if _, err := metrics.WriteTo(w); err != nil {
+			log.Printf("导出指标失败: %v", err)
+		}
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+
+	log.Printf("astauto serve 监听 %s", *listenAddr)
+	if err := http.ListenAndServe(*listenAddr, mux); err != nil {
+		log.Printf("服务退出: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// writeJSON 以 JSON 格式写出响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("写出响应失败: %v", err)
+	}
+}
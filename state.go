@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var stateDirFlag = flag.String("state-dir", "", "directory for the run lock and other on-disk state, overriding state_dir in the config (relative paths are resolved against the module root)")
+var noState = flag.Bool("no-state", false, "keep no on-disk state at all, not even the run lock, for hermetic build systems such as Bazel")
+
+// stateDir 返回本次运行的运行状态目录，标志优先于配置；禁用运行状态时返回 false
+func stateDir(config *logic.Config) (string, bool) {
+	if *noState || *hermetic || config.DisableState {
+		return "", false
+	}
+	dir := config.StateDir
+	if *stateDirFlag != "" {
+		dir = *stateDirFlag
+	}
+	return logic.StateDir(*rootPath, dir), true
+}
+
+// loadLifecycle 读取 deprecation.journal 中字段的废弃记录
+func loadLifecycle(config *logic.Config) (logic.Lifecycle, error) {
+	return logic.LoadLifecycle(config.Deprecation.JournalPath(*rootPath))
+}
+
+// recordLifecycle 把本次写回的废弃和删除记入 deprecation.journal，没有配置废弃流程时不记录
+func recordLifecycle(config *logic.Config, results []*ruleResult) error {
+	if !config.Deprecation.Enabled() {
+		return nil
+	}
+	var lifecycle logic.Lifecycle
+	changed := false
+	now := time.Now()
+	for _, result := range results {
+		if result.Skipped || len(result.Deprecating) == 0 && !removesFields(result) {
+			continue
+		}
+		if lifecycle == nil {
+			var err error
+			if lifecycle, err = loadLifecycle(config); err != nil {
+				return err
+			}
+		}
+		for _, d := range result.Deprecating {
+			if lifecycle.MarkDeprecated(result.Rule.File, d.Struct, d.Field, now) {
+				changed = true
+			}
+		}
+		for _, change := range result.Changes {
+			for _, field := range change.Removed {
+				lifecycle.MarkRemoved(result.Rule.File, change.Struct, field, now)
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+	path := config.Deprecation.JournalPath(*rootPath)
+	if err := lifecycle.Save(path); err != nil {
+		return err
+	}
+	log.Printf("已把字段的废弃阶段记入 %s，这个文件需要和代码一起提交", path)
+	return nil
+}
+
+// removesFields 返回规则是否删除了字段
+func removesFields(result *ruleResult) bool {
+	for _, change := range result.Changes {
+		if len(change.Removed) > 0 {
+			return true
+		}
+	}
+	return false
+}
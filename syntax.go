@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/afantree/astauto/logic"
+)
+
+var keepGoing = flag.Bool("keep-going", false, "when a rule's target file has syntax errors, report them and continue with the other rules instead of aborting the run")
+
+// isSyntaxError 判断规则失败是否由目标文件的语法错误导致
+func isSyntaxError(err error) bool {
+	return syntaxError(err) != nil
+}
+
+// syntaxError 取出规则失败原因中的语法错误，没有时返回 nil
+func syntaxError(err error) *logic.SyntaxError {
+	var syntaxErr *logic.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr
+	}
+	return nil
+}
+
+// applyImportsOnly 目标文件有语法错误且规则设置了 on_syntax_error = "imports" 时，
+// 在不完整的语法树上只添加规则的导入，结构体、代码片段和执行条件都不处理
+func applyImportsOnly(rule *logic.Rule, result *ruleResult, bom, src []byte, syntaxErr error) (*ruleResult, error) {
+	log.Printf("规则 %s 的目标文件有语法错误，只添加导入: %v", rule.Name(), syntaxErr)
+	if rule.When != "" || len(rule.Structs) > 0 || len(rule.ApplySnippets) > 0 {
+		log.Printf("规则 %s 的执行条件、结构体和代码片段已跳过", rule.Name())
+	}
+	imports, err := logic.GateImports(rule.Imports, rule.GoVersion)
+	if err != nil {
+		return nil, fmt.Errorf("导入不符合目标 Go 版本: %v", err)
+	}
+	src, added, _, err := logic.AddImportsPartial(src, imports, rule.ImportConflict)
+	if err != nil {
+		return nil, fmt.Errorf("解析文件失败: %w；无法在不完整的语法树上添加导入: %v", syntaxErr, err)
+	}
+	for _, path := range added {
+		log.Printf("添加导入: %s", path)
+	}
+	result.Imports = added
+	result.Partial, _ = syntaxErr.(*logic.SyntaxError)
+	result.After = append(bom, src...)
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+
+	"github.com/afantree/astauto/logic"
+)
+
+// runValidate 执行 validate 子命令：解析配置，检查规则引用的文件和结构体是否存在、能否解析，
+// 字段的类型和标签是否有效，不修改任何文件，有错误时返回非零退出码
+func runValidate() int {
+	return writeFindings(validateFindings())
+}
+
+// validateFindings 收集配置中所有规则的问题，一个规则的问题不影响检查其他规则
+func validateFindings() []logic.Finding {
+	config, err := loadConfig()
+	if err != nil {
+		return []logic.Finding{{
+			Kind:    logic.FindingInvalid,
+			Level:   logic.LevelError,
+			File:    *configPath,
+			Message: fmt.Sprintf("解析配置失败: %v", err),
+		}}
+	}
+
+	var findings []logic.Finding
+	for _, rule := range config.Rules {
+		filename := filepath.Join(*rootPath, rule.File)
+		invalid := func(format string, args ...interface{}) {
+			findings = append(findings, logic.Finding{
+				Kind:    logic.FindingInvalid,
+				Level:   logic.LevelError,
+				Rule:    rule.Name(),
+				File:    filename,
+				Message: fmt.Sprintf("规则 %s: ", rule.Name()) + fmt.Sprintf(format, args...),
+			})
+		}
+
+		for _, st := range rule.Structs {
+			for _, field := range st.Fields {
+				if err := logic.ValidateField(field); err != nil {
+					invalid("结构体 %s: %v", st.Name, err)
+				}
+			}
+		}
+
+		src, err := os.ReadFile(filename)
+		if errors.Is(err, os.ErrNotExist) && rule.Variant != "" {
+			// 变体文件不存在时会按骨架创建
+			continue
+		}
+		if err != nil {
+			invalid("读取文件失败: %v", err)
+			continue
+		}
+		if _, src, err = logic.SplitBOM(src); err != nil {
+			invalid("文件编码不受支持: %v", err)
+			continue
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			invalid("%v", logic.NewSyntaxError(err))
+			continue
+		}
+
+		structs := make(map[string]bool)
+		logic.WalkStructs(file, func(path string, _ *ast.StructType) {
+			structs[path] = true
+		})
+		for _, st := range rule.Structs {
+			name, err := logic.ResolveStruct(fset, filename, file, st.Name, rule.GoVersion)
+			if err != nil {
+				invalid("%v", err)
+				continue
+			}
+			if !structs[name] && !st.Create {
+				invalid("文件中没有结构体 %s", st.Name)
+			}
+		}
+	}
+	return findings
+}